@@ -4,3 +4,12 @@ import "embed"
 
 //go:embed notify.mp3
 var NotifySound embed.FS
+
+//go:embed remote.html
+var RemotePage []byte
+
+// Locales holds the translations shipped with manta, one locales/<lang>.json
+// catalog of messages per language.
+//
+//go:embed locales/*.json
+var Locales embed.FS
@@ -1,18 +1,560 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ihorbryk/manta/internal"
 )
 
 func main() {
-	m := internal.NewModel()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "status":
+			status(os.Args[2:])
+			return
+		case "run":
+			run(os.Args[2:])
+			return
+		case "start":
+			start(os.Args[2:])
+			return
+		case "export":
+			export(os.Args[2:])
+			return
+		case "import":
+			importEntries(os.Args[2:])
+			return
+		case "history":
+			historyCmd(os.Args[2:])
+			return
+		case "invoice":
+			invoice(os.Args[2:])
+			return
+		case "report":
+			report(os.Args[2:])
+			return
+		case "review":
+			review()
+			return
+		case "year":
+			year(os.Args[2:])
+			return
+		case "unblock":
+			unblock()
+			return
+		case "tray":
+			if err := internal.RunTray(); err != nil {
+				fmt.Fprintln(os.Stderr, "manta tray:", err)
+				os.Exit(1)
+			}
+			return
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "shell-init":
+			shellInit(os.Args[2:])
+			return
+		case "hotkeys":
+			hotkeys(os.Args[2:])
+			return
+		case "control":
+			control(os.Args[2:])
+			return
+		case "pause", "resume", "skip", "stop":
+			remote(os.Args[1], os.Args[2:])
+			return
+		case "doctor":
+			if err := internal.Doctor(os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, "manta doctor:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	// Flags without a command are those of run, e.g. manta --profile writing.
+	run(os.Args[1:])
+}
+
+// run starts the timer UI, or a single session without it.
+func run(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	macro := fs.String("macro", "", "replay the named macro on start")
+	plan := fs.String("plan", "", `run a plan of work sessions with breaks in between, e.g. "3x report, 1x email"`)
+	noUI := fs.Bool("no-ui", false, "run one session without the interface and exit when it ends")
+	quiet := fs.Bool("quiet", false, "with -no-ui, don't print progress")
+	work := fs.Duration("work", 0, "with -no-ui, run a work session of this length")
+	rest := fs.Duration("rest", 0, "with -no-ui, run a rest session of this length")
+	preset := fs.String("preset", "", "with -no-ui, run this preset (default the first one)")
+	task := fs.String("task", "", "with -no-ui, what the session is spent on")
+	maxFPS := fs.Int("max-fps", 0, "redraw the screen at most this many times a second, for slow links")
+	profile := fs.String("profile", os.Getenv("MANTA_PROFILE"), "use the settings of this profile")
+	accessible := fs.Bool("accessible", false, "plain output for screen readers")
+	_ = fs.Parse(args)
+
+	cfg, player, notifier := setup(*profile)
+	if *maxFPS > 0 {
+		cfg.MaxFPS = *maxFPS
+	}
+	if *accessible {
+		cfg.Accessible = true
+	}
+
+	if *noUI {
+		p, err := internal.FindPreset(cfg.Presets, *preset)
+		switch {
+		case *work > 0:
+			p, err = internal.Preset{Name: internal.WORKTIME, Duration: internal.Duration(*work), Phase: internal.WORKTIME}, nil
+		case *rest > 0:
+			p, err = internal.Preset{Name: internal.RESTTIME, Duration: internal.Duration(*rest), Phase: internal.RESTTIME}, nil
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "manta run:", err)
+			os.Exit(2)
+		}
+		headless(cfg, player, notifier, p, *task, *quiet)
+		return
+	}
+
+	m, err := internal.NewModel(cfg, player, notifier)
+	if err != nil {
+		fmt.Println("Failed to set up:", err)
+		os.Exit(1)
+	}
+	if *macro != "" {
+		if m, err = m.WithMacro(*macro); err != nil {
+			fmt.Println("Failed to set up:", err)
+			os.Exit(1)
+		}
+	}
+	if *plan != "" {
+		if m, err = m.WithPlan(*plan); err != nil {
+			fmt.Println("Failed to set up:", err)
+			os.Exit(1)
+		}
+	}
+	ui(m, cfg.MaxFPS)
+}
+
+// start opens the timer UI with a session scheduled to start at a given
+// time, e.g. `manta start work -at 14:00`.
+func start(args []string) {
+	var preset string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		preset, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("start", flag.ExitOnError)
+	at := fs.String("at", "", "when to start, as HH:MM")
+	maxFPS := fs.Int("max-fps", 0, "redraw the screen at most this many times a second, for slow links")
+	profile := fs.String("profile", os.Getenv("MANTA_PROFILE"), "use the settings of this profile")
+	accessible := fs.Bool("accessible", false, "plain output for screen readers")
+	_ = fs.Parse(args)
+
+	if *at == "" {
+		fmt.Fprintln(os.Stderr, "manta start: -at is required")
+		os.Exit(2)
+	}
+	when, err := internal.ParseClock(*at, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta start:", err)
+		os.Exit(2)
+	}
+
+	cfg, player, notifier := setup(*profile)
+	if *maxFPS > 0 {
+		cfg.MaxFPS = *maxFPS
+	}
+	if *accessible {
+		cfg.Accessible = true
+	}
+	m, err := internal.NewModel(cfg, player, notifier)
+	if err != nil {
+		fmt.Println("Failed to set up:", err)
+		os.Exit(1)
+	}
+	if m, err = m.WithSchedule(preset, when); err != nil {
+		fmt.Fprintln(os.Stderr, "manta start:", err)
+		os.Exit(2)
+	}
+	ui(m, cfg.MaxFPS)
+}
+
+// setup loads the config with the given profile, if any, and the sound
+// and notification backends it describes, exiting on errors.
+func setup(profile string) (internal.Config, *internal.Player, internal.Notifier) {
+	cfg, err := internal.LoadProfile(profile)
+	if err != nil {
+		fmt.Println("Failed to load config:", err)
+		os.Exit(1)
+	}
+
+	player, err := internal.NewPlayer(cfg.Sounds)
+	if err != nil {
+		fmt.Println("Failed to load sounds:", err)
+		os.Exit(1)
+	}
+
+	notifier, err := internal.NewNotifier(cfg.Notify)
+	if err != nil {
+		fmt.Println("Failed to set up notifications:", err)
+		os.Exit(1)
+	}
+	return cfg, player, notifier
+}
+
+// ui runs the timer UI until it quits, redrawing at most maxFPS times a
+// second when set. When a profile is picked in the UI, it starts over with
+// that profile, keeping the frame limit unless the profile sets one.
+func ui(m tea.Model, maxFPS int) {
+	for {
+		var opts []tea.ProgramOption
+		if maxFPS > 0 {
+			opts = append(opts, tea.WithFPS(maxFPS))
+		}
+		p := tea.NewProgram(m, opts...)
+		stopControl, err := internal.ServeControl(p)
+		if err != nil {
+			// The timer works without it, it just can't be driven from the tray.
+			stopControl = func() {}
+		}
+		final, err := p.Run()
+		stopControl()
+		internal.Shutdown(final)
+		if err != nil {
+			fmt.Println("Oh no!", err)
+			os.Exit(1)
+		}
+
+		profile, ok := internal.PickedProfile(final)
+		if !ok {
+			return
+		}
+		cfg, player, notifier := setup(profile)
+		if cfg.MaxFPS == 0 {
+			cfg.MaxFPS = maxFPS
+		}
+		if m, err = internal.NewModel(cfg, player, notifier); err != nil {
+			fmt.Println("Failed to set up:", err)
+			os.Exit(1)
+		}
+		maxFPS = cfg.MaxFPS
+	}
+}
+
+// headless runs a single session without the UI. It exits with 0 when the
+// session ends, 130 when interrupted and 1 on errors.
+func headless(cfg internal.Config, player *internal.Player, notifier internal.Notifier, p internal.Preset, task string, quiet bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var out io.Writer = os.Stdout
+	if quiet {
+		out = io.Discard
+	}
+
+	err := internal.RunHeadless(ctx, cfg, player, notifier, p, task, out)
+	switch {
+	case errors.Is(err, internal.ErrInterrupted):
+		os.Exit(130)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "manta run:", err)
+		os.Exit(1)
+	}
+}
+
+// status prints the state of the running timer in a single line.
+func status(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	format := fs.String("format", internal.DefaultStatusFormat, "Go template for the status line (fields: Icon, Phase, Preset, Task, Remaining, End, Paused)")
+	_ = fs.Parse(args)
+
+	if err := internal.PrintStatus(os.Stdout, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "manta status:", err)
+		os.Exit(1)
+	}
+}
+
+// shellInit prints the prompt and alias snippet for a shell.
+func shellInit(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: manta shell-init zsh|bash|fish")
+		os.Exit(2)
+	}
+	if err := internal.ShellInit(os.Stdout, args[0]); err != nil {
+		fmt.Fprintln(os.Stderr, "manta shell-init:", err)
+		os.Exit(2)
+	}
+}
+
+// hotkeys prints the global keys as bindings for a hotkey daemon.
+func hotkeys(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: manta hotkeys skhd|sxhkd|sway|hyprland|gnome")
+		os.Exit(2)
+	}
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta hotkeys:", err)
+		os.Exit(1)
+	}
+	if err := internal.Hotkeys(os.Stdout, args[0], cfg.GlobalKeys); err != nil {
+		fmt.Fprintln(os.Stderr, "manta hotkeys:", err)
+		os.Exit(2)
+	}
+}
+
+// control performs a key action in the running manta, for global hotkeys
+// and scripts.
+func control(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: manta control <action>")
+		os.Exit(2)
+	}
+	if err := internal.Control(args[0]); err != nil {
+		fmt.Fprintln(os.Stderr, "manta control:", err)
+		os.Exit(1)
+	}
+}
+
+// remote pauses, resumes, skips or stops the running session from another
+// shell and prints what it left running.
+func remote(command string, args []string) {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the state as JSON")
+	_ = fs.Parse(args)
+
+	if err := internal.Remote(os.Stdout, command, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, "manta "+command+":", err)
+		os.Exit(1)
+	}
+}
+
+// export dumps the session history for spreadsheets and other tools.
+func export(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", internal.FormatCSV, "output format, csv or json")
+	from := fs.String("from", "", "first day to export, as YYYY-MM-DD")
+	to := fs.String("to", "", "last day to export, as YYYY-MM-DD")
+	_ = fs.Parse(args)
+
+	start, end := dayRange("export", *from, *to)
+	if err := internal.ExportHistory(os.Stdout, *format, start, end); err != nil {
+		fmt.Fprintln(os.Stderr, "manta export:", err)
+		os.Exit(1)
+	}
+}
+
+// importEntries merges time entries logged elsewhere into the history.
+func importEntries(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", internal.FormatCSV, "input format, csv or json")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: manta import [-format csv|json] FILE (- for stdin)")
+		os.Exit(2)
+	}
+
+	var in io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "manta import:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	added, skipped, err := internal.ImportEntries(in, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta import:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d entries", added)
+	if skipped > 0 {
+		fmt.Printf(", skipped %d already in the history", skipped)
+	}
+	fmt.Println()
+}
+
+// historyCmd manages the session history: `manta history redact` applies
+// the privacy settings to the sessions saved before them.
+func historyCmd(args []string) {
+	if len(args) == 0 || args[0] != "redact" {
+		fmt.Fprintln(os.Stderr, "usage: manta history redact [-notes] [-tasks]")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("history redact", flag.ExitOnError)
+	notes := fs.Bool("notes", false, "drop session and interruption notes (default from privacy.drop_notes)")
+	tasks := fs.Bool("tasks", false, "hash task names (default from privacy.hash_tasks)")
+	_ = fs.Parse(args[1:])
+
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta history:", err)
+		os.Exit(1)
+	}
+	privacy := cfg.Privacy
+	if *notes || *tasks {
+		privacy = internal.PrivacyConfig{DropNotes: *notes, HashTasks: *tasks}
+	}
+	changed, err := internal.RedactHistory(privacy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta history:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Redacted %d sessions\n", changed)
+}
+
+// parseDay parses a YYYY-MM-DD date in local time. An empty string yields
+// the zero time.
+func parseDay(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(time.DateOnly, s, time.Local)
+}
+
+// invoice prints a billable-hours summary of tagged work.
+func invoice(args []string) {
+	fs := flag.NewFlagSet("invoice", flag.ExitOnError)
+	format := fs.String("format", internal.FormatMarkdown, "output format, markdown or csv")
+	from := fs.String("from", "", "first day to bill, as YYYY-MM-DD")
+	to := fs.String("to", "", "last day to bill, as YYYY-MM-DD")
+	_ = fs.Parse(args)
+
+	start, end := dayRange("invoice", *from, *to)
+
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta invoice:", err)
+		os.Exit(1)
+	}
+	if err := internal.PrintInvoice(os.Stdout, *format, cfg.Billing, start, end); err != nil {
+		fmt.Fprintln(os.Stderr, "manta invoice:", err)
+		os.Exit(1)
+	}
+}
+
+// report prints a summary of this week's or this month's work.
+func report(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	week := fs.Bool("week", true, "report on this week (the default)")
+	month := fs.Bool("month", false, "report on this month instead of this week")
+	format := fs.String("format", internal.FormatText, "output format, text or markdown")
+	_ = fs.Parse(args)
 
-	if _, err := tea.NewProgram(m).Run(); err != nil {
-		fmt.Println("Oh no!", err)
+	weekSet := false
+	fs.Visit(func(f *flag.Flag) { weekSet = weekSet || f.Name == "week" })
+	if *month && weekSet && *week {
+		fmt.Fprintln(os.Stderr, "manta report: -week and -month can't be used together")
+		os.Exit(2)
+	}
+
+	start, end := internal.Week(time.Now())
+	title := "Week of " + start.Format("2 January 2006")
+	if *month {
+		now := time.Now()
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		end = start.AddDate(0, 1, 0)
+		title = start.Format("January 2006")
+	}
+
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta report:", err)
+		os.Exit(1)
+	}
+	if err := internal.PrintReport(os.Stdout, *format, title, start, end, cfg.Budgets); err != nil {
+		fmt.Fprintln(os.Stderr, "manta report:", err)
+		os.Exit(1)
+	}
+}
+
+// year prints the "year in focus" summary.
+func year(args []string) {
+	fs := flag.NewFlagSet("year", flag.ExitOnError)
+	y := fs.Int("year", time.Now().Year(), "the year to sum up")
+	format := fs.String("format", internal.FormatMarkdown, "output format, markdown or html")
+	_ = fs.Parse(args)
+
+	if err := internal.PrintYear(os.Stdout, *format, *y); err != nil {
+		fmt.Fprintln(os.Stderr, "manta year:", err)
+		os.Exit(1)
+	}
+}
+
+// review walks through last week's stats and asks for reflections.
+func review() {
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta review:", err)
+		os.Exit(1)
+	}
+	if err := internal.RunReview(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "manta review:", err)
+		os.Exit(1)
+	}
+}
+
+// dayRange parses the -from and -to flags of cmd into a half-open range
+// covering both days, exiting on invalid dates.
+func dayRange(cmd, from, to string) (time.Time, time.Time) {
+	start, err := parseDay(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "manta %s: -from: %v\n", cmd, err)
+		os.Exit(2)
+	}
+	end, err := parseDay(to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "manta %s: -to: %v\n", cmd, err)
+		os.Exit(2)
+	}
+	if !end.IsZero() {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// serve runs the HTTP API next to the TUI until interrupted.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", internal.DefaultListen, "address to listen on")
+	token := fs.String("token", os.Getenv("MANTA_TOKEN"), "bearer token requests must carry (default $MANTA_TOKEN)")
+	login := fs.String("login", os.Getenv("MANTA_LOGIN"), "user:password for basic auth, e.g. for the control page (default $MANTA_LOGIN)")
+	_ = fs.Parse(args)
+	if *login != "" && !strings.Contains(*login, ":") {
+		fmt.Fprintln(os.Stderr, "manta serve: -login must be user:password")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := internal.Serve(ctx, *listen, *token, *login); err != nil {
+		fmt.Fprintln(os.Stderr, "manta serve:", err)
+		os.Exit(1)
+	}
+}
+
+// unblock removes the sites strict mode blocked, in case manta couldn't.
+func unblock() {
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "manta unblock:", err)
+		os.Exit(1)
+	}
+	if err := internal.Unblock(cfg.Strict); err != nil {
+		fmt.Fprintln(os.Stderr, "manta unblock:", err)
 		os.Exit(1)
 	}
 }
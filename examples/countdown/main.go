@@ -0,0 +1,42 @@
+// Command countdown runs a single session with manta's timer engine,
+// printing the time left every second and a warning a minute before the
+// end.
+//
+//	go run ./examples/countdown 5m
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+func main() {
+	length := 25 * time.Minute
+	if len(os.Args) > 1 {
+		d, err := time.ParseDuration(os.Args[1])
+		if err != nil || d <= 0 {
+			fmt.Fprintln(os.Stderr, "usage: countdown [duration]")
+			os.Exit(2)
+		}
+		length = d
+	}
+
+	t := timer.Start(length, time.Now())
+	t.WarnBefore(time.Now(), time.Minute)
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for now := range tick.C {
+		switch t.Tick(now) {
+		case timer.Warning:
+			fmt.Println("\nOne minute left")
+		case timer.Completed:
+			fmt.Println("\nDone")
+			return
+		}
+		fmt.Printf("\r%s left ", time.Duration(t.SecondsLeft(now))*time.Second)
+	}
+}
@@ -0,0 +1,77 @@
+// Command cycle alternates work sessions and breaks, reporting each change
+// to its listeners the way manta's event hooks do. It shows how a program
+// embedding the timer engine can fire events of its own on top of Tick and
+// deliver them through an event.Bus.
+//
+//	go run ./examples/cycle -work 25m -rest 5m -rounds 4
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ihorbryk/manta/pkg/event"
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+// warning is the event fired as a session nears its end. manta has no
+// such event; a bus carries events of any name.
+const warning = "warning"
+
+func main() {
+	work := flag.Duration("work", 25*time.Minute, "length of a work session")
+	rest := flag.Duration("rest", 5*time.Minute, "length of a break")
+	rounds := flag.Int("rounds", 4, "work sessions to run")
+	flag.Parse()
+
+	var bus event.Bus
+	bus.Subscribe(func(ev event.Event) error {
+		fmt.Printf("%s %s %s\n", ev.Time.Format("15:04:05"), ev.Phase, ev.Name)
+		return nil
+	})
+
+	for round := 0; round < *rounds; round++ {
+		run(&bus, "work", *work)
+		if round < *rounds-1 {
+			run(&bus, "rest", *rest)
+		}
+	}
+}
+
+// run times one session of length, emitting its events on bus.
+func run(bus *event.Bus, phase string, length time.Duration) {
+	now := time.Now()
+	t := timer.Start(length, now)
+	t.WarnBefore(now, length/5)
+	emit(bus, t, event.Start, phase, now)
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for now := range tick.C {
+		switch t.Tick(now) {
+		case timer.Warning:
+			emit(bus, t, warning, phase, now)
+		case timer.Completed:
+			emit(bus, t, event.End, phase, now)
+			return
+		}
+	}
+}
+
+// emit emits the event called name of the session t times, reporting
+// listeners that fail.
+func emit(bus *event.Bus, t timer.Timer, name, phase string, now time.Time) {
+	ev := event.Event{
+		Name:      name,
+		Phase:     phase,
+		Preset:    phase,
+		Remaining: t.SecondsLeft(now),
+		EndTime:   t.End(now),
+		Time:      now,
+	}
+	if err := bus.Emit(ev); err != nil {
+		fmt.Fprintln(os.Stderr, "cycle:", err)
+	}
+}
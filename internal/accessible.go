@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// progressStep is how far the progress shown in accessible mode moves at a
+// time, so a screen reader isn't re-reading the screen every second.
+const progressStep = 5
+
+// accessibleView renders the running session as plain sentences, with the
+// time left in whole minutes and the progress in steps of progressStep
+// percent, so the screen only changes when there is news.
+func (m model) accessibleView() string {
+	var s strings.Builder
+	s.WriteString(tr("plain.session", m.preset.Name, cmp.Or(m.task, tr("plain.no_task"))) + "\n")
+
+	state := tr("plain.running")
+	if m.timer.Paused() {
+		state = tr("plain.paused")
+	}
+	switch {
+	case m.timer.Stopwatch():
+		s.WriteString(tr("plain.elapsed", state, minutesText(m.timer.Elapsed(m.now))) + "\n")
+	case m.timer.Overtime():
+		s.WriteString(tr("plain.overtime", minutesText(-m.timer.Remaining(m.now))) + "\n")
+	default:
+		percent := int(m.timer.Progress(m.now)*100) / progressStep * progressStep
+		s.WriteString(tr("plain.left", state, minutesText(m.timer.Remaining(m.now)), m.timer.End(m.now).Format("15:04"), percent) + "\n")
+	}
+
+	s.WriteString("\n" + m.timersView("") + m.tagsView("") + m.interruptionsView("") + m.goalView("") +
+		m.inputView("") + m.helpView("") + m.statusView(""))
+	return s.String()
+}
+
+// minutesText spells out d in whole minutes, rounded up so the time left
+// never reads as less than it is.
+func minutesText(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	switch {
+	case d <= 0:
+		return tr("plain.minutes", 0)
+	case minutes == 1:
+		return tr("plain.minutes_one", 1)
+	}
+	return tr("plain.minutes", minutes)
+}
+
+// announce sends a notification about a change of phase, for screen
+// readers, in accessible mode.
+func (m model) announce(title, message string) tea.Cmd {
+	if !m.cfg.Accessible {
+		return nil
+	}
+	return m.pending.track(notifyCmd(m.notifier, title, message))
+}
+
+// plainText removes emoji and the variation selectors that make symbols
+// render as emoji, which screen readers read out as long descriptions or
+// skip.
+func plainText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == 0xFE0F,
+			r >= 0x1F000 && r <= 0x1FAFF, // pictographs, emoticons, symbols
+			r >= 0x2600 && r <= 0x27BF,   // miscellaneous symbols, dingbats
+			r >= 0x23E9 && r <= 0x23FA,   // media controls such as ⏸ and ⏱
+			r == 0x2B50:
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+)
+
+// Ambient tracks generated on the fly instead of read from a file.
+const (
+	AmbientWhite = "white"
+	AmbientBrown = "brown"
+)
+
+// ambient loops a background track while work sessions run. The track
+// starts and stops on a worker goroutine, since opening the audio device
+// can take a moment, and only the latest request is applied.
+type ambient struct {
+	player *Player
+	track  func() (io.Reader, error)
+	volume float64
+	wants  chan bool
+	done   chan struct{}
+
+	// Owned by the worker.
+	out io.Closer
+}
+
+// newAmbient returns the ambient player for the configured track, or nil
+// when none is set.
+func newAmbient(cfg SoundConfig, player *Player) (*ambient, error) {
+	if cfg.Ambient == "" {
+		return nil, nil
+	}
+
+	volume := 0.5
+	if cfg.AmbientVolume != nil {
+		volume = *cfg.AmbientVolume
+		if volume < 0 || volume > 1 {
+			return nil, fmt.Errorf("ambient volume %v: must be between 0 and 1", volume)
+		}
+	}
+
+	var track func() (io.Reader, error)
+	switch cfg.Ambient {
+	case AmbientWhite, AmbientBrown:
+		brown := cfg.Ambient == AmbientBrown
+		track = func() (io.Reader, error) { return &noise{brown: brown}, nil }
+	default:
+		s, err := loadSound(cfg.Ambient, false)
+		if err != nil {
+			return nil, fmt.Errorf("ambient sound: %w", err)
+		}
+		track = func() (io.Reader, error) { return &loop{sound: s}, nil }
+	}
+
+	a := &ambient{
+		player: player,
+		track:  track,
+		volume: volume,
+		wants:  make(chan bool, 1),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a, nil
+}
+
+// update starts or stops the track.
+func (a *ambient) update(play bool) {
+	if a == nil {
+		return
+	}
+	// Drop a request the worker hasn't picked up yet; it is out of date.
+	select {
+	case <-a.wants:
+	default:
+	}
+	a.wants <- play
+}
+
+// close stops the track and the worker.
+func (a *ambient) close() {
+	if a == nil {
+		return
+	}
+	close(a.wants)
+	<-a.done
+	a.apply(false)
+}
+
+func (a *ambient) run() {
+	defer close(a.done)
+	for play := range a.wants {
+		a.apply(play)
+	}
+}
+
+// apply starts or stops playback. Without a working audio device the
+// track just stays silent.
+func (a *ambient) apply(play bool) {
+	defer func() {
+		// A lost device can make Oto panic; ambient sound is optional.
+		_ = recover()
+	}()
+
+	if play == (a.out != nil) {
+		return
+	}
+	if !play {
+		a.out.Close()
+		a.out = nil
+		return
+	}
+
+	if a.player.Backend() != BackendAudio || a.player.Check() != nil {
+		return
+	}
+	track, err := a.track()
+	if err != nil {
+		return
+	}
+	a.out = startPCM(track, a.volume)
+}
+
+// syncAmbient plays the ambient track while a work session runs unmuted.
+func (m model) syncAmbient() {
+	m.ambient.update(m.timer.Running() && !m.timer.Paused() && !m.timer.Overtime() &&
+		m.preset.Phase == WORKTIME && !m.muted)
+}
+
+// noise generates endless white or brown noise as 16-bit stereo PCM.
+type noise struct {
+	brown bool
+	last  float64
+}
+
+func (n *noise) Read(p []byte) (int, error) {
+	size := len(p) / 4 * 4
+	for i := 0; i < size; i += 4 {
+		v := rand.Float64()*2 - 1
+		if n.brown {
+			// Integrating white noise gives brown noise; the leak keeps
+			// it from drifting off.
+			n.last = (n.last + 0.02*v) / 1.02
+			v = n.last * 3.5
+		}
+		sample := uint16(int16(math.Max(-1, math.Min(1, v)) * 0.5 * math.MaxInt16))
+		binary.LittleEndian.PutUint16(p[i:], sample)
+		binary.LittleEndian.PutUint16(p[i+2:], sample)
+	}
+	return size, nil
+}
+
+// loop plays a sound over and over.
+type loop struct {
+	sound sound
+	pcm   io.Reader
+}
+
+func (l *loop) Read(p []byte) (int, error) {
+	fresh := false
+	for {
+		if l.pcm == nil {
+			pcm, err := l.sound.stream()
+			if err != nil {
+				return 0, err
+			}
+			l.pcm, fresh = pcm, true
+		}
+		n, err := l.pcm.Read(p)
+		if err == io.EOF {
+			l.pcm = nil
+			if n > 0 {
+				return n, nil
+			}
+			if fresh {
+				// An empty sound; don't spin.
+				return 0, io.EOF
+			}
+			continue
+		}
+		return n, err
+	}
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// appearanceEvery is how often the system appearance is checked for
+// changes.
+const appearanceEvery = 30 * time.Second
+
+// appearance switches the interface between a light and a dark theme.
+type appearance struct {
+	light, dark Theme
+	isDark      bool
+	system      *bool // the last system appearance seen, dark when true
+	manual      bool  // switched by hand, system changes are ignored
+}
+
+// newAppearance picks the variant matching the terminal background, which
+// the terminal is asked for with OSC 11.
+func newAppearance(cfg ThemeConfig) (*appearance, error) {
+	light, dark, err := newThemes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a := &appearance{light: light, dark: dark, isDark: lipgloss.HasDarkBackground()}
+	if dark, ok := systemDark(); ok {
+		a.system = &dark
+	}
+	return a, nil
+}
+
+// theme returns the variant in use.
+func (a *appearance) theme() *Theme {
+	if a.isDark {
+		return &a.dark
+	}
+	return &a.light
+}
+
+// systemDark reports whether the OS appearance is dark, or false for ok
+// when it can't be told.
+func systemDark() (dark, ok bool) {
+	switch runtime.GOOS {
+	case "darwin":
+		// The key only exists in dark mode.
+		out, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+		return err == nil && strings.TrimSpace(string(out)) == "Dark", true
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKCU\Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`, "/v", "AppsUseLightTheme").Output()
+		if err != nil {
+			return false, false
+		}
+		return strings.Contains(string(out), "0x0"), true
+	default:
+		out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output()
+		if err != nil {
+			return false, false
+		}
+		return strings.Contains(string(out), "dark"), true
+	}
+}
+
+// appearanceMsg carries the system appearance read by appearanceCmd.
+type appearanceMsg struct {
+	dark, ok bool
+}
+
+// appearanceCmd reads the system appearance after appearanceEvery.
+func appearanceCmd() tea.Cmd {
+	return after(appearanceEvery, func(time.Time) tea.Msg {
+		dark, ok := systemDark()
+		return appearanceMsg{dark: dark, ok: ok}
+	})
+}
+
+// updateAppearance follows the system appearance when it changes, unless
+// the theme was switched by hand.
+func (m model) updateAppearance(msg appearanceMsg) (model, tea.Cmd) {
+	a := m.appearance
+	if msg.ok && !a.manual && (a.system == nil || *a.system != msg.dark) {
+		a.system = &msg.dark
+		m.setDark(msg.dark)
+	}
+	return m, appearanceCmd()
+}
+
+// toggleAppearance switches between the light and dark theme by hand and
+// stops following the system from then on.
+func (m *model) toggleAppearance() {
+	m.appearance.manual = true
+	m.setDark(!m.appearance.isDark)
+}
+
+// setDark restyles the interface with the dark or the light theme.
+func (m *model) setDark(dark bool) {
+	m.appearance.isDark = dark
+	m.theme = m.appearance.theme()
+	width := m.progress.Width
+	m.progress = m.theme.progressBar()
+	m.progress.Width = width
+	m.urgency = 0
+	m.help.Styles = m.theme.HelpStyles
+}
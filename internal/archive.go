@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// JournalFileConfig archives the notes of each day into a markdown journal
+// once the day is over.
+type JournalFileConfig struct {
+	// Path is the journal the entry of a day is appended to, a template
+	// in which {{date}} is the day as in daily notes, so
+	// "~/journal/{{date "2006-01"}}.md" keeps a file a month. Empty turns
+	// archiving off.
+	Path string `json:"path"`
+
+	// Template is the entry of a day, a Go template with .Date and
+	// .Sessions, the work sessions of the day with a note. Empty uses
+	// defaultJournalEntry.
+	Template string `json:"template"`
+}
+
+// defaultJournalEntry is the entry of a day unless the config has one.
+const defaultJournalEntry = `## {{.Date.Format "Monday, 2 January 2006"}}
+
+{{range .Sessions}}- {{.Start.Local.Format "15:04"}} {{or .Task .Preset}}: {{.Note}}
+{{end}}
+`
+
+// journalDay is what the entry template of a day is executed with.
+type journalDay struct {
+	Date     time.Time
+	Sessions []Session
+}
+
+// journalArchive appends the notes of each past day to the journal.
+type journalArchive struct {
+	path  *template.Template
+	entry *template.Template
+}
+
+// newJournalArchive returns the journal archive, or nil when it is
+// disabled.
+func newJournalArchive(cfg JournalFileConfig) (*journalArchive, error) {
+	if cfg.Path == "" {
+		return nil, nil
+	}
+	path, err := template.New("path").Funcs(template.FuncMap{"date": noteDate(Session{})}).Parse(expandHome(cfg.Path))
+	if err != nil {
+		return nil, fmt.Errorf("journal file: %w", err)
+	}
+	entry, err := template.New("entry").Parse(cmp.Or(cfg.Template, defaultJournalEntry))
+	if err != nil {
+		return nil, fmt.Errorf("journal file template: %w", err)
+	}
+	return &journalArchive{path: path, entry: entry}, nil
+}
+
+// archivedPath returns the file holding the last day archived.
+func archivedPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journal-archived"), nil
+}
+
+// cmd archives the days that ended since the last run in the background,
+// reporting failures as an *Error. It is nil when archiving is off.
+func (a *journalArchive) cmd(now time.Time) tea.Cmd {
+	if a == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := a.archive(now); err != nil {
+			return newError(CodeJournal, "archive the journal", err)
+		}
+		return nil
+	}
+}
+
+// archive appends an entry for each day, from the one after the last
+// archived up to yesterday, that has notes. The first time it starts with
+// yesterday.
+func (a *journalArchive) archive(now time.Time) error {
+	marker, err := archivedPath()
+	if err != nil {
+		return err
+	}
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	day := today.AddDate(0, 0, -1)
+	data, err := os.ReadFile(marker)
+	switch {
+	case err == nil:
+		last, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(string(data)), now.Location())
+		if err != nil {
+			return fmt.Errorf("%s: %w", marker, err)
+		}
+		day = last.AddDate(0, 0, 1)
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if !day.Before(today) {
+		return nil
+	}
+
+	sessions, err := querySessions(Query{From: day, To: today, Phase: WORKTIME})
+	if err != nil {
+		return err
+	}
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		entry := journalDay{Date: day}
+		for _, s := range sessions {
+			if s.Note != "" && daysBetween(day, s.Start.In(day.Location())) == 0 {
+				entry.Sessions = append(entry.Sessions, s)
+			}
+		}
+		if len(entry.Sessions) == 0 {
+			continue
+		}
+		if err := a.append(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(marker), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(marker, []byte(today.AddDate(0, 0, -1).Format(time.DateOnly)+"\n"), 0o644)
+}
+
+// append adds the entry of a day to its journal file, creating it if
+// needed.
+func (a *journalArchive) append(day journalDay) error {
+	t, err := a.path.Clone()
+	if err != nil {
+		return err
+	}
+	var path strings.Builder
+	if err := t.Funcs(template.FuncMap{"date": noteDate(Session{Start: day.Date})}).Execute(&path, nil); err != nil {
+		return err
+	}
+	var entry strings.Builder
+	if err := a.entry.Execute(&entry, day); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path.String()), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.String(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(entry.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// nextMidnight returns the start of the day after t.
+func nextMidnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+}
+
+// archiveTick archives the journal on the first tick and once a day has
+// ended.
+func (m *model) archiveTick() tea.Cmd {
+	if m.archive == nil || m.now.Before(m.archiveAt) {
+		return nil
+	}
+	m.archiveAt = nextMidnight(m.now)
+	return m.archive.cmd(m.now)
+}
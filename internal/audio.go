@@ -0,0 +1,125 @@
+//go:build !noaudio
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// audioSupport reports whether this build can play sounds. Build with the
+// noaudio tag to leave out Oto and the MP3 decoder.
+const audioSupport = true
+
+var (
+	otoCtx  *oto.Context
+	otoErr  error
+	otoOnce sync.Once
+)
+
+// initOtoContext initializes the shared Oto context.
+// This function should only be called once via sync.Once.
+// Creating multiple contexts is NOT supported by the Oto library.
+func initOtoContext() {
+	op := &oto.NewContextOptions{}
+
+	// Usually 44100 or 48000. Other values might cause distortions in Oto
+	op.SampleRate = sampleRate
+
+	// Number of channels (aka locations) to play sounds from. Either 1 or 2.
+	// 1 is mono sound, and 2 is stereo (most speakers are stereo).
+	op.ChannelCount = 2
+
+	// Format of the source. go-mp3's format is signed 16bit integers.
+	op.Format = oto.FormatSignedInt16LE
+
+	// Create the context once and reuse it for all audio playback
+	ctx, readyChan, err := oto.NewContext(op)
+	if err != nil {
+		otoErr = fmt.Errorf("open audio device: %w", err)
+		return
+	}
+	// It might take a bit for the hardware audio devices to be ready, so we wait on the channel.
+	<-readyChan
+
+	// Some drivers only find out that no device can be opened after the
+	// context is created. Players on such a context never finish playing.
+	if err := ctx.Err(); err != nil {
+		otoErr = fmt.Errorf("open audio device: %w", err)
+		return
+	}
+
+	otoCtx = ctx
+}
+
+// playbackPoll is how often playPCM checks whether a sound has finished.
+// A sound ending a little late goes unnoticed; waking up every millisecond
+// to find out doesn't.
+const playbackPoll = 20 * time.Millisecond
+
+// openAudio opens the audio device on first use and reports whether it
+// still works.
+func openAudio() error {
+	otoOnce.Do(initOtoContext)
+	if otoErr != nil {
+		return otoErr
+	}
+	if err := otoCtx.Err(); err != nil {
+		return fmt.Errorf("audio device lost: %w", err)
+	}
+	return nil
+}
+
+// playPCM plays a PCM stream at volume on the open audio device and blocks
+// until it finishes or the device stops taking samples.
+func playPCM(pcm io.Reader, volume float64) error {
+	// Create a new 'player' that will handle our sound. Paused by default.
+	// We reuse the shared context but create a new player for each playback.
+	player := otoCtx.NewPlayer(pcm)
+	player.SetVolume(volume)
+
+	// Play starts playing the sound and returns without waiting for it (Play() is async).
+	player.Play()
+
+	// Wait for the sound to finish, unless the device stops taking samples.
+	poll := time.NewTicker(playbackPoll)
+	defer poll.Stop()
+	for player.IsPlaying() {
+		if err := otoCtx.Err(); err != nil {
+			player.Close()
+			return fmt.Errorf("audio device lost: %w", err)
+		}
+		<-poll.C
+	}
+
+	// Close the player to free resources after playback completes
+	if err := player.Close(); err != nil {
+		return fmt.Errorf("close player: %w", err)
+	}
+	return player.Err()
+}
+
+// startPCM starts playing a PCM stream at volume on the open audio device
+// in the background. Closing the returned player stops it.
+func startPCM(pcm io.Reader, volume float64) io.Closer {
+	player := otoCtx.NewPlayer(pcm)
+	player.SetVolume(volume)
+	player.Play()
+	return player
+}
+
+func decodeMP3(r io.ReadSeeker) (io.Reader, error) {
+	d, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	if d.SampleRate() != sampleRate {
+		return nil, fmt.Errorf("sample rate %d Hz is not supported, use %d Hz", d.SampleRate(), sampleRate)
+	}
+	return d, nil
+}
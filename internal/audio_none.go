@@ -0,0 +1,32 @@
+//go:build noaudio
+
+package internal
+
+import (
+	"errors"
+	"io"
+)
+
+// audioSupport reports whether this build can play sounds. This one was
+// built with the noaudio tag, so alerts go to the fallback channels.
+const audioSupport = false
+
+// errAudioLeftOut is returned wherever a sound would be played.
+var errAudioLeftOut = errors.New("manta was built without audio (noaudio tag)")
+
+func openAudio() error {
+	return errAudioLeftOut
+}
+
+func playPCM(io.Reader, float64) error {
+	return errAudioLeftOut
+}
+
+// startPCM is never reached, as openAudio fails first.
+func startPCM(io.Reader, float64) io.Closer {
+	return io.NopCloser(nil)
+}
+
+func decodeMP3(io.ReadSeeker) (io.Reader, error) {
+	return nil, errAudioLeftOut
+}
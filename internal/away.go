@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var consoleLocked = regexp.MustCompile(`"CGSSessionScreenIsLocked"\s*=\s*Yes`)
+
+// screenLocked reports whether the screen is locked: from the console
+// session on macOS, and from logind or the desktop's screensaver over
+// D-Bus on Linux.
+func screenLocked() (bool, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("ioreg", "-n", "Root", "-d1").Output()
+		if err != nil {
+			return false, err
+		}
+		return consoleLocked.Match(out), nil
+
+	case "linux":
+		if id := os.Getenv("XDG_SESSION_ID"); id != "" {
+			out, err := exec.Command("loginctl", "show-session", id, "--property=LockedHint", "--value").Output()
+			if err == nil {
+				return strings.TrimSpace(string(out)) == "yes", nil
+			}
+		}
+		out, err := exec.Command("gdbus", "call", "--session",
+			"--dest", "org.freedesktop.ScreenSaver",
+			"--object-path", "/org/freedesktop/ScreenSaver",
+			"--method", "org.freedesktop.ScreenSaver.GetActive").Output()
+		if err != nil {
+			return false, err
+		}
+		// The reply looks like "(true,)".
+		return strings.Contains(string(out), "true"), nil
+	}
+	return false, errors.New("screen lock detection is not available on this system")
+}
+
+// resting reports whether a break is counting down.
+func (m model) resting() bool {
+	return m.timer.Running() && !m.timer.Paused() && !m.timer.Overtime() && m.preset.Phase == RESTTIME
+}
+
+// updateBreakAway counts the time of a break spent away from the screen,
+// with the screen locked or without input since the last check, and nags
+// once when there is still input NagAfter into the break.
+func (m model) updateBreakAway(msg idleMsg) (model, tea.Cmd) {
+	if !m.resting() {
+		return m, nil
+	}
+	if msg.locked || msg.idle >= idleCheckInterval {
+		m.breakAway += idleCheckInterval
+		return m, nil
+	}
+
+	nagAfter := time.Duration(m.cfg.Idle.NagAfter)
+	if nagAfter == 0 || m.nagged || m.timer.Elapsed(m.now) < nagAfter {
+		return m, nil
+	}
+	m.nagged = true
+	return m, m.pending.track(notifyCmd(m.notifier, tr("notify.step_away"),
+		tr("notify.step_away_body", m.preset.Name, minutesText(m.timer.Remaining(m.now)))))
+}
+
+// breakAwaySeconds is how much of the break ending now was spent away,
+// or nil when breaks aren't checked.
+func (m model) breakAwaySeconds() *int {
+	if !m.cfg.Idle.Breaks || m.preset.Phase != RESTTIME {
+		return nil
+	}
+	away := int(min(m.breakAway, m.timer.Elapsed(m.now)) / time.Second)
+	return &away
+}
+
+// complianceText sums up how much of the checked breaks was spent away
+// from the screen.
+func complianceText(away, total time.Duration, breaks int) string {
+	noun := "breaks"
+	if breaks == 1 {
+		noun = "break"
+	}
+	return fmt.Sprintf("Away from the screen for %.0f%% of break time (%d %s)",
+		100*away.Seconds()/max(total.Seconds(), 1), breaks, noun)
+}
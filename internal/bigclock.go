@@ -0,0 +1,42 @@
+package internal
+
+import "strings"
+
+// bigFont draws digits and the colon five rows high.
+var bigFont = map[rune][5]string{
+	'0': {"█████", "█   █", "█   █", "█   █", "█████"},
+	'1': {"  █  ", " ██  ", "  █  ", "  █  ", " ███ "},
+	'2': {"█████", "    █", "█████", "█    ", "█████"},
+	'3': {"█████", "    █", " ████", "    █", "█████"},
+	'4': {"█   █", "█   █", "█████", "    █", "    █"},
+	'5': {"█████", "█    ", "█████", "    █", "█████"},
+	'6': {"█████", "█    ", "█████", "█   █", "█████"},
+	'7': {"█████", "    █", "   █ ", "  █  ", "  █  "},
+	'8': {"█████", "█   █", "█████", "█   █", "█████"},
+	'9': {"█████", "█   █", "█████", "    █", "█████"},
+	':': {"   ", " █ ", "   ", " █ ", "   "},
+}
+
+// bigText renders s with bigFont. Characters missing from the font are
+// skipped.
+func bigText(s string) string {
+	var rows [5]strings.Builder
+	for i, r := range s {
+		glyph, ok := bigFont[r]
+		if !ok {
+			continue
+		}
+		for row := range rows {
+			if i > 0 {
+				rows[row].WriteString(" ")
+			}
+			rows[row].WriteString(glyph[row])
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for i := range rows {
+		lines[i] = rows[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
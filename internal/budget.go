@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// validateBudgets checks the weekly budgets set for tags.
+func validateBudgets(budgets map[string]Duration) error {
+	for tag, budget := range budgets {
+		if budget <= 0 {
+			return fmt.Errorf("budget for %q must be positive", tag)
+		}
+	}
+	return nil
+}
+
+// budgetUse sums the focus of sessions per tag with a budget.
+func budgetUse(budgets map[string]Duration, sessions []Session) map[string]time.Duration {
+	used := map[string]time.Duration{}
+	for _, s := range sessions {
+		for _, tag := range s.Tags {
+			if _, ok := budgets[tag]; ok {
+				used[tag] += s.focus()
+			}
+		}
+	}
+	return used
+}
+
+// budgetMsg carries how much of their weekly budgets the tags of a work
+// session used before it started.
+type budgetMsg struct {
+	start time.Time // when the session started
+	used  map[string]time.Duration
+}
+
+// budgetCmd reads in the background how much of their budgets the tags
+// with one used in the week of now. It returns nil when no tag has one.
+func budgetCmd(budgets map[string]Duration, tags []string, now time.Time) tea.Cmd {
+	if !slices.ContainsFunc(tags, func(tag string) bool { _, ok := budgets[tag]; return ok }) {
+		return nil
+	}
+	return func() tea.Msg {
+		from, to := Week(now)
+		sessions, err := querySessions(Query{From: from, To: to, Phase: WORKTIME})
+		if err != nil {
+			return newError(CodeHistoryRead, "read the history", err)
+		}
+		return budgetMsg{start: now, used: budgetUse(budgets, sessions)}
+	}
+}
+
+// updateBudget warns when the session that just started would take one of
+// its tags over its weekly budget.
+func (m model) updateBudget(msg budgetMsg) (model, tea.Cmd) {
+	if !m.timer.Running() || !m.timer.Started().Equal(msg.start) {
+		return m, nil
+	}
+	var over []string
+	for _, tag := range m.tags {
+		budget, ok := m.cfg.Budgets[tag]
+		if !ok {
+			continue
+		}
+		if used := msg.used[tag]; used+m.timer.Length() > time.Duration(budget) {
+			over = append(over, fmt.Sprintf("#%s at %s of %s", tag, hoursView(used), hoursView(time.Duration(budget))))
+		}
+	}
+	if len(over) > 0 {
+		m.status = tr("status.budget", strings.Join(over, ", "))
+	}
+	return m, nil
+}
+
+// budgetRow compares the focus on a tag with its budget over a report's
+// period.
+type budgetRow struct {
+	Tag          string
+	Used, Budget time.Duration
+}
+
+// budgetRows compares the focus on each tag with a budget in sessions,
+// spanning days days, with its weekly budget scaled to the days.
+func budgetRows(budgets map[string]Duration, sessions []Session, days int) []budgetRow {
+	used := budgetUse(budgets, sessions)
+	rows := make([]budgetRow, 0, len(budgets))
+	for _, tag := range slices.Sorted(maps.Keys(budgets)) {
+		rows = append(rows, budgetRow{
+			Tag:    tag,
+			Used:   used[tag],
+			Budget: time.Duration(budgets[tag]) * time.Duration(days) / 7,
+		})
+	}
+	return rows
+}
+
+// share returns the part of the budget used, as a percentage.
+func (b budgetRow) share() float64 {
+	return 100 * b.Used.Seconds() / b.Budget.Seconds()
+}
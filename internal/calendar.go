@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CalendarConfig blocks focus time in the user's calendar and warns about
+// meetings that would interrupt a work session.
+type CalendarConfig struct {
+	// Feed is an iCalendar file manta keeps its work sessions in as busy
+	// events. Subscribe to it from a calendar app, e.g. through a synced
+	// folder.
+	Feed string `json:"feed"`
+
+	// Meetings is the path or URL of an iCalendar feed with the user's
+	// meetings, such as the secret iCal address of a Google Calendar.
+	Meetings string `json:"meetings"`
+}
+
+// feedKeep is how far back the focus feed keeps past sessions.
+const feedKeep = 30 * 24 * time.Hour
+
+// calendar publishes work sessions to the focus feed and checks the
+// meetings feed when one starts.
+type calendar struct {
+	feed     string
+	meetings string
+	current  *icsEvent // the block of the running work session
+}
+
+// newCalendar returns the calendar integration, or nil when it is disabled.
+func newCalendar(cfg CalendarConfig) listener {
+	if cfg.Feed == "" && cfg.Meetings == "" {
+		return nil
+	}
+	return &calendar{feed: expandHome(cfg.Feed), meetings: expandHome(cfg.Meetings)}
+}
+
+// handle keeps a busy block in the feed while a work session runs: it is
+// added when the session starts or resumes and cut short when it pauses or
+// stops. Meetings during a starting session are reported as an error, which
+// the UI shows as a warning.
+func (c *calendar) handle(ev Event) error {
+	if ev.Phase != WORKTIME {
+		return nil
+	}
+
+	switch ev.Name {
+	case EventStart, EventResume:
+		c.current = &icsEvent{
+			UID:     fmt.Sprintf("%d@manta", ev.Time.UnixNano()),
+			Summary: "Focus: " + cmp.Or(ev.Task, ev.Preset),
+			Start:   ev.Time,
+			End:     ev.EndTime,
+		}
+		err := c.publish(ev.Time)
+		if ev.Name == EventStart {
+			err = errors.Join(err, c.checkMeetings(ev.Time, ev.EndTime))
+		}
+		return err
+
+	case EventEnd, EventPause, EventSkip, EventReset, EventQuit:
+		if c.current == nil {
+			return nil
+		}
+		if ev.Time.Before(c.current.End) {
+			c.current.End = ev.Time
+		}
+		err := c.publish(ev.Time)
+		c.current = nil
+		return err
+	}
+	return nil
+}
+
+// publish writes the current block to the feed, replacing its earlier
+// version and dropping blocks older than feedKeep.
+func (c *calendar) publish(now time.Time) error {
+	if c.feed == "" {
+		return nil
+	}
+
+	var events []icsEvent
+	data, err := os.ReadFile(c.feed)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("calendar: %w", err)
+	}
+	old, err := parseICS(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("calendar: %w", err)
+	}
+	for _, ev := range old {
+		if ev.UID != c.current.UID && now.Sub(ev.End) < feedKeep {
+			events = append(events, ev)
+		}
+	}
+	events = append(events, *c.current)
+
+	if err := os.MkdirAll(filepath.Dir(c.feed), 0o755); err != nil {
+		return fmt.Errorf("calendar: %w", err)
+	}
+	var b bytes.Buffer
+	if err := writeICS(&b, events, now); err != nil {
+		return err
+	}
+	tmp := c.feed + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("calendar: %w", err)
+	}
+	return os.Rename(tmp, c.feed)
+}
+
+// checkMeetings returns an error naming the meetings that overlap the
+// session from start to end.
+func (c *calendar) checkMeetings(start, end time.Time) error {
+	if c.meetings == "" {
+		return nil
+	}
+	events, err := loadMeetings(c.meetings)
+	if err != nil {
+		return fmt.Errorf("calendar: %w", err)
+	}
+
+	var clashes []string
+	for _, ev := range events {
+		if ev.AllDay {
+			continue
+		}
+		for _, occ := range ev.overlapping(start, end) {
+			clashes = append(clashes, fmt.Sprintf("%s at %s", cmp.Or(occ.Summary, "a meeting"), occ.Start.Local().Format("15:04")))
+		}
+	}
+	if len(clashes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("calendar: this session runs into %s", strings.Join(clashes, ", "))
+}
+
+// loadMeetings reads the meetings feed from a file or URL.
+func loadMeetings(src string) ([]icsEvent, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseICS(f)
+	}
+
+	resp, err := httpClient.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("fetch meetings: %s", resp.Status)
+	}
+	return parseICS(resp.Body)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CallConfig pauses work sessions while the user is in a call.
+type CallConfig struct {
+	// Pause turns call detection on: a work session pauses while the
+	// microphone or camera is in use and resumes when the call ends.
+	Pause bool `json:"pause"`
+}
+
+// callCheckInterval is how often the microphone and camera are checked.
+const callCheckInterval = 10 * time.Second
+
+// callMsg reports whether the user is in a call.
+type callMsg struct {
+	active bool
+	err    error
+}
+
+// callCheckCmd checks for a call after callCheckInterval.
+func callCheckCmd() tea.Cmd {
+	return after(callCheckInterval, func(time.Time) tea.Msg {
+		active, err := inCall()
+		return callMsg{active: active, err: err}
+	})
+}
+
+// inCall reports whether the microphone or camera is in use.
+func inCall() (bool, error) {
+	switch runtime.GOOS {
+	case "linux":
+		if micInUse() {
+			return true, nil
+		}
+		return cameraInUse(), nil
+
+	case "darwin":
+		// The camera assistant only runs while an app uses the camera.
+		// macOS has no command line view of the microphone.
+		for _, name := range []string{"VDCAssistant", "AppleCameraAssistant"} {
+			if exec.Command("pgrep", "-x", name).Run() == nil {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, errors.New("call detection is not available on this system")
+}
+
+// micInUse reports whether any ALSA capture device is recording. Sound
+// servers keep the device open only while an app records.
+func micInUse() bool {
+	paths, _ := filepath.Glob("/proc/asound/card*/pcm*c/sub*/status")
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil && bytes.Contains(data, []byte("RUNNING")) {
+			return true
+		}
+	}
+	return false
+}
+
+// cameraInUse reports whether a process has a video device open.
+func cameraInUse() bool {
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		target, err := os.Readlink(fd)
+		if err == nil && strings.HasPrefix(target, "/dev/video") {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCall pauses a running work session when a call starts and resumes
+// it when the call ends. A session paused or resumed by hand in between is
+// left as the user set it.
+func (m model) updateCall(msg callMsg) (model, tea.Cmd) {
+	if msg.err != nil {
+		m.status = tr("status.call_disabled", msg.err)
+		return m, nil
+	}
+
+	m.now = m.wallNow()
+	started := msg.active && !m.inCall
+	ended := !msg.active && m.inCall
+	m.inCall = msg.active
+
+	switch {
+	case started && m.preset.Phase == WORKTIME && m.timer.Pause(m.now):
+		m.callPaused = true
+		m.status = tr("status.call_paused")
+		m.writeState()
+		return m, tea.Batch(callCheckCmd(), m.emit(EventPause))
+
+	case ended && m.callPaused:
+		m.callPaused = false
+		if !m.timer.Paused() {
+			return m, callCheckCmd()
+		}
+		m.timer.Resume(m.now)
+		m.status = tr("status.call_ended")
+		m.writeState()
+		return m, tea.Batch(callCheckCmd(), m.emit(EventResume))
+	}
+	return m, callCheckCmd()
+}
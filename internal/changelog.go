@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Change records the settings that shape sessions as they were from At
+// on, so a change in the stats can be put down to a change of settings.
+type Change struct {
+	At       time.Time         `json:"at"`
+	Settings map[string]string `json:"settings"`
+}
+
+// settings picks out of cfg what changes how sessions go: the length of
+// each preset, the profile, the daily goal, the pre-roll and overtime.
+func settings(cfg Config) (map[string]string, error) {
+	presets, err := newPresets(cfg.Presets)
+	if err != nil {
+		return nil, err
+	}
+	s := map[string]string{}
+	for _, p := range presets {
+		s[p.Name] = timerLength(time.Duration(p.Duration))
+	}
+	if cfg.Profile != "" {
+		s["profile"] = cfg.Profile
+	}
+	if cfg.Goal > 0 {
+		s["goal"] = strconv.Itoa(cfg.Goal)
+	}
+	if cfg.Preroll > 0 {
+		s["preroll"] = timerLength(time.Duration(cfg.Preroll))
+	}
+	if cfg.Overtime {
+		s["overtime"] = "on"
+	}
+	return s, nil
+}
+
+// recordSettings adds a change to the history when the settings differ
+// from the last ones recorded.
+func recordSettings(cfg Config) error {
+	current, err := settings(cfg)
+	if err != nil {
+		return err
+	}
+	store, err := history()
+	if err != nil {
+		return err
+	}
+	changes, err := store.Changes()
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 && maps.Equal(changes[len(changes)-1].Settings, current) {
+		return nil
+	}
+	return store.AppendChange(Change{At: time.Now(), Settings: current})
+}
+
+// recordSettingsCmd records the settings in the background, reporting
+// failures as an *Error.
+func recordSettingsCmd(cfg Config) tea.Cmd {
+	return func() tea.Msg {
+		if err := recordSettings(cfg); err != nil {
+			return newError(CodeHistoryWrite, "record a change of settings", err)
+		}
+		return nil
+	}
+}
+
+// changeMarker is a change of settings placed on a timeline.
+type changeMarker struct {
+	At   time.Time
+	Text string // what changed, e.g. "work 25m → 50m"
+}
+
+// changeMarkers describes each recorded change within [from, to) against
+// the settings before it. The first change recorded is where the log
+// starts rather than a change, and isn't marked.
+func changeMarkers(changes []Change, from, to time.Time) []changeMarker {
+	var markers []changeMarker
+	for i := 1; i < len(changes); i++ {
+		c := changes[i]
+		if c.At.Before(from) || !c.At.Before(to) {
+			continue
+		}
+		if diff := diffSettings(changes[i-1].Settings, c.Settings); diff != "" {
+			markers = append(markers, changeMarker{At: c.At, Text: diff})
+		}
+	}
+	return markers
+}
+
+// diffSettings lists what differs between the settings before and after a
+// change, e.g. "work 25m → 50m, goal 8 → none".
+func diffSettings(before, after map[string]string) string {
+	all := map[string]string{}
+	maps.Copy(all, before)
+	maps.Copy(all, after)
+
+	var diffs []string
+	for _, k := range slices.Sorted(maps.Keys(all)) {
+		if before[k] != after[k] {
+			diffs = append(diffs, fmt.Sprintf("%s %s → %s", k, settingText(before[k]), settingText(after[k])))
+		}
+	}
+	return strings.Join(diffs, ", ")
+}
+
+// settingText shows an unset setting as "none".
+func settingText(v string) string {
+	if v == "" {
+		return "none"
+	}
+	return v
+}
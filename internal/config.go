@@ -0,0 +1,319 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config holds the user settings read from the config file.
+type Config struct {
+	// Presets replace the default work and rest choices.
+	Presets []Preset `json:"presets"`
+
+	Sounds   SoundConfig        `json:"sounds"`
+	Notify   NotifyConfig       `json:"notify"`
+	Theme    ThemeConfig        `json:"theme"`
+	Hooks    []HookConfig       `json:"hooks"`
+	DND      DNDConfig          `json:"dnd"`
+	Strict   StrictConfig       `json:"strict"`
+	Calendar CalendarConfig     `json:"calendar"`
+	Slack    SlackConfig        `json:"slack"`
+	Tracking TimeTrackingConfig `json:"time_tracking"`
+	Tint     TintConfig         `json:"tint"`
+	Idle     IdleConfig         `json:"idle"`
+	Calls    CallConfig         `json:"calls"`
+	Quiet    QuietConfig        `json:"quiet"`
+	Power    PowerConfig        `json:"power"`
+	Tmux     TmuxConfig         `json:"tmux"`
+	Storage  StorageConfig      `json:"storage"`
+
+	// Privacy limits what the history keeps.
+	Privacy PrivacyConfig `json:"privacy"`
+
+	// Profiles are named sets of settings laid over the rest of the
+	// config, such as other presets, sounds and goals for "writing".
+	// Pick one with manta --profile NAME or the profile key.
+	Profiles map[string]json.RawMessage `json:"profiles"`
+
+	// Profile is the profile in use, set when it is loaded.
+	Profile string `json:"-"`
+
+	// Context records where manta was launched with each session.
+	Context ContextConfig `json:"context"`
+
+	// Macros are recorded key sequences, replayed with their key or with
+	// manta run -macro NAME.
+	Macros map[string]Macro `json:"macros"`
+
+	// Tags are rules that tag sessions by launch directory or task.
+	Tags []TagRule `json:"tags"`
+
+	// Billing sets hourly rates for manta invoice.
+	Billing BillingConfig `json:"billing"`
+
+	// DailyNote logs finished work sessions to a markdown note per day.
+	DailyNote DailyNoteConfig `json:"daily_note"`
+
+	// JournalFile archives the notes of each day to a journal entry.
+	JournalFile JournalFileConfig `json:"journal_file"`
+
+	// Summary sums up the day at a set time or when manta quits.
+	Summary SummaryConfig `json:"summary"`
+
+	// Reading suggests a saved article at the start of each break.
+	Reading ReadingConfig `json:"reading"`
+
+	// Breaks nudges about skipped breaks.
+	Breaks BreaksConfig `json:"breaks"`
+
+	// Journal asks for a note after every finished work session.
+	Journal bool `json:"journal"`
+
+	// Goal is the number of work sessions to complete each day.
+	Goal int `json:"goal"`
+
+	// Estimates maps tasks to the number of work sessions they are
+	// expected to take, to track their velocity.
+	Estimates map[string]int `json:"estimates"`
+
+	// Budgets caps the focus time spent on tags each week, such as
+	// {"acme": "10h"}. Work sessions that would go over it are flagged.
+	Budgets map[string]Duration `json:"budgets"`
+
+	// Ritual is a checklist to tick off or skip before each work session
+	// starts, such as ["Close email", "Phone away", "Water ready"].
+	Ritual []string `json:"ritual"`
+
+	// Preroll counts down for this long before a work session starts.
+	Preroll Duration `json:"preroll"`
+
+	// OnStart runs commands as work sessions and breaks start.
+	OnStart PhaseCommandsConfig `json:"on_start"`
+
+	// Transition fills the screen for this long when a session changes
+	// from work to a break or back, such as "5s". Any key dismisses it.
+	Transition Duration `json:"transition"`
+
+	// Overtime keeps the clock counting up after a session ends until it
+	// is finished with the reset key.
+	Overtime bool `json:"overtime"`
+
+	// Snooze is how long the snooze key on the end menu holds off the
+	// next session, from 2 to 5 minutes; 5 minutes when unset.
+	Snooze Duration `json:"snooze"`
+
+	// Tick is how often the display refreshes, 250ms by default so the
+	// progress bar moves smoothly. Raise it up to 1s to save battery.
+	Tick Duration `json:"tick"`
+
+	// ReducedMotion keeps the interface still: the progress bar jumps
+	// instead of gliding, the cursor doesn't blink and alerts that would
+	// flash the screen are shown in the status line.
+	ReducedMotion bool `json:"reduced_motion"`
+
+	// Accessible suits the interface to screen readers: plain sentences
+	// instead of the progress bar and emoji, a screen that changes only
+	// once a minute, and a notification when each session starts.
+	Accessible bool `json:"accessible"`
+
+	// Mouse lets presets and menu entries be picked with a click, the
+	// session be paused with a click on the progress bar and lists be
+	// scrolled with the wheel. manta takes the whole terminal window
+	// while it is on.
+	Mouse bool `json:"mouse"`
+
+	// Language picks the translation of the interface and notifications,
+	// such as "uk" or "pt_BR". Empty follows LC_ALL, LC_MESSAGES or LANG,
+	// falling back to English.
+	Language string `json:"language"`
+
+	// Sleep is what happens to a running session when the computer wakes
+	// up: "ask" (the default) pauses it and asks whether the sleep counts,
+	// "pause" pauses it without asking and "count" keeps it running.
+	Sleep string `json:"sleep"`
+
+	// MaxFPS caps how many times a second the screen is redrawn, for slow
+	// links such as SSH over a poor connection. manta lowers the rate on
+	// its own when the terminal lags; 0 leaves it at the default.
+	MaxFPS int `json:"max_fps"`
+
+	// Keys maps actions (up, down, start, schedule, timer, focus, pause,
+	// skip, restart, reset, big, mute, quiet, task, notes, log, record,
+	// lock, theme, interrupt, stopwatch, lap, profile, snooze, yes, no,
+	// complete, help, quit) to the keys that trigger them, replacing the
+	// defaults.
+	Keys map[string][]string `json:"keys"`
+
+	// GlobalKeys maps actions to keys that trigger them from any app,
+	// such as {"pause": "ctrl+alt+p"}. manta hotkeys writes them out for
+	// the system's hotkey daemon.
+	GlobalKeys map[string]string `json:"global_keys"`
+}
+
+// SoundConfig maps sound events to sound files, or to "none" for
+// silence. Empty paths fall back to the theme, then to the embedded sound;
+// work_start and goal_reached are silent unless set.
+type SoundConfig struct {
+	// Backend is "audio" (the default), "bell" for the terminal bell only,
+	// or "none" to turn sounds off.
+	Backend string `json:"backend"`
+
+	// Theme is a directory of sounds named after their events, such as
+	// work_end.wav, used for the events not set here.
+	Theme string `json:"theme"`
+
+	WorkStart string `json:"work_start"`
+	WorkEnd   string `json:"work_end"`
+	RestEnd   string `json:"rest_end"`
+	Preroll   string `json:"preroll"`      // played when a pre-roll countdown ends
+	Timer     string `json:"timer"`        // played when a named timer runs out
+	Warning   string `json:"warning"`      // played before a work session ends
+	Goal      string `json:"goal_reached"` // played when the daily goal is reached
+
+	// Fallback lists the channels tried in order when audio is
+	// unavailable: "tmux", "flash" and "bell". The first one available is
+	// used; tmux and then the bell when unset.
+	Fallback []string `json:"fallback"`
+
+	// Volume scales playback from 0 (silent) to 1 (full), 1 when unset.
+	Volume *float64 `json:"volume"`
+
+	// Ambient is played in a loop during work sessions: "white" or
+	// "brown" noise, or the path of a sound file.
+	Ambient string `json:"ambient"`
+
+	// AmbientVolume is the volume of the ambient track, 0.5 when unset.
+	AmbientVolume *float64 `json:"ambient_volume"`
+}
+
+// NotifyConfig selects where session-end notifications are sent.
+type NotifyConfig struct {
+	Backends []string            `json:"backends"`
+	Desktop  DesktopNotifyConfig `json:"desktop"`
+	Gotify   GotifyConfig        `json:"gotify"`
+	HTTP     HTTPNotifyConfig    `json:"http"`
+
+	// Repeat re-sends the end-of-session sound and notification at this
+	// interval until a key is pressed. Zero alerts once.
+	Repeat Duration `json:"repeat"`
+
+	// Warn sends a softer alert this long before a work session ends, once
+	// for each duration, so there is time to wrap up.
+	Warn []Duration `json:"warn"`
+}
+
+// DesktopNotifyConfig sets up desktop notifications.
+type DesktopNotifyConfig struct {
+	// Command replaces terminal-notifier. Each argument is a Go template
+	// with .Title, .Message, .Activate and .Actions, the button labels
+	// separated by commas, e.g. ["notify-send", "{{.Title}}", "{{.Message}}"].
+	Command []string `json:"command"`
+
+	// Activate is the bundle ID of the app brought forward when a
+	// notification is clicked, Ghostty when unset.
+	Activate string `json:"activate"`
+
+	// Actions puts buttons on the end-of-session notification: "next"
+	// starts the next session and "snooze" holds it off for a while.
+	// They need a Command that waits for a click and prints the button.
+	Actions []string `json:"actions"`
+}
+
+// GotifyConfig describes a Gotify server and application token.
+type GotifyConfig struct {
+	URL      string `json:"url"`
+	Token    string `json:"token"`
+	Priority int    `json:"priority"`
+}
+
+// HTTPNotifyConfig describes a generic push request. Body is a Go template
+// with .Title and .Message, and a json function for quoting.
+type HTTPNotifyConfig struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// ConfigPath returns the location of the config file.
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manta", "config.json"), nil
+}
+
+// LoadConfig reads the config file. A missing file yields the defaults.
+func LoadConfig() (Config, error) {
+	var cfg Config
+
+	path, err := ConfigPath()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// updateConfig lets edit change the top-level keys of the config file and
+// writes it back, keeping settings it doesn't know about.
+func updateConfig(edit func(raw map[string]json.RawMessage) error) error {
+	path, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	raw := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	if err := edit(raw); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
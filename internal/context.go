@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ContextConfig picks which details about where manta was launched are
+// saved with each session.
+type ContextConfig struct {
+	Branch    bool `json:"branch"`    // current git branch
+	Host      bool `json:"host"`      // machine hostname
+	Directory bool `json:"directory"` // working directory
+}
+
+// Context is the environment captured at launch.
+type Context struct {
+	Branch    string `json:"branch,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Directory string `json:"directory,omitempty"`
+}
+
+// captureContext collects the enabled details. Anything that can't be
+// determined is left empty.
+func captureContext(cfg ContextConfig) Context {
+	var c Context
+	if cfg.Branch {
+		out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+		if err == nil {
+			c.Branch = strings.TrimSpace(string(out))
+		}
+	}
+	if cfg.Host {
+		c.Host, _ = os.Hostname()
+	}
+	if cfg.Directory {
+		c.Directory, _ = os.Getwd()
+	}
+	return c
+}
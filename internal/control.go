@@ -0,0 +1,197 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// controlMsg asks the model to perform a key action sent by another
+// process, such as the tray.
+type controlMsg string
+
+// controlPath returns the location of the control socket.
+func controlPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manta", "control.sock"), nil
+}
+
+// ServeControl lets other processes trigger key actions (pause, skip,
+// quit, ...) in p through the control socket. Each connection sends one
+// action per line and gets "ok" or an error back, or sends "events" to
+// get "ok" followed by every session event as a line of JSON. The
+// returned function stops serving.
+func ServeControl(p *tea.Program) (func(), error) {
+	path, err := controlPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	// A socket left by a crashed manta refuses connections and can be
+	// replaced; a live one belongs to another instance.
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, errors.New("another manta is already running")
+	}
+	_ = os.Remove(path)
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, p)
+		}
+	}()
+
+	return func() {
+		l.Close()
+		_ = os.Remove(path)
+	}, nil
+}
+
+func serveConn(conn net.Conn, p *tea.Program) {
+	defer conn.Close()
+
+	keys := defaultKeys()
+	actions := keys.actions()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		action := strings.TrimSpace(scanner.Text())
+		if action == "events" {
+			streamEvents(conn)
+			return
+		}
+		if _, ok := actions[action]; !ok {
+			fmt.Fprintf(conn, "unknown action %q\n", action)
+			continue
+		}
+		p.Send(controlMsg(action))
+		fmt.Fprintln(conn, "ok")
+	}
+}
+
+// streamEvents writes the session events to conn as lines of JSON until
+// it is closed.
+func streamEvents(conn net.Conn) {
+	events, unsubscribe := eventFeed.subscribe()
+	defer unsubscribe()
+
+	closed := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	fmt.Fprintln(conn, "ok")
+	enc := json.NewEncoder(conn)
+	for {
+		select {
+		case ev := <-events:
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// eventHub passes session events on to the processes watching them through
+// the control socket, such as manta serve.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// eventFeed is the feed of the running TUI.
+var eventFeed = &eventHub{subs: map[chan Event]struct{}{}}
+
+// handle passes ev on to every subscriber, dropping it for those too slow
+// to keep up.
+func (f *eventHub) handle(ev Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return nil
+}
+
+// subscribe returns a channel receiving the events from now on, and a
+// function ending the subscription.
+func (f *eventHub) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+	f.mu.Lock()
+	f.subs[ch] = struct{}{}
+	f.mu.Unlock()
+	return ch, func() {
+		f.mu.Lock()
+		delete(f.subs, ch)
+		f.mu.Unlock()
+	}
+}
+
+// sendControl asks the running manta to perform action.
+func sendControl(action string) error {
+	path, err := controlPath()
+	if err != nil {
+		return err
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return errors.New("manta is not running")
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, action)
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return errors.New(reply)
+	}
+	return nil
+}
+
+// control performs a key action as if its first key had been pressed.
+func (m model) control(action controlMsg) (model, tea.Cmd) {
+	// Actions sent from outside are meant for the session, not for the
+	// screen announcing it.
+	m.transition = nil
+	b, ok := m.keys.actions()[string(action)]
+	if !ok || len(b.Keys()) == 0 {
+		return m, nil
+	}
+	msg, err := parseKey(b.Keys()[0])
+	if err != nil {
+		return m, nil
+	}
+	next, cmd := m.Update(msg)
+	return next.(model), cmd
+}
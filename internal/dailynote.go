@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DailyNoteConfig logs finished work sessions to a markdown note per day,
+// such as the daily notes of an Obsidian vault.
+type DailyNoteConfig struct {
+	// Path is where the note of a day is, a template in which {{date}}
+	// is the day of the session, e.g. "~/notes/daily/{{date}}.md". Give
+	// date a Go layout for other formats: {{date "2006/01/02"}}. Empty
+	// turns logging off.
+	Path string `json:"path"`
+}
+
+// dailyNote appends a line for each finished work session to the note of
+// the day it started.
+type dailyNote struct {
+	path *template.Template
+}
+
+// newDailyNote returns the daily note log, or nil when it is disabled.
+func newDailyNote(cfg DailyNoteConfig) (*dailyNote, error) {
+	if cfg.Path == "" {
+		return nil, nil
+	}
+	path, err := template.New("path").Funcs(template.FuncMap{"date": noteDate(Session{})}).Parse(expandHome(cfg.Path))
+	if err != nil {
+		return nil, fmt.Errorf("daily note: %w", err)
+	}
+	return &dailyNote{path: path}, nil
+}
+
+// noteDate returns the date template function for s.
+func noteDate(s Session) func(layout ...string) string {
+	return func(layout ...string) string {
+		if len(layout) > 0 {
+			return s.Start.Local().Format(layout[0])
+		}
+		return s.Start.Local().Format("2006-01-02")
+	}
+}
+
+// cmd logs s in the background, reporting failures as an *Error. It is
+// nil when s isn't logged.
+func (d *dailyNote) cmd(s Session) tea.Cmd {
+	if !d.logs(s) {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := d.log(s); err != nil {
+			return newError(CodeDailyNote, "write the daily note", err)
+		}
+		return nil
+	}
+}
+
+// logFinished logs s right away if it is logged at all.
+func (d *dailyNote) logFinished(s Session) error {
+	if !d.logs(s) {
+		return nil
+	}
+	return d.log(s)
+}
+
+// logs reports whether s goes in the daily note: only finished work
+// sessions do.
+func (d *dailyNote) logs(s Session) bool {
+	return d != nil && s.Phase == WORKTIME && !s.Abandoned
+}
+
+// log appends s to its daily note, creating the note if needed.
+func (d *dailyNote) log(s Session) error {
+	t, err := d.path.Clone()
+	if err != nil {
+		return err
+	}
+	var path strings.Builder
+	if err := t.Funcs(template.FuncMap{"date": noteDate(s)}).Execute(&path, nil); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path.String()), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.String(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(noteEntry(s)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// noteEntry is the markdown list item for s: its time range, task, tags
+// and note.
+func noteEntry(s Session) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- %s–%s %s", s.Start.Local().Format("15:04"), s.End.Local().Format("15:04"), cmp.Or(s.Task, s.Preset))
+	for _, tag := range s.Tags {
+		b.WriteString(" #" + tag)
+	}
+	if s.Note != "" {
+		b.WriteString(" — " + strings.ReplaceAll(s.Note, "\n", " "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
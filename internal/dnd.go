@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DNDConfig turns on the system Do Not Disturb mode during work sessions.
+//
+// macOS has no command line switch for Focus, so manta runs two Shortcuts
+// that the user creates with the "Set Focus" action.
+type DNDConfig struct {
+	Enabled     bool   `json:"enabled"`
+	OnShortcut  string `json:"on_shortcut"`
+	OffShortcut string `json:"off_shortcut"`
+}
+
+// dnd toggles Do Not Disturb with platform specific commands.
+type dnd struct {
+	on     []string
+	off    []string
+	active bool
+}
+
+// newDND returns the Do Not Disturb integration for the current desktop, or
+// nil when it is disabled.
+func newDND(cfg DNDConfig) (listener, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	switch {
+	case runtime.GOOS == "darwin":
+		on, off := cfg.OnShortcut, cfg.OffShortcut
+		if on == "" {
+			on = "manta focus on"
+		}
+		if off == "" {
+			off = "manta focus off"
+		}
+		return &dnd{
+			on:  []string{"shortcuts", "run", on},
+			off: []string{"shortcuts", "run", off},
+		}, nil
+
+	case strings.Contains(os.Getenv("XDG_CURRENT_DESKTOP"), "GNOME"):
+		const schema = "org.gnome.desktop.notifications"
+		return &dnd{
+			on:  []string{"gsettings", "set", schema, "show-banners", "false"},
+			off: []string{"gsettings", "set", schema, "show-banners", "true"},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("do not disturb is not supported on %s (desktop %q)", runtime.GOOS, os.Getenv("XDG_CURRENT_DESKTOP"))
+}
+
+// handle keeps Do Not Disturb on exactly while a work session is running.
+func (d *dnd) handle(ev Event) error {
+	if ev.Phase != WORKTIME {
+		return nil
+	}
+
+	switch ev.Name {
+	case EventStart, EventResume:
+		return d.set(true)
+	case EventEnd, EventPause, EventSkip, EventReset, EventQuit:
+		return d.set(false)
+	}
+	return nil
+}
+
+func (d *dnd) set(active bool) error {
+	if d.active == active {
+		return nil
+	}
+
+	args := d.off
+	if active {
+		args = d.on
+	}
+	if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("do not disturb: %w: %s", err, bytes.TrimSpace(out))
+	}
+	d.active = active
+	return nil
+}
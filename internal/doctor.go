@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Doctor checks the config and the sound setup and describes what it
+// finds. It returns an error when something needs fixing.
+func Doctor(w io.Writer) error {
+	path, _ := ConfigPath()
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(w, "✗ config: %v\n", err)
+		return errors.New("config is invalid")
+	}
+
+	var problems []string
+
+	player, err := NewPlayer(cfg.Sounds)
+	if err != nil {
+		problems = append(problems, "sounds")
+		fmt.Fprintf(w, "✗ sounds: %v\n", err)
+	}
+	notifier, err := NewNotifier(cfg.Notify)
+	if err != nil {
+		problems = append(problems, "notifications")
+		fmt.Fprintf(w, "✗ notifications: %v\n", err)
+	}
+	if player != nil && notifier != nil {
+		if _, err := NewModel(cfg, player, notifier); err != nil {
+			problems = append(problems, "config")
+			fmt.Fprintf(w, "✗ config %s: %v\n", path, err)
+		} else {
+			fmt.Fprintf(w, "✓ config %s\n", path)
+		}
+	}
+
+	if player != nil {
+		fmt.Fprintf(w, "  sound backend: %s\n", player.Backend())
+		if !audioSupport {
+			fmt.Fprintf(w, "  built without audio, alerts go to %s, the first available of \"sounds.fallback\"\n", strings.Join(player.fallbacks, ", "))
+		}
+		if err := player.Check(); err != nil {
+			problems = append(problems, "audio output")
+			fmt.Fprintf(w, "✗ audio output: %v\n", err)
+			fmt.Fprintf(w, "  alerts go to %s instead, the first available of \"sounds.fallback\"\n", strings.Join(player.fallbacks, ", "))
+			fmt.Fprintf(w, "  set \"sounds\": {\"backend\": %q} to use the terminal bell, or %q to turn sounds off\n", BackendBell, BackendNone)
+		} else if player.Backend() == BackendAudio {
+			fmt.Fprintln(w, "✓ audio output")
+		}
+	}
+
+	fmt.Fprintln(w, "  output devices:")
+	devices := audioDevices()
+	if len(devices) == 0 {
+		fmt.Fprintln(w, "    none found")
+	}
+	for _, d := range devices {
+		fmt.Fprintf(w, "    %s\n", d)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("problems with %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// audioDevices lists the sound outputs the system knows about, as far as
+// it can tell.
+func audioDevices() []string {
+	var devices []string
+	switch runtime.GOOS {
+	case "linux":
+		if data, err := os.ReadFile("/proc/asound/cards"); err == nil {
+			// Each card takes two lines; the first starts with its number.
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line != "" && line[0] >= '0' && line[0] <= '9' {
+					devices = append(devices, "alsa: "+line)
+				}
+			}
+		}
+		if out, err := exec.Command("pactl", "list", "short", "sinks").Output(); err == nil {
+			for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+				if fields := strings.Fields(line); len(fields) > 1 {
+					devices = append(devices, "pulse: "+fields[1])
+				}
+			}
+		}
+
+	case "darwin":
+		out, err := exec.Command("system_profiler", "SPAudioDataType").Output()
+		if err != nil {
+			return nil
+		}
+		// Devices are listed as "        Name:" under "Devices:".
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.HasPrefix(line, "        ") && !strings.HasPrefix(line, "         ") && strings.HasSuffix(line, ":") {
+				devices = append(devices, strings.TrimSuffix(strings.TrimSpace(line), ":"))
+			}
+		}
+	}
+	return devices
+}
@@ -0,0 +1,274 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// waitTimeout is how long the harness waits for the program to react.
+const waitTimeout = 5 * time.Second
+
+// harness runs the whole program the way manta does: keys are sent to it,
+// the frames it renders are captured and the session events it emits are
+// collected. Time is injected with ticks, so a session can be run to its
+// end without waiting for it.
+type harness struct {
+	t       *testing.T
+	p       *tea.Program
+	out     *frames
+	events  <-chan Event
+	notices *notices
+	final   chan tea.Model
+}
+
+// frames collects the program's output. Reads and writes happen on
+// different goroutines.
+type frames struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	seen int // output before this was matched by an earlier wait
+}
+
+func (f *frames) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buf.Write(p)
+}
+
+// notices records the notifications sent.
+type notices struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (n *notices) Notify(title, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.titles = append(n.titles, title)
+	return nil
+}
+
+func (n *notices) sent() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.titles...)
+}
+
+// testPresets are the presets the flows run, with known lengths.
+var testPresets = []Preset{
+	{Name: "work", Duration: Duration(25 * time.Minute), Phase: WORKTIME},
+	{Name: "rest", Duration: Duration(5 * time.Minute), Phase: RESTTIME},
+}
+
+// newHarness starts the program with cfg, keeping its data in temporary
+// directories, and stops it when the test ends.
+func newHarness(t *testing.T, cfg Config) *harness {
+	t.Helper()
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+		t.Setenv(env, t.TempDir())
+	}
+	if cfg.Presets == nil {
+		cfg.Presets = testPresets
+	}
+	// The screens are matched in English whatever the locale.
+	cfg.Language = "en"
+
+	player, err := NewPlayer(SoundConfig{Backend: BackendNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &harness{t: t, out: &frames{}, notices: &notices{}, final: make(chan tea.Model, 1)}
+	m, err := NewModel(cfg, player, h.notices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, unsubscribe := eventFeed.subscribe()
+	t.Cleanup(unsubscribe)
+	h.events = events
+
+	h.p = tea.NewProgram(m, tea.WithInput(nil), tea.WithOutput(h.out), tea.WithoutSignals())
+	go func() {
+		final, err := h.p.Run()
+		if err != nil {
+			t.Error(err)
+		}
+		h.final <- final
+	}()
+	t.Cleanup(func() {
+		h.p.Kill()
+		<-h.final
+	})
+	h.p.Send(tea.WindowSizeMsg{Width: 100, Height: 30})
+	return h
+}
+
+// press sends keys as typed, by their Bubble Tea names.
+func (h *harness) press(keys ...string) {
+	for _, k := range keys {
+		h.p.Send(keyMsg(k))
+	}
+}
+
+// keyMsg returns the message of the key named k.
+func keyMsg(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "space":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	default:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	}
+}
+
+// tick moves the program's clock to at.
+func (h *harness) tick(at time.Time) {
+	h.p.Send(tickMsg(at))
+}
+
+// waitFor waits until the program has rendered text since the last match.
+func (h *harness) waitFor(text string) {
+	h.t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		h.out.mu.Lock()
+		out := h.out.buf.String()[h.out.seen:]
+		if i := strings.Index(out, text); i >= 0 {
+			h.out.seen += i + len(text)
+			h.out.mu.Unlock()
+			return
+		}
+		h.out.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.t.Fatalf("%q never rendered", text)
+}
+
+// event waits for the next session event and checks its name and phase.
+func (h *harness) event(name, phase string) Event {
+	h.t.Helper()
+	select {
+	case ev := <-h.events:
+		if ev.Name != name || ev.Phase != phase {
+			h.t.Fatalf("got %s event of %s, want %s of %s", ev.Name, ev.Phase, name, phase)
+		}
+		return ev
+	case <-time.After(waitTimeout):
+		h.t.Fatalf("no %s event", name)
+		return Event{}
+	}
+}
+
+// noEvent checks that no session event comes for a while.
+func (h *harness) noEvent() {
+	h.t.Helper()
+	select {
+	case ev := <-h.events:
+		h.t.Fatalf("unexpected %s event of %s", ev.Name, ev.Phase)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// quit quits the program as the user would and returns the sessions it
+// saved.
+func (h *harness) quit() []Session {
+	h.t.Helper()
+	h.press("q")
+	select {
+	case final := <-h.final:
+		h.final <- final // for the cleanup
+		Shutdown(final)
+	case <-time.After(waitTimeout):
+		h.t.Fatal("the program didn't quit")
+	}
+	sessions, err := loadSessions()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return sessions
+}
+
+func TestFlowFinish(t *testing.T) {
+	h := newHarness(t, Config{})
+	h.waitFor("work")
+
+	h.press("enter")
+	start := h.event(EventStart, WORKTIME)
+	h.waitFor("25m00s")
+
+	h.tick(start.EndTime.Add(time.Second))
+	h.event(EventEnd, WORKTIME)
+	h.waitFor("Start rest")
+
+	h.press("enter")
+	h.event(EventStart, RESTTIME)
+	h.waitFor("05m00s")
+
+	sessions := h.quit()
+	if got := h.notices.sent(); len(got) != 1 || got[0] != "Time to work is left" {
+		t.Errorf("notifications = %q, want the end of work", got)
+	}
+	if len(sessions) != 1 || sessions[0].Phase != WORKTIME || sessions[0].Abandoned {
+		t.Fatalf("history = %+v, want one finished work session", sessions)
+	}
+	if !sessions[0].End.Equal(start.EndTime) {
+		t.Errorf("end = %v, want %v", sessions[0].End, start.EndTime)
+	}
+}
+
+func TestFlowPause(t *testing.T) {
+	h := newHarness(t, Config{})
+	h.waitFor("work")
+
+	h.press("enter")
+	start := h.event(EventStart, WORKTIME)
+
+	h.press("space")
+	h.event(EventPause, WORKTIME)
+	h.waitFor("⏸️")
+
+	// A paused session doesn't end, however long it waits.
+	h.tick(start.EndTime.Add(time.Hour))
+	h.noEvent()
+
+	h.press("space")
+	h.event(EventResume, WORKTIME)
+	h.waitFor("▶️")
+
+	h.press("esc")
+	h.event(EventReset, WORKTIME)
+	h.waitFor("work")
+
+	sessions := h.quit()
+	if len(sessions) != 1 || !sessions[0].Abandoned {
+		t.Fatalf("history = %+v, want one abandoned session", sessions)
+	}
+}
+
+func TestFlowSkip(t *testing.T) {
+	h := newHarness(t, Config{})
+	h.waitFor("work")
+
+	h.press("enter")
+	h.event(EventStart, WORKTIME)
+
+	h.press("s")
+	h.event(EventSkip, WORKTIME)
+	h.event(EventStart, RESTTIME)
+	h.waitFor("rest")
+
+	sessions := h.quit()
+	if len(sessions) != 1 || sessions[0].Phase != WORKTIME || !sessions[0].Abandoned {
+		t.Fatalf("history = %+v, want the skipped work session", sessions)
+	}
+}
@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+// extendBy is how much time the extend action adds to a finished session.
+const extendBy = 5 * time.Minute
+
+// menuAction is an entry of the menu shown when a session ends.
+type menuAction int
+
+const (
+	actionNext menuAction = iota
+	actionExtend
+	actionNote
+	actionStats
+	actionDone
+)
+
+var menuActions = []menuAction{actionNext, actionExtend, actionNote, actionStats, actionDone}
+
+// label names the action in the menu.
+func (m model) label(a menuAction) string {
+	switch a {
+	case actionNext:
+		return tr("menu.next", nextPreset(m.presets, m.preset).Name)
+	case actionExtend:
+		return tr("menu.extend", int(extendBy/time.Minute))
+	case actionNote:
+		return tr("menu.note")
+	case actionStats:
+		return tr("menu.stats")
+	default:
+		return tr("menu.done")
+	}
+}
+
+// finish ends the current session and opens the end menu. The session is
+// kept until the user picks an action, so it can still be extended or
+// annotated before it is saved. With the journal on, a finished work
+// session asks for a note right away.
+func (m *model) finish() tea.Cmd {
+	s := m.session()
+	m.ended = &s
+	m.menuCursor = 0
+	m.stats = ""
+	m.timer.Stop()
+	m.removeState()
+
+	if m.cfg.Journal && s.Phase == WORKTIME {
+		return m.openPrompt(promptNote, tr("prompt.journal"), "")
+	}
+	return nil
+}
+
+// updateMenu handles keys on the end menu.
+func (m model) updateMenu(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		m.menuCursor = (m.menuCursor + len(menuActions) - 1) % len(menuActions)
+
+	case key.Matches(msg, m.keys.Down):
+		m.menuCursor = (m.menuCursor + 1) % len(menuActions)
+
+	case key.Matches(msg, m.keys.Start):
+		return m.runAction(menuActions[m.menuCursor])
+
+	case key.Matches(msg, m.keys.Snooze):
+		return m.snooze()
+	}
+	return m, nil
+}
+
+// notifyActionMsg reports a button clicked on the notification that the
+// session started at start ended.
+type notifyActionMsg struct {
+	action string
+	start  time.Time
+}
+
+// notifyEnd sends the end-of-session notification, with the configured
+// buttons when the notifier supports them. It isn't tracked, as it may
+// wait for a click for as long as the notification is up.
+func (m model) notifyEnd(title string) tea.Cmd {
+	n, ok := m.notifier.(actionNotifier)
+	actions := m.cfg.Notify.Desktop.Actions
+	if !ok || len(actions) == 0 {
+		return m.pending.track(notifyCmd(m.notifier, title, ""))
+	}
+
+	labels := make([]string, len(actions))
+	for i, a := range actions {
+		labels[i] = m.label(actionNext)
+		if a == NotifyActionSnooze {
+			labels[i] = m.snoozeLabel()
+		}
+	}
+	// Repeated alerts come from the end menu, once the session is over.
+	start := m.session().Start
+	if m.ended != nil {
+		start = m.ended.Start
+	}
+	return func() tea.Msg {
+		i, err := n.NotifyActions(title, "", labels)
+		if err != nil {
+			return newError(CodeNotify, "send the notification", err)
+		}
+		if i < 0 {
+			return nil
+		}
+		return notifyActionMsg{action: actions[i], start: start}
+	}
+}
+
+// notifyAction carries out a button clicked on a notification, as long as
+// the session it was about is still waiting on the end menu or in
+// overtime.
+func (m model) notifyAction(msg notifyActionMsg) (model, tea.Cmd) {
+	if m.locked || m.prompt != promptNone {
+		return m, nil
+	}
+	m.now = m.wallNow()
+	m.awaiting = false
+
+	switch {
+	case m.ended != nil && m.ended.Start.Equal(msg.start):
+		if msg.action == NotifyActionSnooze {
+			return m.snooze()
+		}
+		return m.runAction(actionNext)
+
+	case m.timer.Overtime() && m.session().Start.Equal(msg.start) && msg.action == NotifyActionNext:
+		return m.control("skip")
+	}
+	return m, nil
+}
+
+// runAction carries out a menu action.
+func (m model) runAction(a menuAction) (model, tea.Cmd) {
+	switch a {
+	case actionExtend:
+		// The time spent in the menu doesn't count against the extension.
+		m.timer = timer.Restore(m.ended.Start, time.Duration(m.ended.Planned)*time.Second+extendBy, m.now.Add(extendBy))
+		m.timer.WarnBefore(m.now, warnings(m.cfg.Notify, m.preset)...)
+		m.ended = nil
+		m.writeState()
+		return m, m.emit(EventResume)
+
+	case actionNote:
+		return m, m.openPrompt(promptNote, tr("prompt.note"), m.ended.Note)
+
+	case actionStats:
+		m.stats = m.todayStats()
+		return m, nil
+	}
+
+	record := m.record(*m.ended)
+	m.ended = nil
+	if a == actionNext {
+		return m, tea.Batch(record, m.start(nextPreset(m.presets, m.preset)))
+	}
+	return m, record
+}
+
+// todayStats summarizes the work done today, including the session that
+// just ended.
+func (m model) todayStats() string {
+	y, mo, d := m.now.Date()
+	q := Query{From: time.Date(y, mo, d, 0, 0, 0, 0, m.now.Location()), Phase: WORKTIME}
+
+	store, err := history()
+	if err != nil {
+		return newError(CodeHistoryRead, "read the history", err).Message()
+	}
+	totals, err := store.Aggregate(q)
+	if err != nil {
+		return newError(CodeHistoryRead, "read the history", err).Message()
+	}
+	if q.match(*m.ended) {
+		totals.add(*m.ended)
+	}
+
+	id := "stats.today"
+	if totals.Finished == 1 {
+		id = "stats.today_one"
+	}
+	line := tr(id, totals.Finished, totals.Focus.Round(time.Minute))
+	if t := m.today; t.rests > 0 && t.day == dayOf(m.now) {
+		line += tr("stats.skipped", t.skipped, t.rests, t.skipped*100/t.rests)
+	}
+	if totals.Interruptions > 0 {
+		line += ", " + interruptionsText(totals.Interruptions, 0, 0)
+	}
+	return line
+}
+
+// menuView renders the end menu.
+func (m model) menuView() string {
+	s := strings.Builder{}
+	s.WriteString(m.theme.Title.Render(tr("menu.over", m.preset.Name)) + "\n\n")
+	s.WriteString(m.goalView(""))
+
+	for i, a := range menuActions {
+		if m.menuCursor == i {
+			s.WriteString(m.theme.Selected.Render("[•] " + m.label(a)))
+		} else {
+			s.WriteString("[ ] " + m.label(a))
+		}
+		s.WriteString("\n")
+	}
+	if m.ended.Note != "" && !m.locked {
+		s.WriteString("\n" + tr("prompt.note") + m.ended.Note + "\n")
+	}
+	if m.stats != "" {
+		s.WriteString("\n" + m.stats + "\n")
+	}
+	s.WriteString("\n" + m.inputView("") + m.helpView("") + "\n")
+	if m.status != "" {
+		s.WriteString(m.theme.Status.Render(m.status) + "\n")
+	}
+	return s.String()
+}
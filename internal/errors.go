@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Code identifies a kind of failure. It is shown with the message in the
+// status area and logged, so a failure can be looked up in the README and
+// found again in the error log.
+type Code string
+
+const (
+	CodeHistoryRead  Code = "E101" // the history couldn't be read
+	CodeHistoryWrite Code = "E102" // a session couldn't be saved
+	CodeState        Code = "E103" // the state file couldn't be read or written
+	CodeNotify       Code = "E201" // a notification couldn't be sent
+	CodeSound        Code = "E202" // a sound couldn't be played
+	CodeEvent        Code = "E301" // a hook or integration failed
+	CodeReading      Code = "E302" // the reading list couldn't be loaded
+	CodeDailyNote    Code = "E303" // a session couldn't be logged to the daily note
+	CodeJournal      Code = "E304" // the notes of a day couldn't be archived to the journal
+	CodeTimeTracking Code = "E305" // a session couldn't be sent to the time tracker
+	CodePhaseCommand Code = "E306" // a command run as a session started failed
+	CodeMacro        Code = "E401" // a macro couldn't be saved
+)
+
+// Error is a failure reported to the user: what manta was doing when it
+// failed and why.
+type Error struct {
+	Code Code
+	Op   string // what failed, e.g. "save session"
+	Err  error
+}
+
+// newError returns the Error for err, which happened while doing op.
+func newError(code Code, op string, err error) *Error {
+	return &Error{Code: code, Op: op, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Message is the error as shown to the user.
+func (e *Error) Message() string {
+	return fmt.Sprintf("%s: failed to %s: %v", e.Code, e.Op, e.Err)
+}
+
+// errorLogPath returns the location of the error log.
+func errorLogPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manta", "errors.log"), nil
+}
+
+// logError appends err to the error log. A log that can't be written is
+// ignored; the error has been shown already.
+func logError(err *Error) {
+	path, perr := errorLogPath()
+	if perr != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+	f, ferr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if ferr != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s %v\n", time.Now().Format(time.RFC3339), err.Code, err)
+}
+
+// fail shows err in the status area and logs it.
+func (m *model) fail(err *Error) {
+	m.status = err.Message()
+	logError(err)
+}
+
+// writeState publishes the state of the running session for other
+// processes.
+func (m *model) writeState() {
+	m.stateAt = m.now
+	if err := saveState(m.state()); err != nil {
+		m.fail(newError(CodeState, "save the state file", err))
+	}
+}
+
+// removeState tells other processes no session is running.
+func (m *model) removeState() {
+	if err := clearState(); err != nil {
+		m.fail(newError(CodeState, "remove the state file", err))
+	}
+}
+
+// reportShutdown prints and logs a failure once the interface is gone.
+func reportShutdown(code Code, op string, err error) {
+	if err == nil {
+		return
+	}
+	e := newError(code, op, err)
+	fmt.Fprintln(os.Stderr, "manta:", e.Message())
+	logError(e)
+}
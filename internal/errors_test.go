@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var errBroken = errors.New("broken")
+
+// brokenDir returns a path under a regular file, so creating anything
+// there fails.
+func brokenDir(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(file, "dir")
+}
+
+// wantCode checks that msg is an *Error with the given code.
+func wantCode(t *testing.T, msg any, code Code) {
+	t.Helper()
+	err, ok := msg.(*Error)
+	if !ok {
+		t.Fatalf("got %#v, want an *Error", msg)
+	}
+	if err.Code != code {
+		t.Errorf("code = %s, want %s", err.Code, code)
+	}
+}
+
+// wantFailure hands msg to m and checks that the failure it reports shows
+// with code and op in the status area and in the error log.
+func wantFailure(t *testing.T, m model, msg tea.Msg, code Code, op string) {
+	t.Helper()
+	next, _ := m.Update(msg)
+	if want := string(code) + ": failed to " + op + ": "; !strings.HasPrefix(next.(model).status, want) {
+		t.Errorf("status = %q, want it to start with %q", next.(model).status, want)
+	}
+
+	path, err := errorLogPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " " + string(code) + " " + op + ": "; !strings.Contains(string(data), want) {
+		t.Errorf("log = %q, want %q in it", data, want)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := newError(CodeNotify, "send the notification", errBroken)
+
+	if got, want := err.Message(), "E201: failed to send the notification: broken"; got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, errBroken) {
+		t.Error("errors.Is doesn't find the cause")
+	}
+	var target *Error
+	if !errors.As(error(err), &target) || target.Code != CodeNotify {
+		t.Error("errors.As doesn't find the *Error")
+	}
+}
+
+func TestLogError(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	logError(newError(CodeSound, "play the sound", errBroken))
+
+	path, err := errorLogPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "E202 play the sound: broken") {
+		t.Errorf("log = %q, want the code and error", data)
+	}
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(title, message string) error {
+	return errBroken
+}
+
+func TestNotifyFailure(t *testing.T) {
+	wantCode(t, notifyCmd(failingNotifier{}, "title", "")(), CodeNotify)
+}
+
+type failingListener struct{}
+
+func (failingListener) handle(Event) error {
+	return errBroken
+}
+
+func TestEventFailure(t *testing.T) {
+	d := newDispatcher(failingListener{})
+	wantCode(t, d.cmd(Event{Name: EventStart})(), CodeEvent)
+}
+
+func TestReadingFailure(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "missing.html")
+	wantCode(t, suggestCmd(source)(), CodeReading)
+}
+
+func TestHistoryFailure(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", brokenDir(t))
+
+	wantCode(t, recordCmd(Session{Phase: WORKTIME})(), CodeHistoryWrite)
+	wantCode(t, velocityCmd(map[string]int{"report": 4}, "report", 0, time.Now())(), CodeHistoryRead)
+}
+
+func TestStateFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", brokenDir(t))
+
+	var m model
+	m.writeState()
+	if !strings.HasPrefix(m.status, string(CodeState)) {
+		t.Errorf("status = %q, want a %s error", m.status, CodeState)
+	}
+}
+
+func TestSoundFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var m model
+	m, _ = m.updateFallback(fallbackMsg{channel: FallbackBell, err: errBroken})
+	if want := "E202: failed to play the sound: broken, using bell"; m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+}
+
+func TestMacroFailure(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", brokenDir(t))
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var m model
+	m, _ = m.submit(promptMacroName, "morning")
+	if !strings.HasPrefix(m.status, string(CodeMacro)) {
+		t.Errorf("status = %q, want a %s error", m.status, CodeMacro)
+	}
+}
+
+func TestDailyNoteFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	daily, err := newDailyNote(DailyNoteConfig{Path: filepath.Join(brokenDir(t), "{{date}}.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := daily.cmd(Session{Phase: WORKTIME, Start: time.Now()})()
+	wantCode(t, msg, CodeDailyNote)
+	wantFailure(t, model{}, msg, CodeDailyNote, "write the daily note")
+}
+
+func TestJournalFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", brokenDir(t))
+	archive, err := newJournalArchive(JournalFileConfig{Path: filepath.Join(t.TempDir(), "journal.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := archive.cmd(time.Now())()
+	wantCode(t, msg, CodeJournal)
+	wantFailure(t, model{}, msg, CodeJournal, "archive the journal")
+}
+
+func TestTimeTrackingFailure(t *testing.T) {
+	newTrackServer(t, http.StatusBadRequest)
+	tracking := newTestTracker(t, ServiceToggl)
+
+	msg := tracking.cmd(Session{Phase: WORKTIME, Start: time.Now()})()
+	wantCode(t, msg, CodeTimeTracking)
+	wantFailure(t, model{}, msg, CodeTimeTracking, "send the time entry")
+}
+
+func TestPhaseCommandFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	m := model{clock: time.Now, dir: t.TempDir()}
+	m.cfg.OnStart.Work = []PhaseCommand{{Run: "exit 3"}}
+
+	cmds := m.phaseCommands(Preset{Phase: WORKTIME})
+	if len(cmds) != 1 {
+		t.Fatalf("got %d commands, want 1", len(cmds))
+	}
+	wantFailure(t, m, cmds[0](), CodePhaseCommand, `run "exit 3"`)
+}
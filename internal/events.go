@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listener reacts to session events. User hooks and built-in integrations
+// such as Do Not Disturb are listeners.
+type listener interface {
+	handle(ev Event) error
+}
+
+// dispatcher delivers events to listeners in the background, one event at
+// a time and in the order they were emitted, so a "start" can never
+// overtake the "skip" that preceded it.
+type dispatcher struct {
+	listeners []listener
+
+	mu      sync.Mutex
+	turn    *sync.Cond
+	next    uint64 // ticket handed to the next emitted event
+	serving uint64 // ticket of the event allowed to run
+}
+
+// newDispatcher returns a dispatcher for the given listeners, skipping nil
+// ones left by disabled integrations.
+func newDispatcher(listeners ...listener) *dispatcher {
+	d := &dispatcher{}
+	for _, l := range listeners {
+		if l != nil {
+			d.listeners = append(d.listeners, l)
+		}
+	}
+	d.turn = sync.NewCond(&d.mu)
+	return d
+}
+
+// cmd returns a command delivering ev once every earlier event has been
+// delivered, reporting failures as an *Error.
+func (d *dispatcher) cmd(ev Event) tea.Cmd {
+	if len(d.listeners) == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	ticket := d.next
+	d.next++
+	d.mu.Unlock()
+
+	return func() tea.Msg {
+		d.mu.Lock()
+		for d.serving != ticket {
+			d.turn.Wait()
+		}
+		d.mu.Unlock()
+
+		err := d.dispatch(ev)
+
+		d.mu.Lock()
+		d.serving++
+		d.turn.Broadcast()
+		d.mu.Unlock()
+
+		if err != nil {
+			return newError(CodeEvent, "deliver the "+ev.Name+" event", err)
+		}
+		return nil
+	}
+}
+
+// dispatch delivers ev to every listener right away.
+func (d *dispatcher) dispatch(ev Event) error {
+	var errs []error
+	for _, l := range d.listeners {
+		errs = append(errs, l.handle(ev))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats accepted by ExportHistory.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ExportHistory writes the sessions that started within [from, to) in the
+// given format. A zero from or to leaves that end of the range open.
+func ExportHistory(w io.Writer, format string, from, to time.Time) error {
+	selected, err := querySessions(Query{From: from, To: to})
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+
+	switch format {
+	case FormatCSV:
+		return exportCSV(w, selected)
+	case FormatJSON:
+		return exportJSON(w, selected)
+	default:
+		return fmt.Errorf("unknown format %q, want %s or %s", format, FormatCSV, FormatJSON)
+	}
+}
+
+// exportCSV writes one row per session, durations in seconds.
+func exportCSV(w io.Writer, sessions []Session) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"phase", "preset", "task", "tags", "note", "start", "end", "planned", "paused", "overtime", "abandoned", "low_confidence", "source", "branch", "host", "directory"})
+	for _, s := range sessions {
+		_ = cw.Write([]string{
+			s.Phase,
+			s.Preset,
+			s.Task,
+			strings.Join(s.Tags, " "),
+			s.Note,
+			s.Start.Format(time.RFC3339),
+			s.End.Format(time.RFC3339),
+			strconv.Itoa(s.Planned),
+			strconv.Itoa(s.Paused),
+			strconv.Itoa(s.Overtime),
+			strconv.FormatBool(s.Abandoned),
+			strconv.FormatBool(s.LowConfidence),
+			s.Source,
+			s.Branch,
+			s.Host,
+			s.Directory,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportJSON writes the sessions as an indented JSON array.
+func exportJSON(w io.Writer, sessions []Session) error {
+	if sessions == nil {
+		sessions = []Session{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sessions)
+}
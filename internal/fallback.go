@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Channels that stand in for sounds when audio is unavailable.
+const (
+	FallbackTmux  = "tmux"  // a tmux display-message, inside tmux
+	FallbackFlash = "flash" // the interface flashes, in the TUI
+	FallbackBell  = "bell"  // the terminal bell, always available
+)
+
+// defaultFallbacks is the order fallback channels are tried in when the
+// config doesn't set one.
+var defaultFallbacks = []string{FallbackTmux, FallbackBell}
+
+// errNoAudio is returned by Play once the audio check found no output.
+var errNoAudio = errors.New("no audio output")
+
+// flashFor is how long the interface stays flashed.
+const flashFor = 500 * time.Millisecond
+
+// flashStyle inverts the interface while it flashes.
+var flashStyle = lipgloss.NewStyle().Reverse(true)
+
+// alertTexts holds the message IDs of what the tmux fallback, and the flash
+// with reduced motion, shows for each sound event.
+var alertTexts = map[string]string{
+	SoundWorkStart: "alert.work_start",
+	SoundWorkEnd:   "alert.work_end",
+	SoundRestEnd:   "alert.rest_end",
+	SoundPreroll:   "alert.preroll",
+	SoundTimer:     "alert.timer",
+	SoundWarning:   "alert.warning",
+	SoundGoal:      "alert.goal",
+}
+
+// validateFallbacks checks the fallback channels named in the config.
+func validateFallbacks(channels []string) error {
+	for _, ch := range channels {
+		switch ch {
+		case FallbackTmux, FallbackFlash, FallbackBell:
+		default:
+			return fmt.Errorf("unknown sound fallback %q, want %s, %s or %s", ch, FallbackTmux, FallbackFlash, FallbackBell)
+		}
+	}
+	return nil
+}
+
+// fallback alerts about event through the first available channel in the
+// configured order and returns the channel used, or "" when none was.
+// Flashing is only available in the TUI, which does the flashing itself.
+func (p *Player) fallback(event string, tui bool) string {
+	for _, ch := range p.fallbacks {
+		switch ch {
+		case FallbackTmux:
+			if os.Getenv("TMUX") == "" {
+				continue
+			}
+			if exec.Command("tmux", "display-message", "manta: "+tr(alertTexts[event])).Run() == nil {
+				return ch
+			}
+		case FallbackFlash:
+			if tui {
+				return ch
+			}
+		case FallbackBell:
+			ringBell()
+			return ch
+		}
+	}
+	return ""
+}
+
+// fallbackMsg reports that a sound was replaced by a fallback channel.
+type fallbackMsg struct {
+	event   string
+	channel string
+	err     error // why the sound couldn't be played, unless already known
+}
+
+// flashEndMsg ends the flash of the interface.
+type flashEndMsg struct{}
+
+// updateFallback flashes the interface when that is the fallback used and
+// reports playback failures that weren't known yet. With reduced motion
+// the alert is shown in the status line instead of flashing.
+func (m model) updateFallback(msg fallbackMsg) (model, tea.Cmd) {
+	if msg.err != nil {
+		m.fail(newError(CodeSound, "play the sound", msg.err))
+		m.status += tr("status.fallback", cmp.Or(msg.channel, tr("status.fallback_none")))
+	}
+	if msg.channel != FallbackFlash {
+		return m, nil
+	}
+	if m.cfg.ReducedMotion {
+		m.status = "🔔 " + tr(alertTexts[msg.event])
+		return m, nil
+	}
+	m.flash = true
+	return m, after(flashFor, func(time.Time) tea.Msg { return flashEndMsg{} })
+}
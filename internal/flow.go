@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// flow is a plan of work sessions run one after another, each on its own
+// task, with a break after each but the last.
+type flow struct {
+	tasks []string // task of each work session, in order
+	done  int      // work sessions of the plan finished or skipped
+}
+
+// planStep matches a step of a plan repeated a number of times, such as
+// "3x write report" or "3 × write report".
+var planStep = regexp.MustCompile(`^(\d+)\s*[x×]\s+(.+)$`)
+
+// parsePlan reads a plan such as "3x write report, 1x email triage" into
+// the task of each work session. A step without a count runs once.
+func parsePlan(s string) ([]string, error) {
+	var tasks []string
+	for _, step := range strings.Split(s, ",") {
+		step = strings.TrimSpace(step)
+		count, task := 1, step
+		if match := planStep.FindStringSubmatch(step); match != nil {
+			count, _ = strconv.Atoi(match[1])
+			task = strings.TrimSpace(match[2])
+		}
+		if task == "" {
+			return nil, fmt.Errorf("plan %q: empty step", s)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("plan %q: step %q runs no sessions", s, step)
+		}
+		for range count {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// WithPlan makes the model run the plan, such as "3x write report, 1x
+// email triage", as soon as it starts.
+func (m model) WithPlan(plan string) (model, error) {
+	tasks, err := parsePlan(plan)
+	if err != nil {
+		return m, err
+	}
+	m.flow = &flow{tasks: tasks}
+	return m, nil
+}
+
+// flowStartMsg starts the plan once the program runs.
+type flowStartMsg struct{}
+
+// startFlow starts the next work session of the plan.
+func (m *model) startFlow() tea.Cmd {
+	m.task = m.flow.tasks[m.flow.done]
+	return m.start(nextPreset(m.presets, Preset{Phase: RESTTIME}))
+}
+
+// advanceFlow follows a session of the plan that ended or was skipped
+// with what comes next: a break after a work session, the next work
+// session after a break, and nothing once the plan is through.
+func (m *model) advanceFlow() tea.Cmd {
+	if m.preset.Phase == RESTTIME {
+		return m.startFlow()
+	}
+	m.flow.done++
+	if m.flow.done < len(m.flow.tasks) {
+		return m.start(nextPreset(m.presets, m.preset))
+	}
+	m.status = tr("status.plan_done", len(m.flow.tasks))
+	m.flow = nil
+	m.timer.Stop()
+	m.removeState()
+	return nil
+}
+
+// flowView shows the work sessions of the plan, those done ticked off and
+// the current or next one marked.
+func (m model) flowView(pad string) string {
+	if m.flow == nil {
+		return ""
+	}
+	steps := make([]string, len(m.flow.tasks))
+	for i, task := range m.flow.tasks {
+		if m.locked {
+			task = tr("plan.session", i+1)
+		}
+		switch {
+		case i < m.flow.done:
+			steps[i] = "✓ " + task
+		case i == m.flow.done:
+			steps[i] = "▸ " + task
+		default:
+			steps[i] = task
+		}
+	}
+	return pad + m.theme.Help.Render(tr("plan.progress", min(m.flow.done+1, len(steps)), len(steps), strings.Join(steps, " · "))) + "\n\n"
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BreaksConfig reminds the user to take their breaks.
+type BreaksConfig struct {
+	// NudgeAfter shows a reminder when a work session starts after this
+	// many breaks were skipped or cut short today. Zero turns it off.
+	NudgeAfter int `json:"nudge_after"`
+}
+
+// tally counts the sessions of one day.
+type tally struct {
+	day     string // YYYY-MM-DD
+	done    int    // completed work sessions
+	rests   int    // breaks, taken or not
+	skipped int    // breaks skipped or cut short
+}
+
+// dayOf returns the local calendar day of t.
+func dayOf(t time.Time) string {
+	return t.Local().Format(time.DateOnly)
+}
+
+// loadTally counts the work sessions completed on the day of now from the
+// history.
+func loadTally(now time.Time) (tally, error) {
+	t := tally{day: dayOf(now)}
+	sessions, err := loadSessions()
+	if err != nil {
+		return t, err
+	}
+	for _, s := range sessions {
+		if dayOf(s.End) != t.day {
+			continue
+		}
+		switch {
+		case s.Phase == WORKTIME && !s.Abandoned:
+			t.done++
+		case s.Phase == RESTTIME:
+			t.rests++
+			if s.Abandoned {
+				t.skipped++
+			}
+		}
+	}
+	return t, nil
+}
+
+// tally returns today's counts, starting over when the day has changed.
+func (m *model) tally() *tally {
+	if day := dayOf(m.now); m.today.day != day {
+		m.today = tally{day: day}
+	}
+	return &m.today
+}
+
+// countRest counts a break that ended or was skipped.
+func (m *model) countRest(skipped bool) {
+	t := m.tally()
+	t.rests++
+	if skipped {
+		t.skipped++
+	}
+}
+
+// nudge reminds the user about the breaks they skipped today when a work
+// session starts.
+func (m *model) nudge() {
+	after := m.cfg.Breaks.NudgeAfter
+	if skipped := m.tally().skipped; after > 0 && skipped >= after {
+		m.status = tr("status.skipped_breaks", skipped)
+	}
+}
+
+// completeWork counts a finished work session toward the daily goal and
+// celebrates when it is reached.
+func (m *model) completeWork() tea.Cmd {
+	t := m.tally()
+	t.done++
+	m.countVelocity()
+
+	if m.cfg.Goal == 0 || t.done != m.cfg.Goal {
+		return nil
+	}
+	title := tr("notify.goal")
+	message := tr("notify.goal_body", t.done)
+	return tea.Batch(
+		m.pending.track(notifyCmd(m.notifier, title, message)),
+		m.pending.track(soundCmd(m.player, SoundGoal)),
+	)
+}
+
+// goalView shows the progress toward the daily goal.
+func (m model) goalView(pad string) string {
+	if m.cfg.Goal == 0 {
+		return ""
+	}
+	done := 0
+	if m.today.day == dayOf(m.now) {
+		done = m.today.done
+	}
+	line := tr("goal.line", done, m.cfg.Goal)
+	if m.cfg.Accessible {
+		// The color alone would tell the goal was reached.
+		line = tr("goal.line_plain", done, m.cfg.Goal)
+		if done >= m.cfg.Goal {
+			line += tr("goal.reached")
+		}
+	}
+	if done >= m.cfg.Goal {
+		return pad + m.theme.Selected.Render(line) + "\n\n"
+	}
+	return pad + m.theme.Help.Render(line) + "\n\n"
+}
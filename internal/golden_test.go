@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the screens rendered")
+
+// fakeClock is a clock moved by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) add(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// screen drives the model the way the program does, but in step: each
+// message is handled and the commands it returns are run to the end,
+// their messages handled in turn, before the next one. Timers are held
+// instead of waited on, so the screen only changes when the test presses
+// a key or moves the clock, and renders the same every time.
+type screen struct {
+	t       *testing.T
+	m       model
+	clock   *fakeClock
+	notices *notices
+}
+
+// newScreen returns a screen of 80 by 24 with cfg at noon on a fixed day,
+// keeping its data in temporary directories. The progress bar is drawn
+// without animation, whose frames come in real time, and the screen
+// without colors, whichever the terminal running the tests supports.
+func newScreen(t *testing.T, cfg Config) *screen {
+	t.Helper()
+	profile, timers := lipgloss.ColorProfile(), after
+	lipgloss.SetColorProfile(termenv.Ascii)
+	after = holdTimer
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(profile)
+		after = timers
+	})
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+		t.Setenv(env, t.TempDir())
+	}
+	if cfg.Presets == nil {
+		cfg.Presets = testPresets
+	}
+	cfg.Language = "en"
+	cfg.ReducedMotion = true
+
+	player, err := NewPlayer(SoundConfig{Backend: BackendNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 12, 0, 0, 0, time.Local)}
+	n := &notices{}
+	m, err := newModel(cfg, player, n, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &screen{t: t, m: m, clock: clock, notices: n}
+	s.run(m.Init())
+	s.send(tea.WindowSizeMsg{Width: 80, Height: 24})
+	return s
+}
+
+// send handles msg and runs the commands it returns.
+func (s *screen) send(msg tea.Msg) {
+	next, cmd := s.m.Update(msg)
+	s.m = next.(model)
+	s.run(cmd)
+}
+
+// press handles keys as typed, by their Bubble Tea names.
+func (s *screen) press(keys ...string) {
+	for _, k := range keys {
+		s.send(keyMsg(k))
+	}
+}
+
+// tickStep is how far apart the ticks of advance are, close enough for
+// the computer not to seem to have slept between them.
+const tickStep = sleepGap - time.Second
+
+// advance moves the clock on by d, ticking along the way. Only the
+// commands of the last tick are run.
+func (s *screen) advance(d time.Duration) {
+	for ; d > tickStep; d -= tickStep {
+		s.m, _ = s.m.updateTick(s.clock.add(tickStep))
+	}
+	s.send(tickMsg(s.clock.add(d)))
+}
+
+// run runs cmd and handles the message it returns, and those of a batch
+// in the order of the batch.
+func (s *screen) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			s.run(c)
+		}
+		return
+	}
+	s.handle(msg)
+}
+
+// handle takes in a message a command returned. Ticks are left to the
+// test, as they carry the time.
+func (s *screen) handle(msg tea.Msg) {
+	switch msg.(type) {
+	case nil, tickMsg:
+	default:
+		s.send(msg)
+	}
+}
+
+// holdTimer stands in for after on a screen: the timer never fires, as
+// the time only moves when the test moves it, and the ticks that follow
+// it are sent by advance.
+func holdTimer(time.Duration, func(time.Time) tea.Msg) tea.Cmd {
+	return nil
+}
+
+// golden checks the screen against testdata/golden/name.golden, or
+// rewrites it with -update.
+func (s *screen) golden(name string) {
+	s.t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	got := s.m.View()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			s.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			s.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		s.t.Fatalf("%v; run the tests with -update to write it", err)
+	}
+	if got != string(want) {
+		s.t.Errorf("%s screen changed; run the tests with -update if it should have\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestScreens(t *testing.T) {
+	s := newScreen(t, Config{})
+	s.golden("chooser")
+
+	s.press("enter")
+	s.advance(7 * time.Minute)
+	s.golden("running")
+
+	s.press("space")
+	s.advance(time.Minute)
+	s.golden("paused")
+
+	s.press("space")
+	s.advance(18*time.Minute + time.Second)
+	s.golden("finished")
+
+	s.press("down", "down", "down", "enter")
+	s.golden("stats")
+}
+
+// A plan moves on to the break by itself, so the end of the work session
+// isn't alerted again during it.
+func TestPlanRepeat(t *testing.T) {
+	s := newScreen(t, Config{Notify: NotifyConfig{Repeat: Duration(time.Minute)}})
+	var err error
+	if s.m, err = s.m.WithPlan("2x docs"); err != nil {
+		t.Fatal(err)
+	}
+	s.send(flowStartMsg{})
+
+	s.advance(25*time.Minute + time.Second)
+	if s.m.preset.Phase != RESTTIME {
+		t.Fatalf("phase = %s, want the break of the plan", s.m.preset.Phase)
+	}
+	for range 3 {
+		s.advance(time.Minute)
+	}
+	if got := s.notices.sent(); len(got) != 1 || got[0] != "Time to work is left" {
+		t.Errorf("notifications = %q, want the end of work once", got)
+	}
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+// ErrInterrupted is returned by RunHeadless when the session is cancelled
+// before it ends.
+var ErrInterrupted = errors.New("interrupted")
+
+// RunHeadless runs a single session of preset p without the terminal UI,
+// for scripts. It prints the remaining time to out once a minute, fires
+// hooks, notifications and the end sound like the UI does, and returns
+// once the session ends or ctx is cancelled. Failing hooks and
+// notifications are reported to out without stopping the session.
+func RunHeadless(ctx context.Context, cfg Config, player *Player, notifier Notifier, p Preset, task string, out io.Writer) error {
+	if err := useLanguage(cfg.Language); err != nil {
+		return err
+	}
+	if err := useHistory(cfg); err != nil {
+		return err
+	}
+	hooks, err := newHooks(cfg.Hooks)
+	if err != nil {
+		return err
+	}
+	dnd, err := newDND(cfg.DND)
+	if err != nil {
+		return err
+	}
+	strict, err := newStrict(cfg.Strict)
+	if err != nil {
+		return err
+	}
+	tint, err := newTint(cfg.Tint)
+	if err != nil {
+		return err
+	}
+	rules, err := newTagRules(cfg.Tags)
+	if err != nil {
+		return err
+	}
+	daily, err := newDailyNote(cfg.DailyNote)
+	if err != nil {
+		return err
+	}
+	tracking, err := newTimeTracker(cfg.Tracking)
+	if err != nil {
+		return err
+	}
+	privacy, err := cfg.Privacy.redactor()
+	if err != nil {
+		return err
+	}
+	events := newDispatcher(hooks, dnd, strict, tint, newCalendar(cfg.Calendar), newSlack(cfg.Slack))
+
+	start := wallClock(time.Now())
+	t := timer.Start(time.Duration(p.Duration), start)
+	t.WarnBefore(start, warnings(cfg.Notify, p)...)
+	end := t.End(start)
+	s := Session{
+		Phase:   p.Phase,
+		Preset:  p.Name,
+		Task:    task,
+		Tags:    rules.tags(launchDir(), task),
+		Start:   start,
+		Planned: int(time.Duration(p.Duration) / time.Second),
+		Context: captureContext(cfg.Context),
+	}
+
+	// fail prints and logs a failure that doesn't stop the session.
+	fail := func(code Code, op string, err error) {
+		if err != nil {
+			e := newError(code, op, err)
+			fmt.Fprintln(out, e.Message())
+			logError(e)
+		}
+	}
+
+	// emit delivers an event right away; a failing hook is reported but
+	// doesn't stop the session.
+	emit := func(name string, now time.Time) {
+		ev := Event{
+			Name:      name,
+			Phase:     p.Phase,
+			Preset:    p.Name,
+			Task:      task,
+			Remaining: t.SecondsLeft(now),
+			EndTime:   end,
+			Time:      now,
+		}
+		fail(CodeEvent, "deliver the "+name+" event", events.dispatch(ev))
+	}
+
+	fail(CodeState, "save the state file", saveState(State{
+		Phase:     p.Phase,
+		Preset:    p.Name,
+		Task:      task,
+		Tags:      s.Tags,
+		Remaining: s.Planned,
+		EndTime:   end,
+		UpdatedAt: start,
+		Start:     start,
+		Planned:   s.Planned,
+	}))
+	defer func() { fail(CodeState, "remove the state file", clearState()) }()
+
+	fail(CodeHistoryWrite, "record a change of settings", recordSettings(cfg))
+	emit(EventStart, start)
+	fmt.Fprintf(out, "%s started, ends at %s\n", p.Name, end.Format("15:04:05"))
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			now := wallClock(time.Now())
+			s.End, s.Abandoned = now, true
+			emit(EventQuit, now)
+			fail(CodeHistoryWrite, "save the session", appendSession(s))
+			return ErrInterrupted
+
+		case tick := <-ticker.C:
+			now := wallClock(tick)
+			switch t.Tick(now) {
+			case timer.Warning:
+				title := warningTitle(p.Name, t.Remaining(now))
+				fmt.Fprintln(out, title)
+				if err := player.Play(SoundWarning); err != nil {
+					player.fallback(SoundWarning, false)
+				}
+				fail(CodeNotify, "send the notification", notifier.Notify(title, tr("notify.wrap_up")))
+				continue
+			case timer.NoEvent:
+				if secs := t.SecondsLeft(now); secs%60 == 0 {
+					fmt.Fprintf(out, "%s %02d:00 left\n", p.Name, secs/60)
+				}
+				continue
+			}
+
+			// After a suspend the end may be noticed late; it still
+			// happened on time.
+			s.End = end
+			emit(EventEnd, now)
+			fmt.Fprintf(out, "%s done\n", p.Name)
+
+			event := SoundWorkEnd
+			if p.Phase == RESTTIME {
+				event = SoundRestEnd
+			}
+			if err := player.Play(event); err != nil {
+				player.fallback(event, false)
+			}
+			fail(CodeNotify, "send the notification", notifier.Notify(tr("notify.end", p.Name), ""))
+			if err := appendSession(s); err != nil {
+				e := newError(CodeHistoryWrite, "save the session", err)
+				logError(e)
+				return e
+			}
+			shared := privacy.redact(s)
+			fail(CodeDailyNote, "write the daily note", daily.logFinished(shared))
+			if tracking.tracks(shared) {
+				fail(CodeTimeTracking, "send the time entry", tracking.send(tracking.entry(shared)))
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Session is one finished session as recorded in the history file.
+type Session struct {
+	Phase    string    `json:"phase"`
+	Preset   string    `json:"preset,omitempty"`
+	Task     string    `json:"task,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Planned  int       `json:"planned"`            // seconds
+	Paused   int       `json:"paused,omitempty"`   // seconds
+	Overtime int       `json:"overtime,omitempty"` // seconds
+	Snoozed  int       `json:"snoozed,omitempty"`  // seconds held off the next session
+	Estimate int       `json:"estimate,omitempty"` // work sessions the task is expected to take
+	Laps     []int     `json:"laps,omitempty"`     // seconds into a stopwatch session
+
+	// Abandoned marks a session that was skipped before it ended.
+	Abandoned bool `json:"abandoned,omitempty"`
+
+	// LowConfidence marks a work session with a long stretch without
+	// keyboard or mouse input, which may not have been worked.
+	LowConfidence bool `json:"low_confidence,omitempty"`
+
+	// ShutDown marks a session the machine shut down in the middle of,
+	// whether it was then resumed, completed or abandoned.
+	ShutDown bool `json:"shut_down,omitempty"`
+
+	// Away is how many seconds of a break were spent away from the
+	// screen, with it locked or without input, when breaks are checked.
+	Away *int `json:"away,omitempty"`
+
+	// RitualSkipped lists the steps of the focus ritual skipped before a
+	// work session.
+	RitualSkipped []string `json:"ritual_skipped,omitempty"`
+
+	// Interruptions are the breaks in focus logged during a work session.
+	Interruptions []Interruption `json:"interruptions,omitempty"`
+
+	// Source is where a session not timed by manta came from, such as
+	// "import".
+	Source string `json:"source,omitempty"`
+
+	// Context is where manta was launched, when enabled in the config.
+	Context
+}
+
+// dataDir returns the directory manta keeps its data in, following the XDG
+// base directory spec where it applies.
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "manta"), nil
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "manta"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "manta"), nil
+}
+
+// appendSession adds s to the history.
+func appendSession(s Session) error {
+	store, err := history()
+	if err != nil {
+		return err
+	}
+	return store.Append(s)
+}
+
+// loadSessions reads every session from the history.
+func loadSessions() ([]Session, error) {
+	store, err := history()
+	if err != nil {
+		return nil, err
+	}
+	return store.Query(Query{})
+}
+
+// querySessions reads the sessions matching q from the history.
+func querySessions(q Query) ([]Session, error) {
+	store, err := history()
+	if err != nil {
+		return nil, err
+	}
+	return store.Query(q)
+}
+
+// loadChanges reads the changes of settings from the history.
+func loadChanges() ([]Change, error) {
+	store, err := history()
+	if err != nil {
+		return nil, err
+	}
+	return store.Changes()
+}
+
+// record saves s to the history in the background, and to the daily note
+// and the time tracker when it is a finished work session. All of them get
+// s redacted as the privacy settings say.
+func (m model) record(s Session) tea.Cmd {
+	shared := m.privacy.redact(s)
+	return tea.Batch(m.pending.track(recordCmd(s)), m.pending.track(m.dailyNote.cmd(shared)), m.pending.track(m.tracking.cmd(shared)))
+}
+
+// recordCmd appends s to the history in the background, reporting failures
+// as an *Error.
+func recordCmd(s Session) tea.Cmd {
+	return func() tea.Msg {
+		if err := appendSession(s); err != nil {
+			return newError(CodeHistoryWrite, "save the session", err)
+		}
+		return nil
+	}
+}
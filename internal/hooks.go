@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ihorbryk/manta/pkg/event"
+)
+
+// Session events that hooks can subscribe to.
+const (
+	EventStart  = event.Start
+	EventEnd    = event.End
+	EventPause  = event.Pause
+	EventResume = event.Resume
+	EventSkip   = event.Skip
+	EventReset  = event.Reset
+	EventQuit   = event.Quit
+)
+
+var events = []string{EventStart, EventEnd, EventPause, EventResume, EventSkip, EventReset, EventQuit}
+
+// HookConfig runs a shell command and/or posts to a webhook whenever one of
+// its events fires. An empty event list subscribes to every event.
+type HookConfig struct {
+	Events  []string `json:"events"`
+	Command string   `json:"command"`
+	Webhook string   `json:"webhook"`
+
+	// Secret signs webhook payloads so receivers can verify them.
+	Secret string `json:"secret"`
+}
+
+// Event describes something that happened to the session. It is the type
+// of pkg/event, published for programs embedding the timer.
+type Event = event.Event
+
+// Hooks is the validated list of configured hooks.
+type Hooks []HookConfig
+
+// newHooks checks the configured hooks.
+func newHooks(cfg []HookConfig) (Hooks, error) {
+	for i, h := range cfg {
+		if h.Command == "" && h.Webhook == "" {
+			return nil, fmt.Errorf("hook %d: needs a command or a webhook", i+1)
+		}
+		for _, e := range h.Events {
+			if !slices.Contains(events, e) {
+				return nil, fmt.Errorf("hook %d: unknown event %q", i+1, e)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// handle fires every hook subscribed to ev and returns their combined errors.
+func (hs Hooks) handle(ev Event) error {
+	var errs []error
+	for _, h := range hs {
+		if len(h.Events) > 0 && !slices.Contains(h.Events, ev.Name) {
+			continue
+		}
+		if h.Command != "" {
+			errs = append(errs, runCommand(h.Command, ev))
+		}
+		if h.Webhook != "" {
+			errs = append(errs, postWebhook(h.Webhook, h.Secret, ev))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("hook failed: %w", err)
+	}
+	return nil
+}
+
+// hookTimeout is how long a hook command runs before it is stopped. Hooks
+// are delivered in turn, so a command that hangs would hold up every
+// listener after it.
+const hookTimeout = 10 * time.Second
+
+func runCommand(command string, ev Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		"MANTA_EVENT="+ev.Name,
+		"MANTA_PHASE="+ev.Phase,
+		"MANTA_PRESET="+ev.Preset,
+		"MANTA_TASK="+ev.Task,
+		"MANTA_REMAINING="+strconv.Itoa(ev.Remaining),
+		"MANTA_END_TIME="+ev.EndTime.Format(time.RFC3339),
+	)
+	// Don't wait on children that outlive the shell for the output they
+	// hold open.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	switch {
+	case ctx.Err() != nil:
+		return fmt.Errorf("%q: timed out after %s", command, hookTimeout)
+	case errors.Is(err, exec.ErrWaitDelay):
+		return nil
+	case err != nil:
+		return fmt.Errorf("%q: %w: %s", command, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func postWebhook(url, secret string, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Manta-Timestamp", timestamp)
+		req.Header.Set("X-Manta-Signature", "sha256="+signPayload(secret, timestamp, body))
+	}
+	return send(req)
+}
+
+// signPayload returns the hex HMAC-SHA256 of "timestamp.body" keyed with
+// secret. Including the timestamp lets receivers reject replayed requests.
+func signPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Hotkey daemons manta hotkeys writes global bindings for.
+const (
+	HotkeysSkhd     = "skhd"  // macOS
+	HotkeysSxhkd    = "sxhkd" // X11
+	HotkeysSway     = "sway"  // also i3
+	HotkeysHyprland = "hyprland"
+	HotkeysGnome    = "gnome" // custom shortcuts set with gsettings
+)
+
+// hotkeyModifiers are the modifiers a global key may hold, as named in the
+// config, and how each daemon spells them.
+var hotkeyModifiers = map[string]map[string]string{
+	"ctrl":  {HotkeysSkhd: "ctrl", HotkeysSxhkd: "ctrl", HotkeysSway: "Ctrl", HotkeysHyprland: "CTRL", HotkeysGnome: "<Control>"},
+	"alt":   {HotkeysSkhd: "alt", HotkeysSxhkd: "alt", HotkeysSway: "Mod1", HotkeysHyprland: "ALT", HotkeysGnome: "<Alt>"},
+	"shift": {HotkeysSkhd: "shift", HotkeysSxhkd: "shift", HotkeysSway: "Shift", HotkeysHyprland: "SHIFT", HotkeysGnome: "<Shift>"},
+	"super": {HotkeysSkhd: "cmd", HotkeysSxhkd: "super", HotkeysSway: "Mod4", HotkeysHyprland: "SUPER", HotkeysGnome: "<Super>"},
+}
+
+// hotkey is a global key split into its modifiers and the key itself.
+type hotkey struct {
+	mods []string
+	key  string
+}
+
+// parseHotkey reads a global key such as "ctrl+alt+p". "cmd" is another
+// name for "super".
+func parseHotkey(s string) (hotkey, error) {
+	parts := strings.Split(strings.ToLower(s), "+")
+	h := hotkey{key: parts[len(parts)-1]}
+	if h.key == "" {
+		return hotkey{}, fmt.Errorf("global key %q: missing the key", s)
+	}
+	for _, mod := range parts[:len(parts)-1] {
+		if mod == "cmd" {
+			mod = "super"
+		}
+		if _, ok := hotkeyModifiers[mod]; !ok {
+			return hotkey{}, fmt.Errorf("global key %q: unknown modifier %q, want ctrl, alt, shift or super", s, mod)
+		}
+		h.mods = append(h.mods, mod)
+	}
+	if len(h.mods) == 0 {
+		return hotkey{}, fmt.Errorf("global key %q: needs a modifier, or it would be taken from every app", s)
+	}
+	return h, nil
+}
+
+// validateGlobalKeys checks the global keys and the actions they trigger.
+func validateGlobalKeys(keys map[string]string) error {
+	defaults := defaultKeys()
+	actions := defaults.actions()
+	for action, key := range keys {
+		if _, ok := actions[action]; !ok {
+			return fmt.Errorf("global key for unknown action %q", action)
+		}
+		if _, err := parseHotkey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spell writes h the way daemon expects it.
+func (h hotkey) spell(daemon string) string {
+	mods := make([]string, len(h.mods))
+	for i, mod := range h.mods {
+		mods[i] = hotkeyModifiers[mod][daemon]
+	}
+	switch daemon {
+	case HotkeysSkhd:
+		return strings.Join(mods, " + ") + " - " + h.key
+	case HotkeysSxhkd:
+		return strings.Join(append(mods, h.key), " + ")
+	case HotkeysSway:
+		return strings.Join(append(mods, h.key), "+")
+	case HotkeysHyprland:
+		return strings.Join(mods, " ") + ", " + strings.ToUpper(h.key)
+	default:
+		return strings.Join(mods, "") + h.key
+	}
+}
+
+// Hotkeys writes bindings of the global keys for daemon, each running
+// `manta control` with its action, so the running timer can be driven
+// from any app.
+func Hotkeys(w io.Writer, daemon string, keys map[string]string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("no global keys configured under \"global_keys\"")
+	}
+	if err := validateGlobalKeys(keys); err != nil {
+		return err
+	}
+
+	var s strings.Builder
+	actions := slices.Sorted(maps.Keys(keys))
+	for _, action := range actions {
+		h, _ := parseHotkey(keys[action])
+		key, command := h.spell(daemon), "manta control "+action
+		switch daemon {
+		case HotkeysSkhd:
+			fmt.Fprintf(&s, "%s : %s\n", key, command)
+		case HotkeysSxhkd:
+			fmt.Fprintf(&s, "%s\n    %s\n", key, command)
+		case HotkeysSway:
+			fmt.Fprintf(&s, "bindsym %s exec %s\n", key, command)
+		case HotkeysHyprland:
+			fmt.Fprintf(&s, "bind = %s, exec, %s\n", key, command)
+		case HotkeysGnome:
+			path := fmt.Sprintf("/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/manta-%s/", action)
+			schema := "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:" + path
+			fmt.Fprintf(&s, "gsettings set %s name 'manta %s'\n", schema, action)
+			fmt.Fprintf(&s, "gsettings set %s command '%s'\n", schema, command)
+			fmt.Fprintf(&s, "gsettings set %s binding '%s'\n", schema, key)
+		default:
+			return fmt.Errorf("unknown hotkey daemon %q, want %s, %s, %s, %s or %s",
+				daemon, HotkeysSkhd, HotkeysSxhkd, HotkeysSway, HotkeysHyprland, HotkeysGnome)
+		}
+	}
+	if daemon == HotkeysGnome {
+		s.WriteString(gnomeShortcutList(actions))
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// gnomeShortcutList lists manta's shortcuts as custom shortcuts, which
+// GNOME only uses once listed. The list replaces the one GNOME has, so it
+// is left for the user to merge with the shortcuts they already have.
+func gnomeShortcutList(actions []string) string {
+	paths := make([]string, len(actions))
+	for i, action := range actions {
+		paths[i] = fmt.Sprintf("'/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/manta-%s/'", action)
+	}
+	return "# Add these to your custom shortcuts, listed by\n" +
+		"#   gsettings get org.gnome.settings-daemon.plugins.media-keys custom-keybindings\n" +
+		"# gsettings set org.gnome.settings-daemon.plugins.media-keys custom-keybindings \"[" + strings.Join(paths, ", ") + "]\"\n"
+}
+
+// Control performs a key action in the running manta, as a global hotkey
+// does.
+func Control(action string) error {
+	keys := defaultKeys()
+	if _, ok := keys.actions()[action]; !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	return sendControl(action)
+}
@@ -0,0 +1,343 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ihorbryk/manta/assets"
+)
+
+// messages is a catalog of the strings shown to the user, keyed by message
+// ID. Each is a fmt format, so a translation may reorder the arguments with
+// %[2]s and the like.
+type messages map[string]string
+
+// english is the built-in catalog. Translations fill in the IDs they have
+// and fall back to it for the rest.
+var english = messages{
+	"chooser.title": "Choose time type",
+
+	"menu.next":   "Start %s",
+	"menu.extend": "Extend %dm",
+	"menu.note":   "Add a note",
+	"menu.stats":  "Today's stats",
+	"menu.done":   "Back to presets",
+	"menu.snooze": "Snooze %s",
+	"menu.over":   "%s is over",
+
+	"key.up":        "up",
+	"key.down":      "down",
+	"key.start":     "start",
+	"key.schedule":  "start at",
+	"key.timer":     "add timer",
+	"key.focus":     "next timer",
+	"key.pause":     "pause",
+	"key.skip":      "skip",
+	"key.restart":   "restart",
+	"key.reset":     "stop",
+	"key.big":       "big clock",
+	"key.mute":      "mute",
+	"key.quiet":     "quiet",
+	"key.unquiet":   "sounds on",
+	"key.task":      "set task",
+	"key.notes":     "notes",
+	"key.log":       "log",
+	"key.record":    "record macro",
+	"key.lock":      "lock",
+	"key.interrupt": "interruption",
+	"key.stopwatch": "stopwatch",
+	"key.lap":       "lap",
+	"key.profile":   "profile",
+	"key.snooze":    "snooze",
+	"key.theme":     "light/dark",
+	"key.yes":       "yes",
+	"key.no":        "no",
+	"key.complete":  "complete",
+	"key.help":      "help",
+	"key.quit":      "quit",
+
+	// Key help shown in place of the above on some screens.
+	"key.resume":       "resume",
+	"key.select":       "select",
+	"key.unmute":       "unmute",
+	"key.finish":       "finish",
+	"key.finish_next":  "finish & next",
+	"key.stop_save":    "stop & save",
+	"key.pause_timer":  "pause %s",
+	"key.resume_timer": "resume %s",
+	"key.stop_timer":   "stop %s",
+
+	"plan.progress": "Plan %d/%d: %s",
+	"plan.session":  "session %d",
+
+	"log.title": "Log",
+	"log.empty": "No commands have run yet.",
+
+	"alert.work_start": "work session started",
+	"alert.work_end":   "work session over",
+	"alert.rest_end":   "break over",
+	"alert.preroll":    "session starting",
+	"alert.timer":      "timer done",
+	"alert.warning":    "session ends soon",
+	"alert.goal":       "daily goal reached",
+
+	"transition.rest":      "Break time! 🎉",
+	"transition.rest_body": "%s has begun, step away for a bit",
+	"transition.work":      "Back to work 💪",
+	"transition.work_body": "%s has begun",
+	"transition.dismiss":   "press any key to continue",
+
+	"notify.end":            "Time to %s is left",
+	"notify.warning":        "%s ends in %s",
+	"notify.wrap_up":        "Time to wrap up",
+	"notify.started":        "%s started",
+	"notify.ends_at":        "Ends at %s",
+	"notify.counting_up":    "Counting up",
+	"notify.scheduled":      "Your %s session scheduled for %s has begun",
+	"notify.goal":           "Daily goal reached 🎉",
+	"notify.goal_body":      "%d work sessions done today",
+	"notify.idle":           "Welcome back",
+	"notify.idle_body":      "Your %s session is paused",
+	"notify.timer":          "%s is done",
+	"notify.timer_body":     "Your %s timer has run out",
+	"notify.step_away":      "Step away from the screen",
+	"notify.step_away_body": "Your %s has %s left",
+	"notify.summary":        "Today's summary",
+	"notify.summary_body":   "Work sessions: %d · Focus: %s · Tasks: %d",
+	"notify.summary_goal":   " · Goal: %d of %d",
+	"notify.power":          "Power saver on",
+	"notify.power_body":     "Battery at %d%%: refreshing less and holding sounds back",
+
+	"status.plan_done":          "Plan done: %d work sessions",
+	"status.plan_stopped":       "Plan stopped",
+	"status.budget":             "⚠ This session goes over the weekly budget: %s",
+	"status.skipped_breaks":     "You've skipped %d breaks today, take the next one",
+	"status.call_disabled":      "Call detection disabled: %v",
+	"status.call_paused":        "Paused for a call",
+	"status.call_ended":         "Call ended, session resumed",
+	"status.idle_disabled":      "Idle detection disabled: %v",
+	"status.away":               "Paused while you were away since %s, press %s to resume",
+	"status.power_disabled":     "Power saver disabled: %v",
+	"status.power_off":          "Power saver off",
+	"status.power_on":           "Power saver on: battery at %d%%",
+	"status.ended_closed":       "%s ended at %s while manta was closed",
+	"status.ended_asleep":       "%s ended at %s while the computer was asleep",
+	"status.paused_asleep":      "Paused at %s when the computer went to sleep",
+	"status.no_sound":           ", alerting without sound (see manta doctor)",
+	"status.fallback":           ", using %s",
+	"status.fallback_none":      "nothing",
+	"status.quiet":              "Quiet: sounds are held back",
+	"status.sounds_on":          "Sounds are back on",
+	"status.locked":             "Locked, press any key to unlock",
+	"status.wrong_passphrase":   "Wrong passphrase",
+	"status.lock_empty":         "Not locked, the passphrase can't be empty",
+	"status.stop_recording":     "Stop recording the macro before locking",
+	"status.recording":          "Recording macro, press %s to stop",
+	"status.macro_discarded":    "Macro discarded",
+	"status.macro_saved":        "Saved macro %[1]q, replay it with manta run -macro %[1]q",
+	"status.interruption":       "Logged interruption %d",
+	"status.lap":                "Lap %d at %s",
+	"status.timer_stopped":      "%s stopped",
+	"status.timer_done":         "%s is done",
+	"status.unknown_profile":    "Unknown profile %q",
+	"status.schedule_cancelled": "Schedule cancelled",
+	"prompt.task":               "Task: ",
+	"prompt.schedule":           "Start %s at (HH:MM): ",
+	"prompt.timer":              "Timer (e.g. tea 4m): ",
+	"prompt.lock":               "Lock with passphrase: ",
+	"prompt.unlock":             "Passphrase: ",
+	"prompt.macro":              "Macro name: ",
+	"prompt.journal":            "What did you accomplish? ",
+	"prompt.note":               "Note: ",
+	"prompt.profile":            "Profile (%s, empty for none): ",
+	"prompt.interrupt":          "Interruption ([i]nternal/[e]xternal, note): ",
+	"view.overtime":             "+%02dm%02ds overtime",
+	"view.elapsed":              "%s elapsed %s",
+	"view.paused":               " (paused)",
+	"view.tags":                 "Tags: #",
+	"view.article":              "%s (read until %s)",
+	"notes.title":               "Notes",
+	"notes.empty":               "No notes yet.",
+	"notes.help":                "%s/%s scroll • %s close",
+	"stats.today":               "Today: %d work sessions, %s of focus",
+	"stats.today_one":           "Today: %d work session, %s of focus",
+	"stats.skipped":             ", %d of %d breaks skipped (%d%%)",
+	"sleep.ask":                 "The computer slept for %s. Count it toward the session? (%s/%s)",
+	"schedule.title":            "%s starts in %s",
+	"schedule.help":             "at %s • %s start now • %s cancel",
+	"preroll.title":             "%s starts in %d…",
+	"preroll.help":              "%s start now • %s cancel",
+	"snooze.title":              "Snoozed, %s starts in %s",
+	"snooze.help":               "%s start now • %s back to presets",
+	"ritual.title":              "Before %s:",
+	"ritual.help":               "%s done • %s skip • %s cancel",
+	"ritual.skipped":            "%d ritual steps skipped (%s)",
+	"ritual.skipped_one":        "%d ritual step skipped (%s)",
+	"interrupt.count":           "%d interruptions",
+	"interrupt.count_one":       "%d interruption",
+	"interrupt.internal":        "%d internal",
+	"interrupt.external":        "%d external",
+	"goal.line":                 "%d/%d 🍅 today",
+	"goal.line_plain":           "%d of %d work sessions today",
+	"goal.reached":              ", goal reached",
+	"velocity.line":             "📈 %d/%d 🍅 · %.1f/day",
+	"velocity.reached":          " · estimate reached",
+	"velocity.done_by":          " · done by %s",
+	"velocity.idle":             " · no sessions this week",
+	"stopwatch.lap":             "Lap %d  %s  +%s",
+	"resume.shutdown":           "%s. Resume, complete or abandon it? (%s/%s/%s)",
+	"resume.left":               "%02d:%02d left",
+	"resume.overtime":           "in overtime",
+	"resume.counted":            "%s counted",
+	"resume.ask":                "Resume %s (%s)? (%s/%s, %s to complete it)",
+	"shutdown.session":          "%s session",
+	"shutdown.task":             "%s session %q",
+	"shutdown.stopwatch":        "%s had run for %s at shutdown",
+	"shutdown.ended":            "%s had ended by shutdown, %s over",
+	"shutdown.left":             "%s had %s left at shutdown",
+	"status.slow":               "Slow terminal: refreshing once a second",
+	"plain.session":             "%s session: %s.",
+	"plain.no_task":             "no task",
+	"plain.running":             "Running",
+	"plain.paused":              "Paused",
+	"plain.elapsed":             "%s. %s elapsed.",
+	"plain.overtime":            "Ended. %s in overtime.",
+	"plain.left":                "%s. %s left, ends at %s. Progress: %d percent.",
+	"plain.minutes":             "%d minutes",
+	"plain.minutes_one":         "%d minute",
+	"tray.pause":                "Pause",
+	"tray.pause_tip":            "Pause or resume the session",
+	"tray.resume":               "Resume",
+	"tray.skip":                 "Skip",
+	"tray.skip_tip":             "Skip to the next session",
+	"tray.quit":                 "Quit manta",
+	"tray.quit_tip":             "Quit the timer",
+	"tray.close":                "Close tray",
+	"tray.close_tip":            "Close the tray, leaving the timer running",
+	"tray.idle":                 "No session running",
+}
+
+// catalog is the catalog in use, English until useLanguage picks another.
+var catalog atomic.Pointer[messages]
+
+func init() {
+	catalog.Store(&english)
+}
+
+// tr returns the message id of the catalog in use, formatted with args.
+func tr(id string, args ...any) string {
+	format, ok := (*catalog.Load())[id]
+	if !ok {
+		format = english[id]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// localeLanguage returns the language set for messages in the
+// environment, "uk_UA" for LANG=uk_UA.UTF-8, or "" for the C locale.
+func localeLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			lang, _, _ := strings.Cut(v, ".")
+			lang, _, _ = strings.Cut(lang, "@")
+			if lang == "C" || lang == "POSIX" {
+				return ""
+			}
+			return lang
+		}
+	}
+	return ""
+}
+
+// localesDir returns the directory with the user's translations, next to
+// the config file.
+func localesDir() (string, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "locales"), nil
+}
+
+// readCatalog reads the translation named name, such as "uk.json", from
+// fsys into c. It reports whether there was one.
+func readCatalog(fsys fs.FS, name string, c messages) (bool, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return false, fmt.Errorf("translation %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// loadCatalog builds the catalog of lang, such as "uk" or "pt_BR", from
+// the translations shipped with manta and those in the user's locales
+// directory, which take precedence. A regional language falls back to its
+// base one, "pt_BR" to "pt", and every catalog to English. It reports
+// whether there is a translation for lang at all.
+func loadCatalog(lang string) (messages, bool, error) {
+	lang = strings.ReplaceAll(lang, "-", "_")
+	base, _, _ := strings.Cut(lang, "_")
+	if lang == "" || base == "en" {
+		return english, true, nil
+	}
+	names := []string{base + ".json"}
+	if base != lang {
+		names = append(names, lang+".json")
+	}
+
+	shipped, err := fs.Sub(assets.Locales, "locales")
+	if err != nil {
+		return nil, false, err
+	}
+	sources := []fs.FS{shipped}
+	if dir, err := localesDir(); err == nil {
+		sources = append(sources, os.DirFS(dir))
+	}
+	c := maps.Clone(english)
+	found := false
+	for _, fsys := range sources {
+		for _, name := range names {
+			ok, err := readCatalog(fsys, name, c)
+			if err != nil {
+				return nil, false, err
+			}
+			found = found || ok
+		}
+	}
+	return c, found, nil
+}
+
+// useLanguage switches the messages to lang, or to the language of the
+// environment when it is empty. A language only asked for by the
+// environment falls back to English when there's no translation for it.
+func useLanguage(lang string) error {
+	configured := lang != ""
+	if !configured {
+		lang = localeLanguage()
+	}
+	c, found, err := loadCatalog(lang)
+	if err != nil {
+		return err
+	}
+	if !found && configured {
+		return fmt.Errorf("no translation for language %q", lang)
+	}
+	catalog.Store(&c)
+	return nil
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsEvent is a VEVENT, reduced to what manta needs.
+type icsEvent struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+	Rule    *icsRule // nil for one-off events
+}
+
+// icsRule is the subset of RRULE manta understands: daily and weekly
+// repeats with an interval, an end and weekdays.
+type icsRule struct {
+	Freq     string // DAILY or WEEKLY
+	Interval int
+	Until    time.Time
+	Count    int
+	ByDay    []time.Weekday
+}
+
+// parseICS reads the events of an iCalendar file. Properties manta doesn't
+// use are skipped, as are events it can't make sense of.
+func parseICS(r io.Reader) ([]icsEvent, error) {
+	var (
+		events []icsEvent
+		ev     *icsEvent
+	)
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, params, _ := strings.Cut(name, ";")
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			ev = &icsEvent{}
+		case name == "END" && value == "VEVENT":
+			if ev != nil && !ev.Start.IsZero() {
+				if ev.End.IsZero() {
+					ev.End = ev.Start
+				}
+				events = append(events, *ev)
+			}
+			ev = nil
+		case ev == nil:
+		case name == "UID":
+			ev.UID = value
+		case name == "SUMMARY":
+			ev.Summary = unescapeICS(value)
+		case name == "DTSTART":
+			ev.Start, ev.AllDay, _ = parseICSTime(params, value)
+		case name == "DTEND":
+			ev.End, _, _ = parseICSTime(params, value)
+		case name == "RRULE":
+			ev.Rule = parseRRule(value)
+		}
+	}
+	return events, nil
+}
+
+// unfoldICS joins continuation lines, which start with a space or tab.
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICSTime parses a DATE-TIME in UTC, in a TZID or floating, or a DATE,
+// which marks an all-day event.
+func parseICSTime(params, value string) (time.Time, bool, error) {
+	loc := time.Local
+	for _, p := range strings.Split(params, ";") {
+		if tzid, ok := strings.CutPrefix(p, "TZID="); ok {
+			if l, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+				loc = l
+			}
+		}
+	}
+	if len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if v, ok := strings.CutSuffix(value, "Z"); ok {
+		t, err := time.Parse("20060102T150405", v)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule parses a repeat rule, or returns nil when manta can't follow
+// it; such events are checked on their first occurrence only.
+func parseRRule(value string) *icsRule {
+	rule := &icsRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "FREQ":
+			rule.Freq = v
+		case "INTERVAL":
+			rule.Interval, _ = strconv.Atoi(v)
+		case "COUNT":
+			rule.Count, _ = strconv.Atoi(v)
+		case "UNTIL":
+			rule.Until, _, _ = parseICSTime("", v)
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				day, ok := icsWeekdays[d]
+				if !ok {
+					// Ordinal days like 1MO belong to monthly rules.
+					return nil
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		default:
+			if k != "WKST" {
+				return nil
+			}
+		}
+	}
+	if (rule.Freq != "DAILY" && rule.Freq != "WEEKLY") || rule.Interval < 1 {
+		return nil
+	}
+	return rule
+}
+
+// overlapping returns the occurrences of ev that overlap [from, to), as
+// events of their own.
+func (ev icsEvent) overlapping(from, to time.Time) []icsEvent {
+	length := ev.End.Sub(ev.Start)
+	var found []icsEvent
+	check := func(start time.Time) {
+		if start.Before(to) && start.Add(length).After(from) {
+			occ := ev
+			occ.Start, occ.End, occ.Rule = start, start.Add(length), nil
+			found = append(found, occ)
+		}
+	}
+	if ev.Rule == nil {
+		check(ev.Start)
+		return found
+	}
+
+	r := ev.Rule
+	if r.Freq == "WEEKLY" && len(r.ByDay) == 0 {
+		r.ByDay = []time.Weekday{ev.Start.Weekday()}
+	}
+	n := 0
+	for day := ev.Start; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if !r.Until.IsZero() && day.After(r.Until) {
+			break
+		}
+		periods := daysBetween(ev.Start, day)
+		if r.Freq == "WEEKLY" {
+			periods = daysBetween(weekStart(ev.Start), day) / 7
+		}
+		if periods%r.Interval != 0 || (len(r.ByDay) > 0 && !slices.Contains(r.ByDay, day.Weekday())) {
+			continue
+		}
+		n++
+		if r.Count > 0 && n > r.Count {
+			break
+		}
+		check(day)
+	}
+	return found
+}
+
+// weekStart returns the Monday of the week of t.
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// writeICS writes events as an iCalendar file of busy blocks.
+func writeICS(w io.Writer, events []icsEvent, now time.Time) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprint(b, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//manta//focus//EN\r\n")
+	for _, ev := range events {
+		fmt.Fprint(b, "BEGIN:VEVENT\r\n")
+		fmt.Fprintf(b, "UID:%s\r\n", ev.UID)
+		fmt.Fprintf(b, "DTSTAMP:%s\r\n", now.UTC().Format("20060102T150405Z"))
+		fmt.Fprintf(b, "DTSTART:%s\r\n", ev.Start.UTC().Format("20060102T150405Z"))
+		fmt.Fprintf(b, "DTEND:%s\r\n", ev.End.UTC().Format("20060102T150405Z"))
+		fmt.Fprintf(b, "SUMMARY:%s\r\n", escapeICS(ev.Summary))
+		fmt.Fprint(b, "TRANSP:OPAQUE\r\nEND:VEVENT\r\n")
+	}
+	fmt.Fprint(b, "END:VCALENDAR\r\n")
+	return b.Flush()
+}
+
+var (
+	icsEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	icsUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeICS(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+func unescapeICS(s string) string {
+	return icsUnescaper.Replace(s)
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// IdleConfig pauses work sessions when the user walks away.
+type IdleConfig struct {
+	// PauseAfter is how long the user must be idle before the session is
+	// paused. Zero turns idle detection off.
+	PauseAfter Duration `json:"pause_after"`
+
+	// LowConfidenceAfter marks work sessions with a stretch this long
+	// without keyboard or mouse input as low confidence in the history.
+	// Zero turns it off.
+	LowConfidenceAfter Duration `json:"low_confidence_after"`
+
+	// Breaks checks during breaks whether the screen is locked or there
+	// is no input, and records how much of each break was spent away
+	// from the screen.
+	Breaks bool `json:"breaks"`
+
+	// NagAfter sends a notification once when there is still input this
+	// long into a break. Zero never nags.
+	NagAfter Duration `json:"nag_after"`
+}
+
+// enabled reports whether the idle time needs to be polled.
+func (c IdleConfig) enabled() bool {
+	return c.PauseAfter > 0 || c.LowConfidenceAfter > 0 || c.Breaks || c.NagAfter > 0
+}
+
+// idleCheckInterval is how often the system idle time is polled.
+const idleCheckInterval = 15 * time.Second
+
+// idleMsg reports the system idle time.
+type idleMsg struct {
+	idle   time.Duration
+	locked bool // the screen is locked, when breaks are checked
+	err    error
+}
+
+// idleCheckCmd polls the idle time after idleCheckInterval, and whether
+// the screen is locked when breaks are checked. A screen lock that can't
+// be told counts as unlocked; the idle time still shows time away.
+func (m model) idleCheckCmd() tea.Cmd {
+	locks := m.cfg.Idle.Breaks
+	return after(idleCheckInterval, func(time.Time) tea.Msg {
+		idle, err := idleTime()
+		msg := idleMsg{idle: idle, err: err}
+		if locks && err == nil {
+			msg.locked, _ = screenLocked()
+		}
+		return msg
+	})
+}
+
+var hidIdleTime = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+// idleTime returns how long there has been no keyboard or mouse input.
+func idleTime() (time.Duration, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("ioreg", "-c", "IOHIDSystem").Output()
+		if err != nil {
+			return 0, err
+		}
+		m := hidIdleTime.FindSubmatch(out)
+		if m == nil {
+			return 0, errors.New("HIDIdleTime not found in ioreg output")
+		}
+		ns, err := strconv.ParseInt(string(m[1]), 10, 64)
+		return time.Duration(ns), err
+
+	case "linux":
+		if strings.Contains(os.Getenv("XDG_CURRENT_DESKTOP"), "GNOME") {
+			out, err := exec.Command("gdbus", "call", "--session",
+				"--dest", "org.gnome.Mutter.IdleMonitor",
+				"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
+				"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime").Output()
+			if err == nil {
+				// The reply looks like "(uint64 12345,)".
+				return parseMillis(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(string(out)), "(uint64 "), ",)"))
+			}
+		}
+		if os.Getenv("DISPLAY") != "" {
+			out, err := exec.Command("xprintidle").Output()
+			if err != nil {
+				return 0, err
+			}
+			return parseMillis(strings.TrimSpace(string(out)))
+		}
+	}
+	return 0, errors.New("idle time is not available on this system")
+}
+
+func parseMillis(s string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	return time.Duration(ms) * time.Millisecond, err
+}
+
+// updateIdle pauses a running work session once the user has been idle
+// for too long, backdating the pause to when they left so the time away
+// doesn't count as focus. When they come back it tells them the session
+// is waiting to be resumed. During breaks it counts the time away instead.
+func (m model) updateIdle(msg idleMsg) (model, tea.Cmd) {
+	if msg.err != nil {
+		m.status = tr("status.idle_disabled", msg.err)
+		return m, nil
+	}
+
+	limit := time.Duration(m.cfg.Idle.PauseAfter)
+	m.now = m.wallNow()
+	m, nag := m.updateBreakAway(msg)
+	next := tea.Batch(m.idleCheckCmd(), nag)
+	working := m.timer.Running() && !m.timer.Paused() && !m.timer.Overtime() && m.preset.Phase == WORKTIME
+	if working && (limit == 0 || msg.idle < limit) {
+		// Time away that gets paused doesn't count against the session.
+		m.longestIdle = max(m.longestIdle, msg.idle)
+	}
+
+	switch {
+	case working && limit > 0 && msg.idle >= limit:
+		m.timer.Pause(m.now.Add(-msg.idle))
+		m.idlePaused = true
+		m.writeState()
+		return m, tea.Batch(next, m.emit(EventPause))
+
+	case m.idlePaused && msg.idle < limit:
+		m.idlePaused = false
+		if !m.timer.Paused() {
+			return m, next
+		}
+		m.status = tr("status.away",
+			m.timer.PausedAt().Format("15:04"), m.keys.Pause.Help().Key)
+		notice := m.pending.track(notifyCmd(m.notifier, tr("notify.idle"), tr("notify.idle_body", m.preset.Name)))
+		return m, tea.Batch(next, notice)
+	}
+	return m, next
+}
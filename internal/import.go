@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SourceImport marks sessions imported from other tools.
+const SourceImport = "import"
+
+// timeEntry is a block of focus time logged outside manta.
+type timeEntry struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Label string `json:"label"`
+}
+
+// entryLayouts are the time formats accepted in imported entries. Times
+// without a zone are local.
+var entryLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// ImportEntries adds time entries read from r in the given format to the
+// history as finished work sessions, with the label as their task. Entries
+// already in the history, with the same start and end, are skipped so a
+// file can be imported again. It returns how many entries were added and
+// how many were skipped.
+func ImportEntries(r io.Reader, format string) (added, skipped int, err error) {
+	var entries []timeEntry
+	switch format {
+	case FormatCSV:
+		entries, err = readEntriesCSV(r)
+	case FormatJSON:
+		err = json.NewDecoder(r).Decode(&entries)
+	default:
+		err = fmt.Errorf("unknown format %q, want %s or %s", format, FormatCSV, FormatJSON)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sessions := make([]Session, 0, len(entries))
+	for i, e := range entries {
+		s, err := e.session()
+		if err != nil {
+			return 0, 0, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	history, err := loadSessions()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read history: %w", err)
+	}
+	type span struct{ start, end int64 }
+	seen := make(map[span]bool, len(history))
+	for _, s := range history {
+		seen[span{s.Start.Unix(), s.End.Unix()}] = true
+	}
+
+	for _, s := range sessions {
+		key := span{s.Start.Unix(), s.End.Unix()}
+		if seen[key] {
+			skipped++
+			continue
+		}
+		seen[key] = true
+		if err := appendSession(s); err != nil {
+			return added, skipped, err
+		}
+		added++
+	}
+	return added, skipped, nil
+}
+
+// session turns the entry into a finished work session.
+func (e timeEntry) session() (Session, error) {
+	start, err := parseEntryTime(e.Start)
+	if err != nil {
+		return Session{}, fmt.Errorf("start: %w", err)
+	}
+	end, err := parseEntryTime(e.End)
+	if err != nil {
+		return Session{}, fmt.Errorf("end: %w", err)
+	}
+	if !end.After(start) {
+		return Session{}, errors.New("ends before it starts")
+	}
+	return Session{
+		Phase:   WORKTIME,
+		Task:    strings.TrimSpace(e.Label),
+		Start:   start,
+		End:     end,
+		Planned: int(end.Sub(start) / time.Second),
+		Source:  SourceImport,
+	}, nil
+}
+
+func parseEntryTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range entryLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, want e.g. 2024-03-04 14:00 or RFC 3339", s)
+}
+
+// readEntriesCSV reads entries from a CSV file with a header naming the
+// start, end and (optional) label columns.
+func readEntriesCSV(r io.Reader) ([]timeEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	cols := map[string]int{"start": -1, "end": -1, "label": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cols[name]; ok {
+			cols[name] = i
+		}
+	}
+	if cols["start"] < 0 || cols["end"] < 0 {
+		return nil, errors.New("the header needs start and end columns")
+	}
+
+	field := func(record []string, name string) string {
+		if i := cols[name]; i >= 0 && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var entries []timeEntry
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, timeEntry{
+			Start: field(record, "start"),
+			End:   field(record, "end"),
+			Label: field(record, "label"),
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prompt identifies what the text input is collecting.
+type prompt int
+
+const (
+	promptNone prompt = iota
+	promptTask
+	promptMacroName
+	promptNote
+	promptSchedule
+	promptTimer
+	promptLock
+	promptUnlock
+	promptInterrupt
+	promptProfile
+)
+
+// openPrompt focuses the text input to collect a value for p.
+func (m *model) openPrompt(p prompt, label, value string) tea.Cmd {
+	m.prompt = p
+	m.input.Prompt = label
+	m.input.EchoMode = textinput.EchoNormal
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// updateInput routes keys to the open prompt. Enter submits the value and
+// esc cancels.
+func (m model) updateInput(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.prompt = promptNone
+		m.input.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		p := m.prompt
+		m.prompt = promptNone
+		m.input.Blur()
+		return m.submit(p, value)
+	}
+
+	var cmd tea.Cmd
+	*m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// submit applies a value entered at a prompt.
+func (m model) submit(p prompt, value string) (model, tea.Cmd) {
+	switch p {
+	case promptTask:
+		task, estimate := parseTask(value)
+		m.task = task
+		if m.timer.Running() {
+			m.tags = m.rules.tags(m.dir, m.task)
+			m.writeState()
+		}
+		return m, velocityCmd(m.cfg.Estimates, m.task, estimate, m.now)
+
+	case promptLock:
+		if value == "" {
+			m.status = tr("status.lock_empty")
+			return m, nil
+		}
+		m.lock(value)
+
+	case promptUnlock:
+		m.unlock(value)
+
+	case promptInterrupt:
+		m.interrupt(parseInterruption(value, m.now))
+
+	case promptProfile:
+		return m.pickProfile(value)
+
+	case promptNote:
+		if m.ended != nil {
+			m.ended.Note = value
+		}
+
+	case promptSchedule:
+		if value == "" {
+			return m, nil
+		}
+		at, err := ParseClock(value, m.now)
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.schedule(m.presets[m.cursor], at)
+
+	case promptTimer:
+		if value == "" {
+			return m, nil
+		}
+		name, d, err := parseTimer(value)
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.addTimer(name, d)
+
+	case promptMacroName:
+		if value == "" {
+			m.status = tr("status.macro_discarded")
+			return m, nil
+		}
+		mac := Macro{Keys: m.recorded}
+		if err := saveMacro(value, mac); err != nil {
+			m.fail(newError(CodeMacro, "save the macro", err))
+			return m, nil
+		}
+		if m.cfg.Macros == nil {
+			m.cfg.Macros = map[string]Macro{}
+		}
+		m.cfg.Macros[value] = mac
+		m.status = tr("status.macro_saved", value)
+	}
+	return m, nil
+}
+
+// inputView renders the open prompt, if any.
+func (m model) inputView(pad string) string {
+	if m.prompt == promptNone {
+		return ""
+	}
+	return pad + m.input.View() + "\n\n"
+}
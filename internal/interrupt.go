@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Kinds of interruption, following the Pomodoro Technique.
+const (
+	InterruptInternal = "internal" // a thought or urge of your own
+	InterruptExternal = "external" // someone or something else
+)
+
+// Interruption is a break in focus logged during a work session.
+type Interruption struct {
+	At   time.Time `json:"at"`
+	Kind string    `json:"kind,omitempty"` // InterruptInternal, InterruptExternal or unknown
+	Note string    `json:"note,omitempty"`
+}
+
+// parseInterruption reads what was typed at the interruption prompt: an
+// optional kind, "i" or "e" for short, followed by an optional note.
+func parseInterruption(s string, at time.Time) Interruption {
+	in := Interruption{At: at, Note: s}
+	word, rest, _ := strings.Cut(s, " ")
+	switch strings.ToLower(word) {
+	case "i", InterruptInternal:
+		in.Kind = InterruptInternal
+	case "e", InterruptExternal:
+		in.Kind = InterruptExternal
+	default:
+		return in
+	}
+	in.Note = strings.TrimSpace(rest)
+	return in
+}
+
+// openInterruption asks about an interruption to log.
+func (m *model) openInterruption() tea.Cmd {
+	return m.openPrompt(promptInterrupt, tr("prompt.interrupt"), "")
+}
+
+// interrupt logs an interruption of the running session.
+func (m *model) interrupt(in Interruption) {
+	m.interruptions = append(m.interruptions, in)
+	m.status = tr("status.interruption", len(m.interruptions))
+	m.writeState()
+}
+
+// countInterruptions returns how many of interruptions are internal and
+// external.
+func countInterruptions(interruptions []Interruption) (internal, external int) {
+	for _, in := range interruptions {
+		switch in.Kind {
+		case InterruptInternal:
+			internal++
+		case InterruptExternal:
+			external++
+		}
+	}
+	return internal, external
+}
+
+// interruptionsText describes n interruptions of which internal and
+// external were tagged, like "3 interruptions (1 internal, 2 external)".
+func interruptionsText(n, internal, external int) string {
+	id := "interrupt.count"
+	if n == 1 {
+		id = "interrupt.count_one"
+	}
+	s := tr(id, n)
+	var kinds []string
+	if internal > 0 {
+		kinds = append(kinds, tr("interrupt.internal", internal))
+	}
+	if external > 0 {
+		kinds = append(kinds, tr("interrupt.external", external))
+	}
+	if len(kinds) > 0 {
+		s += " (" + strings.Join(kinds, ", ") + ")"
+	}
+	return s
+}
+
+// interruptionsView counts the interruptions of the running session.
+func (m model) interruptionsView(pad string) string {
+	if len(m.interruptions) == 0 {
+		return ""
+	}
+	internal, external := countInterruptions(m.interruptions)
+	return pad + m.theme.Help.Render("⚡ "+interruptionsText(len(m.interruptions), internal, external)) + "\n\n"
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// BillingConfig sets hourly rates for tagged work.
+type BillingConfig struct {
+	Currency string `json:"currency"`
+
+	// Rates maps a tag to its hourly rate.
+	Rates map[string]float64 `json:"rates"`
+}
+
+// FormatMarkdown renders an invoice as a markdown table. PrintInvoice
+// also accepts FormatCSV.
+const FormatMarkdown = "markdown"
+
+// invoiceLine is the billable time of one tag.
+type invoiceLine struct {
+	Tag    string
+	Worked time.Duration
+	Rate   float64
+}
+
+func (l invoiceLine) hours() float64 {
+	return l.Worked.Hours()
+}
+
+func (l invoiceLine) amount() float64 {
+	return l.hours() * l.Rate
+}
+
+// PrintInvoice sums the work done within [from, to) on every tag that has a
+// rate and writes a billable-hours summary. A session tagged with several
+// rated tags is billed to the first one.
+func PrintInvoice(w io.Writer, format string, cfg BillingConfig, from, to time.Time) error {
+	if len(cfg.Rates) == 0 {
+		return fmt.Errorf("no billing rates configured")
+	}
+
+	sessions, err := querySessions(Query{From: from, To: to, Phase: WORKTIME})
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+
+	worked := map[string]time.Duration{}
+	for _, s := range sessions {
+		for _, tag := range s.Tags {
+			if _, ok := cfg.Rates[tag]; ok {
+				worked[tag] += s.focus()
+				break
+			}
+		}
+	}
+
+	var lines []invoiceLine
+	for tag, d := range worked {
+		lines = append(lines, invoiceLine{Tag: tag, Worked: d, Rate: cfg.Rates[tag]})
+	}
+	slices.SortFunc(lines, func(a, b invoiceLine) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	switch format {
+	case FormatCSV:
+		return invoiceCSV(w, lines)
+	case FormatMarkdown:
+		return invoiceMarkdown(w, lines, cfg.Currency)
+	default:
+		return fmt.Errorf("unknown format %q, want %s or %s", format, FormatMarkdown, FormatCSV)
+	}
+}
+
+// invoiceCSV writes one row per tag.
+func invoiceCSV(w io.Writer, lines []invoiceLine) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"tag", "hours", "rate", "amount"})
+	for _, l := range lines {
+		_ = cw.Write([]string{
+			l.Tag,
+			strconv.FormatFloat(l.hours(), 'f', 2, 64),
+			strconv.FormatFloat(l.Rate, 'f', 2, 64),
+			strconv.FormatFloat(l.amount(), 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// invoiceMarkdown writes a draft invoice table with a total.
+func invoiceMarkdown(w io.Writer, lines []invoiceLine, currency string) error {
+	var hours, total float64
+	fmt.Fprintln(w, "| Project | Hours | Rate | Amount |")
+	fmt.Fprintln(w, "|---|---:|---:|---:|")
+	for _, l := range lines {
+		hours += l.hours()
+		total += l.amount()
+		fmt.Fprintf(w, "| %s | %.2f | %s | %s |\n", l.Tag, l.hours(), money(l.Rate, currency), money(l.amount(), currency))
+	}
+	_, err := fmt.Fprintf(w, "| **Total** | **%.2f** | | **%s** |\n", hours, money(total, currency))
+	return err
+}
+
+// money formats an amount with the currency, if set.
+func money(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds the bindings for every action in the TUI.
+type keyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	Start     key.Binding
+	Schedule  key.Binding
+	Timer     key.Binding
+	Focus     key.Binding
+	Pause     key.Binding
+	Skip      key.Binding
+	Restart   key.Binding
+	Reset     key.Binding
+	Big       key.Binding
+	Mute      key.Binding
+	Quiet     key.Binding
+	Task      key.Binding
+	Notes     key.Binding
+	Log       key.Binding
+	Record    key.Binding
+	Lock      key.Binding
+	Theme     key.Binding
+	Interrupt key.Binding
+	Stopwatch key.Binding
+	Lap       key.Binding
+	Profile   key.Binding
+	Snooze    key.Binding
+	Yes       key.Binding
+	No        key.Binding
+	Complete  key.Binding
+	Help      key.Binding
+	Quit      key.Binding
+}
+
+// defaultKeys returns the built-in bindings.
+func defaultKeys() keyMap {
+	return keyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", tr("key.up")),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", tr("key.down")),
+		),
+		Start: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", tr("key.start")),
+		),
+		Schedule: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", tr("key.schedule")),
+		),
+		Timer: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", tr("key.timer")),
+		),
+		Focus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", tr("key.focus")),
+		),
+		Pause: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", tr("key.pause")),
+		),
+		Skip: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", tr("key.skip")),
+		),
+		Restart: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", tr("key.restart")),
+		),
+		Reset: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", tr("key.reset")),
+		),
+		Big: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", tr("key.big")),
+		),
+		Mute: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", tr("key.mute")),
+		),
+		Quiet: key.NewBinding(
+			key.WithKeys("h"),
+			key.WithHelp("h", tr("key.quiet")),
+		),
+		Task: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", tr("key.task")),
+		),
+		Notes: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", tr("key.notes")),
+		),
+		Log: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", tr("key.log")),
+		),
+		Record: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", tr("key.record")),
+		),
+		Lock: key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp("ctrl+l", tr("key.lock")),
+		),
+		Interrupt: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", tr("key.interrupt")),
+		),
+		Stopwatch: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", tr("key.stopwatch")),
+		),
+		Lap: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", tr("key.lap")),
+		),
+		Profile: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", tr("key.profile")),
+		),
+		Snooze: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", tr("key.snooze")),
+		),
+		Theme: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", tr("key.theme")),
+		),
+		Yes: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", tr("key.yes")),
+		),
+		No: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", tr("key.no")),
+		),
+		Complete: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", tr("key.complete")),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", tr("key.help")),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", tr("key.quit")),
+		),
+	}
+}
+
+// newKeyMap applies the user's overrides from the config on top of the
+// defaults. Keys use Bubble Tea names such as "ctrl+c", "enter" or "space".
+func newKeyMap(overrides map[string][]string) (keyMap, error) {
+	km := defaultKeys()
+	actions := km.actions()
+
+	for action, keys := range overrides {
+		b, ok := actions[action]
+		if !ok {
+			return km, fmt.Errorf("unknown key action %q", action)
+		}
+		if len(keys) == 0 {
+			return km, fmt.Errorf("no keys for action %q", action)
+		}
+
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = k
+			if k == "space" {
+				names[i] = " "
+			}
+		}
+		b.SetKeys(names...)
+		b.SetHelp(keys[0], b.Help().Desc)
+	}
+	return km, nil
+}
+
+// actions maps config action names to the bindings they configure.
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":        &k.Up,
+		"down":      &k.Down,
+		"start":     &k.Start,
+		"schedule":  &k.Schedule,
+		"timer":     &k.Timer,
+		"focus":     &k.Focus,
+		"pause":     &k.Pause,
+		"skip":      &k.Skip,
+		"restart":   &k.Restart,
+		"reset":     &k.Reset,
+		"big":       &k.Big,
+		"mute":      &k.Mute,
+		"quiet":     &k.Quiet,
+		"task":      &k.Task,
+		"notes":     &k.Notes,
+		"log":       &k.Log,
+		"record":    &k.Record,
+		"lock":      &k.Lock,
+		"theme":     &k.Theme,
+		"interrupt": &k.Interrupt,
+		"stopwatch": &k.Stopwatch,
+		"lap":       &k.Lap,
+		"profile":   &k.Profile,
+		"snooze":    &k.Snooze,
+		"yes":       &k.Yes,
+		"no":        &k.No,
+		"complete":  &k.Complete,
+		"help":      &k.Help,
+		"quit":      &k.Quit,
+	}
+}
+
+// setRunning enables the bindings that make sense while a session runs and
+// disables the chooser ones, or the other way round.
+func (k *keyMap) setRunning(running bool) {
+	k.Up.SetEnabled(!running)
+	k.Down.SetEnabled(!running)
+	k.Start.SetEnabled(!running)
+	k.Schedule.SetEnabled(!running)
+	k.Stopwatch.SetEnabled(!running)
+	k.Profile.SetEnabled(!running)
+	k.Pause.SetEnabled(running)
+	k.Skip.SetEnabled(running)
+	k.Restart.SetEnabled(running)
+	k.Reset.SetEnabled(running)
+	k.Big.SetEnabled(running)
+}
+
+// ShortHelp implements help.KeyMap.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Start, k.Snooze, k.Pause, k.Reset, k.Help, k.Quit}
+}
+
+// FullHelp implements help.KeyMap.
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Start, k.Snooze, k.Schedule, k.Stopwatch, k.Profile},
+		{k.Pause, k.Skip, k.Restart, k.Reset, k.Big, k.Interrupt, k.Lap},
+		{k.Timer, k.Focus, k.Mute, k.Quiet, k.Task, k.Notes, k.Log, k.Record, k.Lock, k.Theme, k.Help, k.Quit},
+	}
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openLock asks for the passphrase that will unlock the screen.
+func (m *model) openLock() tea.Cmd {
+	cmd := m.openPrompt(promptLock, tr("prompt.lock"), "")
+	m.input.EchoMode = textinput.EchoPassword
+	return cmd
+}
+
+// lock hides the task and notes behind passphrase until it is entered
+// again. The countdown stays visible and sessions keep running.
+func (m *model) lock(passphrase string) {
+	m.locked = true
+	m.lockHash = sha256.Sum256([]byte(passphrase))
+	m.showNotes = false
+	m.status = tr("status.locked")
+}
+
+// unlock lifts the lock when passphrase matches the one it was set with.
+func (m *model) unlock(passphrase string) {
+	sum := sha256.Sum256([]byte(passphrase))
+	if subtle.ConstantTimeCompare(sum[:], m.lockHash[:]) != 1 {
+		m.status = tr("status.wrong_passphrase")
+		return
+	}
+	m.locked = false
+	m.lockHash = [sha256.Size]byte{}
+	m.status = ""
+}
+
+// lockedKeys handles keys while the screen is locked: any key asks for the
+// passphrase and nothing else can be done until it is entered.
+func (m model) lockedKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	if m.prompt == promptUnlock {
+		return m.updateInput(msg)
+	}
+	cmd := m.openPrompt(promptUnlock, tr("prompt.unlock"), "")
+	m.input.EchoMode = textinput.EchoPassword
+	return m, cmd
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Macro is a recorded sequence of key presses, replayed as if typed.
+type Macro struct {
+	// Key optionally replays the macro with a single key press.
+	Key  string   `json:"key,omitempty"`
+	Keys []string `json:"keys"`
+}
+
+// replayMsg asks the model to replay a macro.
+type replayMsg Macro
+
+// keyTypes maps key names such as "enter" or "ctrl+c" to their type.
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-128); t < 128; t++ {
+		if name := t.String(); name != "" {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+// parseKey turns a key name as reported by tea.KeyMsg.String back into a
+// key press.
+func parseKey(s string) (tea.KeyMsg, error) {
+	alt := false
+	if len(s) > len("alt+") && s[:len("alt+")] == "alt+" {
+		alt, s = true, s[len("alt+"):]
+	}
+	if t, ok := keyTypes[s]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}, nil
+	}
+	if utf8.RuneCountInString(s) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s), Alt: alt}, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("unknown key %q", s)
+}
+
+// validateMacros checks that every macro key can be replayed.
+func validateMacros(macros map[string]Macro) error {
+	for name, mac := range macros {
+		for _, k := range mac.Keys {
+			if _, err := parseKey(k); err != nil {
+				return fmt.Errorf("macro %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WithMacro makes the model replay the named macro as soon as it starts.
+func (m model) WithMacro(name string) (model, error) {
+	mac, ok := m.cfg.Macros[name]
+	if !ok {
+		return m, fmt.Errorf("no macro named %q", name)
+	}
+	m.startMacro = &mac
+	return m, nil
+}
+
+// macroFor returns the macro bound to the pressed key, if any.
+func (m model) macroFor(msg tea.KeyMsg) (Macro, bool) {
+	for _, mac := range m.cfg.Macros {
+		if mac.Key != "" && mac.Key == msg.String() {
+			return mac, true
+		}
+	}
+	return Macro{}, false
+}
+
+// replay feeds the macro keys through Update one by one.
+func (m model) replay(mac Macro) (model, tea.Cmd) {
+	m.replaying = true
+	var cmds []tea.Cmd
+	for _, k := range mac.Keys {
+		msg, err := parseKey(k)
+		if err != nil {
+			m.status = err.Error()
+			break
+		}
+		next, cmd := m.Update(msg)
+		m = next.(model)
+		cmds = append(cmds, cmd)
+	}
+	m.replaying = false
+	return m, tea.Batch(cmds...)
+}
+
+// toggleRecording starts recording keys, or stops and asks for a name.
+func (m model) toggleRecording() (model, tea.Cmd) {
+	if !m.recording {
+		m.recording = true
+		m.recorded = nil
+		m.status = tr("status.recording", m.keys.Record.Help().Key)
+		return m, nil
+	}
+
+	m.recording = false
+	if len(m.recorded) == 0 {
+		m.status = tr("status.macro_discarded")
+		return m, nil
+	}
+	m.status = ""
+	return m, m.openPrompt(promptMacroName, tr("prompt.macro"), "")
+}
+
+// saveMacro stores mac under name in the config file.
+func saveMacro(name string, mac Macro) error {
+	return updateConfig(func(raw map[string]json.RawMessage) error {
+		macros := map[string]Macro{}
+		if data, ok := raw["macros"]; ok {
+			if err := json.Unmarshal(data, &macros); err != nil {
+				return err
+			}
+		}
+		macros[name] = mac
+
+		data, err := json.Marshal(macros)
+		if err != nil {
+			return err
+		}
+		raw["macros"] = data
+		return nil
+	})
+}
@@ -5,177 +5,1154 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/ihorbryk/manta/pkg/timer"
 )
 
+// Session phases.
 const (
-	// work = 5 // use for test
-	work     = 25 * 60
-	rest     = 5 * 60
 	WORKTIME = "work"
 	RESTTIME = "rest"
 )
 
-type Mapping map[string]int
-
-var mapping = Mapping{
-	WORKTIME: work,
-	RESTTIME: rest,
-}
-
-var choices = []string{WORKTIME, RESTTIME}
-
 const (
 	padding  = 2
 	maxWidth = 80
 )
 
-var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
-
+// model drives the session timer from Bubble Tea messages and renders it.
+// Bubble Tea copies the model for every message, so large parts that rarely
+// change, like the theme, help and text input, are kept behind pointers.
 type model struct {
-	progress progress.Model
-	timeLeft int
-	timeType string
-	cursor   int
-	choice   string
-	pause    bool
-	endTime  time.Time
+	progress      progress.Model
+	urgency       int // urgency step the progress bar is colored for, 0 (green) when it is built
+	presets       []Preset
+	preset        Preset // the running or last run preset
+	cursor        int
+	choice        string
+	timer         timer.Timer   // the running session, stopped when idle
+	idlePaused    bool          // paused automatically because the user walked away
+	longestIdle   time.Duration // longest stretch without input in the running session
+	breakAway     time.Duration // time of the running break spent away from the screen
+	nagged        bool          // the running break already nagged about input
+	inCall        bool          // the microphone or camera was in use at the last check
+	callPaused    bool          // paused automatically for a call
+	awaiting      bool          // the session ended and alerts repeat until a key is pressed
+	alertedAt     time.Time     // when the last end-of-session alert went out
+	ended         *Session      // finished session waiting for an end menu action
+	menuCursor    int
+	preroll       *Preset     // session waiting for the pre-roll countdown
+	transition    *transition // change of phase being announced
+	prerollEnd    time.Time   // when the countdown ends
+	scheduled     *Preset     // session waiting for its start time
+	scheduledAt   time.Time
+	timers        []namedTimer // timers running alongside the session
+	focus         int          // the timer the pause and stop keys act on: 0 for the session, i for timers[i-1]
+	lastTick      time.Time
+	lag           time.Duration // how late ticks are handled, smoothed
+	slow          bool          // the terminal lags, so the display refreshes less
+	saving        bool          // on battery, so power is saved
+	locked        bool          // task and notes are hidden until the passphrase is entered
+	lockHash      [32]byte      // SHA-256 of the lock passphrase
+	slept         bool          // paused after a sleep, asking whether the sleep counts
+	interrupted   *State        // session left running when manta last quit, waiting for an answer
+	shutDown      bool          // the machine shut down in the middle of the interrupted or resumed session
+	stateAt       time.Time     // when the state file was last written
+	today         tally         // work sessions completed today
+	showNotes     bool
+	showLog       bool      // the log pane with the output of phase commands is open
+	commandLog    []string  // output of phase commands, oldest first
+	notes         []Session // sessions with notes, newest first
+	notesOffset   int
+	stats         string // today's stats, shown in the end menu on request
+	now           time.Time
+	clock         func() time.Time // reads the time, time.Now but in tests
+	cfg           Config
+	player        *Player
+	notifier      Notifier
+	keys          keyMap
+	help          *help.Model
+	theme         *Theme
+	appearance    *appearance // light and dark themes, when the theme follows the background
+	events        *dispatcher
+	pending       *tracker
+	big           bool     // show the fullscreen big clock instead of the progress bar
+	flash         bool     // the interface is flashed in place of a sound
+	article       *article // reading suggestion for the current break
+	width         int
+	height        int
+	status        string
+	muted         bool            // mirrors the player, for the help bar
+	quiet         quietState      // sounds held back by quiet hours
+	task          string          // what the user is working on
+	velocity      *velocity       // progress of the task, when it has an estimate
+	tags          []string        // tags of the running session
+	interruptions []Interruption  // logged during the running session
+	laps          []time.Duration // marked on the running stopwatch, as time elapsed
+	ritual        *ritual         // checklist gone through before a work session starts
+	ritualSkipped []string        // steps of the ritual skipped before the running session
+	snoozing      *snoozing       // session that ended, holding off the next one
+	rules         tagRules
+	dir           string // where manta was launched
+	tmux          *tmux
+	dailyNote     *dailyNote
+	tracking      *timeTracker
+	privacy       redactor // applied to what the daily note and time tracker get
+	archive       *journalArchive
+	archiveAt     time.Time // when the journal is archived next
+	summaryAt     time.Time // when the day is summed up next, zero without a set time
+	ambient       *ambient
+	context       Context // where manta was launched
+	input         *textinput.Model
+	prompt        prompt   // what the input is collecting, promptNone when closed
+	recording     bool     // keys are being recorded into a macro
+	recorded      []string // keys recorded so far
+	replaying     bool     // a macro is being replayed
+	startMacro    *Macro   // macro to replay on start
+	flow          *flow    // plan of work sessions being run
+	switchTo      *string  // profile picked, to start over with once quit
 }
 
-func NewModel() model {
-	return model{
-		progress: progress.New(progress.WithDefaultGradient()),
-		timeLeft: 0,
-		timeType: WORKTIME,
+func NewModel(cfg Config, player *Player, notifier Notifier) (model, error) {
+	return newModel(cfg, player, notifier, time.Now)
+}
+
+// newModel returns the model reading the time from clock, so tests can
+// render screens at a set time.
+func newModel(cfg Config, player *Player, notifier Notifier, clock func() time.Time) (model, error) {
+	// The key help is translated, so the language goes first.
+	if err := useLanguage(cfg.Language); err != nil {
+		return model{}, err
+	}
+	if err := useHistory(cfg); err != nil {
+		return model{}, err
+	}
+
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		return model{}, err
+	}
+
+	presets, err := newPresets(cfg.Presets)
+	if err != nil {
+		return model{}, err
+	}
+
+	theme, err := newTheme(cfg.Theme)
+	if err != nil {
+		return model{}, err
+	}
+	var look *appearance
+	if cfg.Theme.auto() {
+		if look, err = newAppearance(cfg.Theme); err != nil {
+			return model{}, err
+		}
+		theme = *look.theme()
+	}
+
+	hooks, err := newHooks(cfg.Hooks)
+	if err != nil {
+		return model{}, err
+	}
+
+	dnd, err := newDND(cfg.DND)
+	if err != nil {
+		return model{}, err
+	}
+
+	strict, err := newStrict(cfg.Strict)
+	if err != nil {
+		return model{}, err
+	}
+
+	tint, err := newTint(cfg.Tint)
+	if err != nil {
+		return model{}, err
+	}
+
+	if err := validateMacros(cfg.Macros); err != nil {
+		return model{}, err
+	}
+
+	if err := validateSleep(cfg.Sleep); err != nil {
+		return model{}, err
+	}
+
+	if err := validateSnooze(cfg.Snooze); err != nil {
+		return model{}, err
+	}
+
+	if err := validateBudgets(cfg.Budgets); err != nil {
+		return model{}, err
+	}
+
+	if err := validateGlobalKeys(cfg.GlobalKeys); err != nil {
+		return model{}, err
+	}
+
+	rules, err := newTagRules(cfg.Tags)
+	if err != nil {
+		return model{}, err
 	}
+
+	tmux, err := newTmux(cfg.Tmux)
+	if err != nil {
+		return model{}, err
+	}
+
+	daily, err := newDailyNote(cfg.DailyNote)
+	if err != nil {
+		return model{}, err
+	}
+
+	tracking, err := newTimeTracker(cfg.Tracking)
+	if err != nil {
+		return model{}, err
+	}
+
+	privacy, err := cfg.Privacy.redactor()
+	if err != nil {
+		return model{}, err
+	}
+
+	archive, err := newJournalArchive(cfg.JournalFile)
+	if err != nil {
+		return model{}, err
+	}
+
+	quietHours, err := parseQuietHours(cfg.Quiet.Hours)
+	if err != nil {
+		return model{}, err
+	}
+
+	if err := cfg.Power.validate(); err != nil {
+		return model{}, err
+	}
+
+	if err := cfg.OnStart.validate(); err != nil {
+		return model{}, fmt.Errorf("on_start: %w", err)
+	}
+
+	ambient, err := newAmbient(cfg.Sounds, player)
+	if err != nil {
+		return model{}, err
+	}
+
+	input := textinput.New()
+	if cfg.ReducedMotion {
+		input.Cursor.SetMode(cursor.CursorStatic)
+	}
+	h := help.New()
+	h.Styles = theme.HelpStyles
+
+	now := wallClock(clock())
+	var summaryAt time.Time
+	if cfg.Summary.At != "" {
+		if summaryAt, err = ParseClock(cfg.Summary.At, now); err != nil {
+			return model{}, fmt.Errorf("summary: %w", err)
+		}
+	}
+
+	var failure *Error
+	today, err := loadTally(now)
+	if err != nil && cfg.Goal > 0 {
+		failure = newError(CodeHistoryRead, "read the history", err)
+	}
+	interrupted, err := loadInterrupted()
+	if err != nil {
+		failure = newError(CodeState, "read the interrupted session", err)
+	}
+
+	m := model{
+		progress:   theme.progressBar(),
+		theme:      &theme,
+		appearance: look,
+		events:     newDispatcher(hooks, dnd, strict, tint, newCalendar(cfg.Calendar), newSlack(cfg.Slack), eventFeed),
+		pending:    &tracker{},
+		presets:    presets,
+		cfg:        cfg,
+		player:     player,
+		notifier:   notifier,
+		keys:       keys,
+		help:       &h,
+		input:      &input,
+		context:    captureContext(cfg.Context),
+		rules:      rules,
+		tmux:       tmux,
+		dailyNote:  daily,
+		tracking:   tracking,
+		privacy:    privacy,
+		archive:    archive,
+		summaryAt:  summaryAt,
+		quiet:      quietState{hours: quietHours},
+		ambient:    ambient,
+		dir:        launchDir(),
+		today:      today,
+		now:        now,
+		clock:      clock,
+	}
+	if failure != nil {
+		m.fail(failure)
+	}
+	if interrupted != nil {
+		m = m.offerResume(*interrupted)
+	}
+	return m, nil
+}
+
+// wallClock strips the monotonic reading from t. The monotonic clock stops
+// while the machine sleeps, but a session should keep counting down.
+func wallClock(t time.Time) time.Time {
+	return t.Round(0)
+}
+
+// wallNow returns the time now on the model's clock.
+func (m model) wallNow() time.Time {
+	return wallClock(m.clock())
 }
 
 func (m model) Init() tea.Cmd {
-	return tickCmd()
+	cmds := []tea.Cmd{m.tickCmd(), soundCheckCmd(m.player), recordSettingsCmd(m.cfg)}
+	if m.cfg.Idle.enabled() {
+		cmds = append(cmds, m.idleCheckCmd())
+	}
+	if m.cfg.Calls.Pause {
+		cmds = append(cmds, callCheckCmd())
+	}
+	if m.appearance != nil {
+		cmds = append(cmds, appearanceCmd())
+	}
+	if m.cfg.Mouse {
+		cmds = append(cmds, mouseCmds()...)
+	}
+	if m.cfg.Quiet.detects() {
+		cmds = append(cmds, quietCheckCmd(m.cfg.Quiet))
+	}
+	if cmd := m.tracking.flushCmd(); cmd != nil {
+		cmds = append(cmds, m.pending.track(cmd))
+	}
+	if m.cfg.Power.Saver != "" {
+		cmds = append(cmds, powerCheckCmd(true))
+	}
+	if m.startMacro != nil {
+		mac := *m.startMacro
+		cmds = append(cmds, func() tea.Msg { return replayMsg(mac) })
+	}
+	if m.flow != nil {
+		cmds = append(cmds, func() tea.Msg { return flowStartMsg{} })
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Ticks and animation frames arrive many times a second. They are
+	// handled apart from the other messages, whose handling moves the
+	// model to the heap, so they don't copy it there each time.
+	switch msg := msg.(type) {
+	case tickMsg:
+		next, cmd := m.updateTick(time.Time(msg))
+		return next, cmd
+
+	// FrameMsg is sent when the progress bar wants to animate itself
+	case progress.FrameMsg:
+		progressModel, cmd := m.progress.Update(msg)
+		m.progress = progressModel.(progress.Model)
+		return m, cmd
+	}
+	return m.update(msg)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		m.now = m.wallNow()
+
+		// Any key acknowledges a finished session.
+		m.awaiting = false
+
+		if m.locked {
+			return m.lockedKeys(msg)
+		}
+
+		if key.Matches(msg, m.keys.Record) && m.prompt == promptNone && !m.replaying {
+			return m.toggleRecording()
+		}
+		if m.recording {
+			m.recorded = append(m.recorded, msg.String())
+		}
+		if m.prompt != promptNone {
+			return m.updateInput(msg)
+		}
+		if mac, ok := m.macroFor(msg); ok && !m.replaying {
+			return m.replay(mac)
+		}
+
+		if m.transition != nil && !key.Matches(msg, m.keys.Quit) {
+			// Any key takes the transition screen away.
+			m.transition = nil
+			return m, nil
+		}
+		if m.showNotes && !key.Matches(msg, m.keys.Quit) {
+			return m.notesKeys(msg)
+		}
+		if m.interrupted != nil && !key.Matches(msg, m.keys.Quit) {
+			return m.resumeKeys(msg)
+		}
+		if m.slept && !key.Matches(msg, m.keys.Quit) {
+			return m.sleepKeys(msg)
+		}
+		if m.ritual != nil && !key.Matches(msg, m.keys.Quit) {
+			return m.ritualKeys(msg)
+		}
+		if m.preroll != nil && !key.Matches(msg, m.keys.Quit) {
+			return m.prerollKeys(msg)
+		}
+		if m.snoozing != nil && !key.Matches(msg, m.keys.Quit) {
+			return m.snoozeKeys(msg)
+		}
+		if m.scheduled != nil && !key.Matches(msg, m.keys.Quit, m.keys.Task) {
+			return m.scheduleKeys(msg)
+		}
+		if m.ended != nil && !key.Matches(msg, m.keys.Quit, m.keys.Help, m.keys.Mute, m.keys.Quiet, m.keys.Task, m.keys.Notes, m.keys.Log, m.keys.Timer, m.keys.Focus, m.keys.Lock, m.keys.Theme) {
+			return m.updateMenu(msg)
+		}
+		if m.focused() != nil && key.Matches(msg, m.keys.Pause, m.keys.Reset) {
+			return m.timerKeys(msg)
+		}
+
+		keys := m.activeKeys()
+
+		switch {
+		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
 
-		case "enter":
-			switch choices[m.cursor] {
-			case WORKTIME:
-				m.timeLeft = work
-				m.timeType = WORKTIME
-				m.endTime = time.Now().Add(time.Duration(m.timeLeft) * time.Second)
-			case RESTTIME:
-				m.timeLeft = rest
-				m.timeType = RESTTIME
-				m.endTime = time.Now().Add(time.Duration(m.timeLeft) * time.Second)
+		case key.Matches(msg, keys.Task):
+			return m, m.openPrompt(promptTask, tr("prompt.task"), m.task)
+
+		case key.Matches(msg, keys.Notes):
+			return m.openNotes()
+
+		case key.Matches(msg, keys.Log):
+			m.showLog = !m.showLog
+
+		case key.Matches(msg, keys.Lock):
+			if m.recording {
+				m.status = tr("status.stop_recording")
+				return m, nil
 			}
+			return m, m.openLock()
+
+		case key.Matches(msg, keys.Mute):
+			m.muted = m.player.ToggleMute()
+			m.syncAmbient()
+			return m, nil
+
+		case key.Matches(msg, keys.Quiet):
+			m.toggleQuiet()
+			return m, nil
+
+		case key.Matches(msg, keys.Interrupt):
+			return m, m.openInterruption()
+
+		case key.Matches(msg, keys.Theme):
+			m.toggleAppearance()
+			return m, nil
+
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+
+		case key.Matches(msg, keys.Big):
+			m.big = !m.big
+
+		case key.Matches(msg, keys.Start):
+			return m, m.start(m.presets[m.cursor])
+
+		case key.Matches(msg, keys.Profile):
+			return m, m.openProfilePicker()
+
+		case key.Matches(msg, keys.Stopwatch):
+			return m, m.start(stopwatchPreset)
+
+		case key.Matches(msg, keys.Lap):
+			m.lap()
 
-		case "down", "j":
+		case key.Matches(msg, keys.Schedule):
+			return m, m.openPrompt(promptSchedule, tr("prompt.schedule", m.presets[m.cursor].Name), "")
+
+		case key.Matches(msg, keys.Timer):
+			return m, m.openPrompt(promptTimer, tr("prompt.timer"), "")
+
+		case key.Matches(msg, keys.Focus):
+			m.focus = (m.focus + 1) % (len(m.timers) + 1)
+
+		case key.Matches(msg, keys.Skip):
+			// Skipping a running session abandons it; skipping overtime
+			// finishes a session that has already ended.
+			s := m.session()
+			s.Abandoned = !m.timer.Overtime()
+			if s.Abandoned && s.Phase == RESTTIME {
+				m.countRest(true)
+			}
+			cmds := []tea.Cmd{m.emit(EventSkip), m.record(s)}
+			if m.flow != nil {
+				return m, tea.Batch(append(cmds, m.advanceFlow())...)
+			}
+			return m, tea.Batch(append(cmds, m.start(nextPreset(m.presets, m.preset)))...)
+
+		case key.Matches(msg, keys.Restart):
+			return m, m.begin(m.preset)
+
+		case key.Matches(msg, keys.Down):
 			m.cursor++
-			if m.cursor >= len(choices) {
+			if m.cursor >= len(m.presets) {
 				m.cursor = 0
 			}
 
-		case " ":
-			m.endTime = time.Now().Add(time.Duration(m.timeLeft) * time.Second)
-			m.pause = !m.pause
+		case key.Matches(msg, keys.Pause):
+			event := EventPause
+			if m.timer.Paused() {
+				m.timer.Resume(m.now)
+				event = EventResume
+			} else {
+				m.timer.Pause(m.now)
+			}
+			m.callPaused = false
+			m.syncAmbient()
+			m.writeState()
+			return m, m.emit(event)
 
-		case "esc":
-			m.timeLeft = 0
-			m.pause = false
+		case key.Matches(msg, keys.Reset):
+			if m.timer.Stopwatch() {
+				return m.stopStopwatch()
+			}
+			// Stopping a running session abandons it; in overtime the
+			// session has already ended and this just stops the clock.
+			s := m.session()
+			var cmd tea.Cmd
+			if !m.timer.Overtime() {
+				s.Abandoned = true
+				cmd = m.emit(EventReset)
+				if s.Phase == RESTTIME {
+					m.countRest(true)
+				}
+			}
+			cmd = tea.Batch(cmd, m.record(s))
+			if m.flow != nil {
+				m.flow = nil
+				m.status = tr("status.plan_stopped")
+			}
+			m.timer.Stop()
+			m.removeState()
+			return m, cmd
 
-		case "up", "k":
+		case key.Matches(msg, keys.Up):
 			m.cursor--
 			if m.cursor < 0 {
-				m.cursor = len(choices) - 1
+				m.cursor = len(m.presets) - 1
 			}
 		}
 		return m, nil
 
 	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 		m.progress.Width = msg.Width - padding*2 - 4
 		if m.progress.Width > maxWidth {
 			m.progress.Width = maxWidth
 		}
+		m.help.Width = msg.Width - padding*2
 		return m, nil
 
-	case tickMsg:
-		if m.pause {
-			return m, tickCmd()
-		}
+	case *Error:
+		m.fail(msg)
+		return m, nil
 
-		if m.progress.Percent() == 1.0 && m.timeLeft == 0 {
-			PlayNotification()
-			_ = notify(fmt.Sprintf("Time to %s is left", m.timeType), "")
-		}
+	case idleMsg:
+		return m.updateIdle(msg)
 
-		m.timeLeft -= 1
+	case fallbackMsg:
+		return m.updateFallback(msg)
 
-		percent := 0.0
+	case flashEndMsg:
+		m.flash = false
+		return m, nil
 
-		if m.timeType == WORKTIME {
-			percent = 1.0 - float64(m.timeLeft)/float64(work)
-		}
+	case appearanceMsg:
+		return m.updateAppearance(msg)
 
-		if m.timeType == RESTTIME {
-			percent = 1.0 - float64(m.timeLeft)/float64(rest)
-		}
+	case velocityMsg:
+		return m.updateVelocity(msg)
 
-		cmd := m.progress.SetPercent(float64(percent))
+	case budgetMsg:
+		return m.updateBudget(msg)
 
-		return m, tea.Batch(tickCmd(), cmd)
+	case summaryMsg:
+		return m.updateSummary(msg)
 
-	// FrameMsg is sent when the progress bar wants to animate itself
-	case progress.FrameMsg:
-		progressModel, cmd := m.progress.Update(msg)
-		m.progress = progressModel.(progress.Model)
-		return m, cmd
+	case callMsg:
+		return m.updateCall(msg)
+
+	case quietMsg:
+		return m.updateQuiet(msg)
+
+	case powerMsg:
+		return m.updatePower(msg)
+
+	case phaseOutputMsg:
+		return m.updatePhaseOutput(msg)
+
+	case flowStartMsg:
+		return m, m.startFlow()
+
+	case replayMsg:
+		return m.replay(Macro(msg))
+
+	case controlMsg:
+		return m.control(msg)
+
+	case notifyActionMsg:
+		return m.notifyAction(msg)
+
+	case soundCheckMsg:
+		if msg.err != nil {
+			m.fail(newError(CodeSound, "open the audio output", msg.err))
+			m.status += tr("status.no_sound")
+		}
+		return m, nil
+
+	case articleMsg:
+		if m.timer.Running() && m.preset.Phase == RESTTIME {
+			a := article(msg)
+			m.article = &a
+		}
+		return m, nil
 
 	default:
 		return m, nil
 	}
 }
 
+// updateTick advances the session and the timers to the time of a tick.
+func (m model) updateTick(now time.Time) (model, tea.Cmd) {
+	m.measureLag(now)
+	m.now = wallClock(now)
+	timers := m.tickTimers()
+	archive := m.archiveTick()
+	summary := m.summaryTick()
+	m.heartbeat()
+	m.urgencyTick()
+	m.syncQuiet()
+	m.transitionTick()
+	m, cmd := m.tickSession()
+	return m, tea.Batch(cmd, timers, archive, summary)
+}
+
+// tickSession advances the session on a tick.
+func (m model) tickSession() (model, tea.Cmd) {
+	var sleepCmd tea.Cmd
+	m, sleepCmd = m.detectSleep(m.now)
+	if sleepCmd != nil {
+		return m, tea.Batch(m.tickCmd(), sleepCmd)
+	}
+
+	running := State{}
+	if m.timer.Running() {
+		running = m.state()
+	}
+	m.tmux.update(running, m.now)
+	m.syncAmbient()
+
+	if repeat := time.Duration(m.cfg.Notify.Repeat); m.awaiting && repeat > 0 && m.now.Sub(m.alertedAt) >= repeat {
+		alert := m.alert()
+		return m, tea.Batch(m.tickCmd(), alert)
+	}
+
+	if m.preroll != nil {
+		return m.updatePreroll()
+	}
+	if m.snoozing != nil {
+		return m.updateSnooze()
+	}
+	if m.scheduled != nil {
+		return m.updateSchedule()
+	}
+
+	if !m.timer.Running() || m.timer.Paused() || m.timer.Overtime() {
+		return m, m.tickCmd()
+	}
+
+	switch m.timer.Tick(m.now) {
+	case timer.Warning:
+		return m, tea.Batch(m.tickCmd(), m.warn(), m.setProgress(m.timer.Progress(m.now)))
+
+	case timer.Completed:
+		return m.complete()
+	}
+
+	cmd := m.setProgress(m.timer.Progress(m.now))
+
+	return m, tea.Batch(m.tickCmd(), cmd)
+}
+
+// complete ends the session once its time is up, going into overtime or
+// on to the end menu.
+func (m model) complete() (model, tea.Cmd) {
+	cmds := []tea.Cmd{
+		m.tickCmd(),
+		m.alert(),
+		m.emit(EventEnd),
+		m.setProgress(1),
+	}
+	if m.preset.Phase == WORKTIME {
+		cmds = append(cmds, m.completeWork())
+	} else {
+		m.countRest(false)
+	}
+
+	// A plan moves on by itself, without overtime or the end menu.
+	if m.flow != nil {
+		cmds = append(cmds, m.record(m.session()), m.advanceFlow())
+		return m, tea.Batch(cmds...)
+	}
+
+	// Alerts repeat until a key is pressed, but only once nothing runs.
+	m.awaiting = true
+
+	if m.cfg.Overtime {
+		m.timer.StartOvertime()
+		m.writeState()
+		return m, tea.Batch(cmds...)
+	}
+
+	cmds = append(cmds, m.finish())
+	return m, tea.Batch(cmds...)
+}
+
+// setProgress moves the progress bar to percent. The bar is updated
+// through a copy, as its animation command holds on to what it is called
+// on and would otherwise keep the whole model on the heap.
+func (m *model) setProgress(percent float64) tea.Cmd {
+	if m.still() {
+		return nil
+	}
+	bar := m.progress
+	cmd := bar.SetPercent(percent)
+	m.progress = bar
+	return cmd
+}
+
+// alert plays the end-of-session sound and sends the notification.
+func (m *model) alert() tea.Cmd {
+	m.alertedAt = m.now
+	event := SoundWorkEnd
+	if m.preset.Phase == RESTTIME {
+		event = SoundRestEnd
+	}
+	title := tr("notify.end", m.preset.Name)
+	return tea.Batch(
+		m.pending.track(soundCmd(m.player, event)),
+		m.notifyEnd(title),
+	)
+}
+
+// emit delivers the named event to hooks and integrations in the background.
+func (m model) emit(name string) tea.Cmd {
+	return m.pending.track(m.events.cmd(m.event(name)))
+}
+
+// event describes the current session for listeners.
+func (m model) event(name string) Event {
+	return Event{
+		Name:      name,
+		Phase:     m.preset.Phase,
+		Preset:    m.preset.Name,
+		Task:      m.task,
+		Remaining: m.timer.SecondsLeft(m.now),
+		EndTime:   m.timer.End(m.now),
+		Time:      m.now,
+	}
+}
+
+// begin starts a fresh session of the given preset.
+func (m *model) begin(p Preset) tea.Cmd {
+	m.announcePhase(p)
+	m.preset = p
+	m.timer = timer.Start(time.Duration(p.Duration), m.now)
+	if p.Duration == 0 {
+		m.timer = timer.StartStopwatch(m.now)
+	}
+	m.timer.WarnBefore(m.now, warnings(m.cfg.Notify, p)...)
+	m.callPaused = false
+	m.longestIdle = 0
+	m.breakAway = 0
+	m.nagged = false
+	m.shutDown = false
+	m.article = nil
+	m.tags = m.rules.tags(m.dir, m.task)
+	m.interruptions = nil
+	m.laps = nil
+	m.writeState()
+
+	ends := tr("notify.ends_at", m.timer.End(m.now).Format("15:04"))
+	if m.timer.Stopwatch() {
+		ends = tr("notify.counting_up")
+	}
+	cmds := []tea.Cmd{m.setProgress(0), m.emit(EventStart), m.announce(tr("notify.started", p.Name), ends)}
+	if p.Phase == WORKTIME {
+		cmds = append(cmds, m.pending.track(soundCmd(m.player, SoundWorkStart)), budgetCmd(m.cfg.Budgets, m.tags, m.now))
+	}
+	if p.Phase == RESTTIME {
+		cmds = append(cmds, suggestCmd(m.cfg.Reading.Source))
+	}
+	cmds = append(cmds, m.phaseCommands(p)...)
+	return tea.Batch(cmds...)
+}
+
+// session returns the history record of the current session ending now.
+func (m model) session() Session {
+	end := m.now
+	overtime := 0
+	planned := m.timer.Length()
+	switch {
+	case m.timer.Stopwatch():
+		// A stopwatch has no plan; it ran as long as it was meant to.
+		planned = m.timer.Elapsed(m.now)
+	case m.timer.Overtime():
+		overtime = int(-m.timer.Remaining(m.now) / time.Second)
+	case m.timer.Remaining(m.now) < 0:
+		// The end was noticed late, e.g. after the computer slept
+		// through it; the session still ended on time.
+		end = end.Add(m.timer.Remaining(m.now))
+	}
+	return Session{
+		Phase:    m.preset.Phase,
+		Preset:   m.preset.Name,
+		Task:     m.task,
+		Tags:     m.tags,
+		Start:    m.timer.Started(),
+		End:      end,
+		Planned:  int(planned / time.Second),
+		Paused:   int(m.timer.PausedFor() / time.Second),
+		Overtime: overtime,
+		Laps:     lapSeconds(m.laps),
+		Estimate: m.estimate(),
+		Context:  m.context,
+
+		Interruptions: m.interruptions,
+		RitualSkipped: m.ritualSkipped,
+		LowConfidence: m.cfg.Idle.LowConfidenceAfter > 0 && m.longestIdle >= time.Duration(m.cfg.Idle.LowConfidenceAfter),
+		Away:          m.breakAwaySeconds(),
+		ShutDown:      m.shutDown,
+	}
+}
+
+// state returns the snapshot of the timer published through the state file.
+func (m model) state() State {
+	t := m.timer.State(m.now)
+	var pausedAt time.Time
+	if t.Paused {
+		pausedAt = m.timer.PausedAt()
+	}
+	return State{
+		Phase:     m.preset.Phase,
+		Preset:    m.preset.Name,
+		Task:      m.task,
+		Paused:    t.Paused,
+		Remaining: m.timer.SecondsLeft(m.now),
+		EndTime:   t.End,
+		Overtime:  t.Overtime,
+		UpdatedAt: m.now,
+		Tags:      m.tags,
+		Stopwatch: m.timer.Stopwatch(),
+		Start:     m.timer.Started(),
+		Planned:   int(m.timer.Length() / time.Second),
+		PausedAt:  pausedAt,
+
+		Interruptions: m.interruptions,
+	}
+}
+
+// activeKeys returns the key map with only the bindings usable in the
+// current screen enabled.
+func (m model) activeKeys() keyMap {
+	keys := m.keys
+	keys.setRunning(m.timer.Running())
+	if m.timer.Paused() {
+		keys.Pause.SetHelp(keys.Pause.Help().Key, tr("key.resume"))
+	}
+	keys.Snooze.SetEnabled(m.ended != nil)
+	if m.ended != nil {
+		keys.Start.SetHelp(keys.Start.Help().Key, tr("key.select"))
+	}
+	if m.muted {
+		keys.Mute.SetHelp(keys.Mute.Help().Key, tr("key.unmute"))
+	}
+	keys.Quiet.SetEnabled(m.quiet.hours != nil || m.cfg.Quiet.detects())
+	keys.Log.SetEnabled(m.cfg.OnStart.configured())
+	if m.quiet.on {
+		keys.Quiet.SetHelp(keys.Quiet.Help().Key, tr("key.unquiet"))
+	}
+	if m.timer.Overtime() {
+		keys.Pause.SetEnabled(false)
+		keys.Restart.SetEnabled(false)
+		keys.Reset.SetHelp(keys.Reset.Help().Key, tr("key.finish"))
+		keys.Skip.SetHelp(keys.Skip.Help().Key, tr("key.finish_next"))
+	}
+	keys.Focus.SetEnabled(len(m.timers) > 0)
+	keys.Theme.SetEnabled(m.appearance != nil)
+	if m.cfg.Accessible {
+		keys.Big.SetEnabled(false)
+	}
+	if len(m.cfg.Profiles) == 0 {
+		keys.Profile.SetEnabled(false)
+	}
+	keys.Interrupt.SetEnabled(m.timer.Running() && !m.timer.Overtime() && m.preset.Phase == WORKTIME)
+	keys.Lap.SetEnabled(m.timer.Stopwatch())
+	if m.timer.Stopwatch() {
+		keys.Skip.SetEnabled(false)
+		keys.Reset.SetHelp(keys.Reset.Help().Key, tr("key.stop_save"))
+	}
+	if t := m.focused(); t != nil {
+		keys.Pause.SetEnabled(true)
+		keys.Pause.SetHelp(keys.Pause.Help().Key, tr("key.pause_timer", t.name))
+		if t.timer.Paused() {
+			keys.Pause.SetHelp(keys.Pause.Help().Key, tr("key.resume_timer", t.name))
+		}
+		keys.Reset.SetEnabled(true)
+		keys.Reset.SetHelp(keys.Reset.Help().Key, tr("key.stop_timer", t.name))
+	}
+	return keys
+}
+
+// helpView renders the help bar indented by pad.
+func (m model) helpView(pad string) string {
+	if m.locked {
+		return ""
+	}
+	return pad + strings.ReplaceAll(m.help.View(m.activeKeys()), "\n", "\n"+pad)
+}
+
 func (m model) View() string {
-	if m.timeLeft <= 0 {
+	if m.cfg.Accessible {
+		return plainText(m.view())
+	}
+	if m.flash {
+		return flashStyle.Render(m.view())
+	}
+	return m.view()
+}
+
+// view renders the current screen.
+func (m model) view() string {
+	if m.switchTo != nil {
+		// Leave nothing behind for the interface started next.
+		return ""
+	}
+	if m.transition != nil {
+		return m.transitionView()
+	}
+	if m.showNotes {
+		return m.notesView()
+	}
+	if m.ritual != nil {
+		return m.ritualView()
+	}
+	if m.preroll != nil {
+		return m.prerollView()
+	}
+	if m.snoozing != nil {
+		return m.snoozeView()
+	}
+	if m.scheduled != nil && m.interrupted == nil {
+		return m.scheduleView()
+	}
+
+	if m.ended != nil {
+		return m.menuView()
+	}
+
+	if !m.timer.Running() {
 		s := strings.Builder{}
-		s.WriteString("Choose time type:\n")
+		s.WriteString(tr("chooser.title") + m.profileView() + ":\n")
 
-		for i := 0; i < len(choices); i++ {
+		for i := 0; i < len(m.presets); i++ {
+			p := m.presets[i]
 			if m.cursor == i {
-				s.WriteString("[•] ")
+				s.WriteString(m.theme.Selected.Render("[•] " + p.Name))
 			} else {
-				s.WriteString("[ ] ")
+				s.WriteString("[ ] " + p.Name)
 			}
-			s.WriteString(choices[i])
-			totalTime := mapping[choices[i]]
-			minutes := (totalTime % 3600) / 60
+			minutes := int(time.Duration(p.Duration) / time.Minute)
 			s.WriteString(fmt.Sprintf(" (%02dm)", minutes))
 			s.WriteString("\n")
 		}
-		s.WriteString("\n(press q to quit)\n")
+		if m.task != "" && !m.locked {
+			s.WriteString("\n" + tr("prompt.task") + m.task + "\n")
+		}
+		s.WriteString("\n" + m.flowView("") + m.timersView("") + m.resumeView() + m.goalView("") + m.velocityView("") + m.logView("") + m.inputView("") + m.helpView("") + "\n")
+		if m.status != "" {
+			s.WriteString(m.theme.Status.Render(m.status) + "\n")
+		}
 
 		return s.String()
 	}
 
+	if m.cfg.Accessible {
+		return m.accessibleView()
+	}
+	if m.big {
+		return m.bigView()
+	}
+
 	pad := strings.Repeat(" ", padding)
 
-	minutes := (m.timeLeft % 3600) / 60
-	seconds := m.timeLeft - minutes*60
+	timeLeft := m.timer.SecondsLeft(m.now)
+	minutes := timeLeft / 60
+	seconds := timeLeft - minutes*60
+
+	if m.timer.Overtime() {
+		over := -m.timer.Remaining(m.now) / time.Second
+		return "\n" +
+			pad + m.theme.Title.Render(m.title()) + "\n\n" +
+			pad + m.progressView() + "\n\n" +
+			pad + m.theme.Overtime.Render(tr("view.overtime", over/60, over%60)) + "\n\n" +
+			m.timersView(pad) +
+			m.logView(pad) +
+			m.inputView(pad) +
+			m.helpView(pad) +
+			m.statusView(pad)
+	}
 
 	pause := "▶️"
-	if m.pause {
+	if m.timer.Paused() {
 		pause = "⏸️"
 	}
 
+	if m.timer.Stopwatch() {
+		return "\n" +
+			pad + m.theme.Title.Render(m.title()) + "\n\n" +
+			pad + tr("view.elapsed", clockText(m.timer.Elapsed(m.now)), pause) + "\n\n" +
+			m.lapsView(pad) +
+			m.sleepView(pad) +
+			m.timersView(pad) +
+			m.tagsView(pad) +
+			m.interruptionsView(pad) +
+			m.goalView(pad) +
+			m.logView(pad) +
+			m.inputView(pad) +
+			m.helpView(pad) +
+			m.statusView(pad)
+	}
+
 	return "\n" +
-		pad + m.timeType + "\n\n" +
-		pad + m.progress.View() + "\n\n" +
-		pad + fmt.Sprintf("%02dm%02ds -> %s %v", minutes, seconds, m.endTime.Format("15:04:05"), pause) +
-		pad + helpStyle("Press 'q' key to quit")
+		pad + m.theme.Title.Render(m.title()) + "\n\n" +
+		pad + m.progressView() + "\n\n" +
+		pad + fmt.Sprintf("%02dm%02ds -> %s %v", minutes, seconds, m.timer.End(m.now).Format("15:04:05"), pause) + "\n\n" +
+		m.flowView(pad) +
+		m.sleepView(pad) +
+		m.timersView(pad) +
+		m.tagsView(pad) +
+		m.interruptionsView(pad) +
+		m.goalView(pad) +
+		m.velocityView(pad) +
+		m.articleView(pad) +
+		m.logView(pad) +
+		m.inputView(pad) +
+		m.helpView(pad) +
+		m.statusView(pad)
+}
+
+// title names the running session and the task it is spent on.
+func (m model) title() string {
+	if m.task == "" || m.locked {
+		return m.preset.Name
+	}
+	return m.preset.Name + " · " + m.task
+}
+
+// tagsView shows the tags the session will be saved with.
+func (m model) tagsView(pad string) string {
+	if len(m.tags) == 0 || m.locked {
+		return ""
+	}
+	label := "🏷 #"
+	if m.cfg.Accessible {
+		label = tr("view.tags")
+	}
+	return pad + m.theme.Help.Render(label+strings.Join(m.tags, " #")) + "\n\n"
+}
+
+// articleView suggests something to read during the break, boxed in by
+// the time the break ends.
+func (m model) articleView(pad string) string {
+	if m.article == nil {
+		return ""
+	}
+	return pad + "📖 " + m.article.Title + "\n" +
+		pad + m.theme.Help.Render(tr("view.article", m.article.Link, m.timer.End(m.now).Format("15:04"))) + "\n\n"
+}
+
+// statusView renders the last non-fatal error, if any.
+func (m model) statusView(pad string) string {
+	if m.status == "" {
+		return ""
+	}
+	return "\n\n" + pad + m.theme.Status.Render(m.status)
+}
+
+// bigView renders the remaining time in large digits centered in the
+// terminal, for reading the timer from across the room.
+func (m model) bigView() string {
+	timeLeft := m.timer.SecondsLeft(m.now)
+	text := fmt.Sprintf("%02d:%02d", timeLeft/60, timeLeft%60)
+	if m.timer.Stopwatch() {
+		text = clockText(m.timer.Elapsed(m.now))
+	}
+	clock := m.theme.Title.Render(bigText(text))
+
+	caption := m.title()
+	if m.timer.Paused() {
+		caption += tr("view.paused")
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		clock,
+		"",
+		caption,
+		"",
+		m.help.View(m.activeKeys()),
+	)
+	if m.width == 0 || m.height == 0 {
+		return content
+	}
+	return place(m.width, m.height, content)
+}
+
+// place centers content in a width by height area. It leaves out the
+// trailing whitespace lipgloss.Place fills the area with, which takes
+// an allocation per cell and the big clock is redrawn several times a
+// second.
+func place(width, height int, content string) string {
+	lines := strings.Split(content, "\n")
+	left := strings.Repeat(" ", max(0, (width-lipgloss.Width(content))/2))
+
+	var b strings.Builder
+	b.Grow(len(content) + len(lines)*len(left) + height)
+	b.WriteString(strings.Repeat("\n", max(0, (height-len(lines))/2)))
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(left)
+		b.WriteString(line)
+	}
+	return b.String()
 }
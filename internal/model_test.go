@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// benchModel returns a model in the middle of a work session, with its
+// data kept in a temporary directory.
+func benchModel(tb testing.TB) model {
+	tb.Helper()
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+		tb.Setenv(env, tb.TempDir())
+	}
+
+	player, err := NewPlayer(SoundConfig{Backend: BackendNone})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	m, err := NewModel(Config{}, player, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = next.(model)
+	m.task = "write the report"
+	m.begin(m.presets[0])
+	m.now = m.now.Add(7 * time.Minute)
+	return m
+}
+
+func BenchmarkView(b *testing.B) {
+	m := benchModel(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = m.View()
+	}
+}
+
+func BenchmarkViewBigClock(b *testing.B) {
+	m := benchModel(b)
+	m.big = true
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = m.View()
+	}
+}
+
+func BenchmarkUpdateTick(b *testing.B) {
+	m := benchModel(b)
+	now := m.now
+	b.ReportAllocs()
+	for b.Loop() {
+		now = now.Add(tickEvery(0))
+		next, _ := m.Update(tickMsg(now))
+		m = next.(model)
+	}
+}
+
+// TestAllocationBudget keeps the work done several times a second from
+// growing unnoticed. Raise a budget only for a good reason. The budgets
+// are for the screen without colors, whichever the terminal running the
+// tests supports.
+func TestAllocationBudget(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.Ascii)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	m := benchModel(t)
+	big := m
+	big.big = true
+	now := m.now
+
+	tests := []struct {
+		name   string
+		budget float64
+		run    func()
+	}{
+		{"View", 100, func() { _ = m.View() }},
+		{"View big clock", 150, func() { _ = big.View() }},
+		{"Update tick", 25, func() {
+			now = now.Add(tickEvery(0))
+			next, _ := m.Update(tickMsg(now))
+			m = next.(model)
+		}},
+	}
+	for _, tt := range tests {
+		if got := testing.AllocsPerRun(100, tt.run); got > tt.budget {
+			t.Errorf("%s: %v allocations, budget %v", tt.name, got, tt.budget)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Rows of the screens that take clicks, counted from the top of the view.
+const (
+	chooserFirstRow = 1 // below "Choose time type:"
+	progressRow     = 3 // below the blank line, the title and another blank line
+)
+
+// mouseCmds turns on mouse reporting. The view moves to the alternate
+// screen, so its rows are the terminal's and clicks can be matched to them.
+func mouseCmds() []tea.Cmd {
+	return []tea.Cmd{tea.EnterAltScreen, tea.EnableMouseCellMotion}
+}
+
+// updateMouse handles clicks and the wheel: a click on a preset starts it
+// and one on an entry of the end menu picks it, a click on the progress bar
+// pauses or resumes, and the wheel moves through the presets, the end menu
+// and the notes. Screens that wait for an answer ignore the mouse.
+func (m model) updateMouse(msg tea.MouseMsg) (model, tea.Cmd) {
+	if m.locked || m.prompt != promptNone || m.switchTo != nil {
+		return m, nil
+	}
+
+	if msg.Action == tea.MouseActionPress && (msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown) {
+		action := "up"
+		if msg.Button == tea.MouseButtonWheelDown {
+			action = "down"
+		}
+		if m.showNotes || m.ended != nil || !m.timer.Running() && !m.holding() {
+			return m.control(controlMsg(action))
+		}
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft || m.showNotes || m.holding() {
+		return m, nil
+	}
+	switch {
+	case m.ended != nil:
+		first := 2 + strings.Count(m.goalView(""), "\n")
+		if i := msg.Y - first; i >= 0 && i < len(menuActions) {
+			m.menuCursor = i
+			return m.control("start")
+		}
+
+	case !m.timer.Running():
+		if i := msg.Y - chooserFirstRow; i >= 0 && i < len(m.presets) {
+			m.cursor = i
+			return m.control("start")
+		}
+
+	case m.big || msg.Y == progressRow && !m.cfg.Accessible:
+		return m.control("pause")
+	}
+	return m, nil
+}
+
+// holding reports whether a question or a countdown holds the screen
+// around a session, during which the mouse is ignored.
+func (m model) holding() bool {
+	return m.ritual != nil || m.preroll != nil || m.transition != nil || m.snoozing != nil ||
+		m.scheduled != nil || m.interrupted != nil || m.slept
+}
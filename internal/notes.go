@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openNotes loads the sessions that have notes, newest first, and shows
+// them.
+func (m model) openNotes() (model, tea.Cmd) {
+	sessions, err := loadSessions()
+	if err != nil {
+		m.fail(newError(CodeHistoryRead, "read the history", err))
+		return m, nil
+	}
+
+	m.notes = nil
+	for _, s := range slices.Backward(sessions) {
+		if s.Note != "" {
+			m.notes = append(m.notes, s)
+		}
+	}
+	m.showNotes = true
+	m.notesOffset = 0
+	return m, nil
+}
+
+// notesKeys scrolls through the notes and closes them.
+func (m model) notesKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		m.notesOffset = max(m.notesOffset-1, 0)
+
+	case key.Matches(msg, m.keys.Down):
+		m.notesOffset = min(m.notesOffset+1, max(len(m.notes)-1, 0))
+
+	case key.Matches(msg, m.keys.Notes, m.keys.Reset):
+		m.showNotes = false
+	}
+	return m, nil
+}
+
+// notesView lists past notes, as many as fit the terminal.
+func (m model) notesView() string {
+	s := strings.Builder{}
+	s.WriteString(m.theme.Title.Render(tr("notes.title")) + "\n\n")
+	if len(m.notes) == 0 {
+		s.WriteString(tr("notes.empty") + "\n")
+	}
+
+	rows := len(m.notes)
+	if m.height > 0 {
+		rows = max(m.height-6, 1)
+	}
+	for _, n := range m.notes[m.notesOffset:min(m.notesOffset+rows, len(m.notes))] {
+		when := n.End.Local().Format("Mon Jan 2 15:04")
+		if n.Task != "" {
+			when += " · " + n.Task
+		}
+		s.WriteString(m.theme.Help.Render(when) + "  " + n.Note + "\n")
+	}
+
+	s.WriteString("\n" + m.theme.Help.Render(tr("notes.help",
+		m.keys.Up.Help().Key, m.keys.Down.Help().Key, m.keys.Notes.Help().Key)) + "\n")
+	return s.String()
+}
@@ -1,13 +1,342 @@
 package internal
 
-import "os/exec"
-
-func notify(title, message string) error {
-	cmd := exec.Command(
-		"terminal-notifier",
-		"-title", title,
-		"-message", message,
-		"-activate", "com.mitchellh.ghostty",
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Notifier delivers a notification when a session ends.
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// Notifier backends that can be listed in the config.
+const (
+	NotifyDesktop = "desktop"
+	NotifyGotify  = "gotify"
+	NotifyHTTP    = "http"
+	NotifyNone    = "none"
+)
+
+// actionNotifier is a Notifier whose notifications can carry buttons. It
+// waits for one to be clicked and returns its index, or -1 when the
+// notification is dismissed otherwise.
+type actionNotifier interface {
+	NotifyActions(title, message string, labels []string) (int, error)
+}
+
+// Buttons that can be put on the end-of-session notification.
+const (
+	NotifyActionNext   = "next"   // start the next session
+	NotifyActionSnooze = "snooze" // hold off the next session
+)
+
+// defaultActivate is the app terminal-notifier brings forward when a
+// notification is clicked.
+const defaultActivate = "com.mitchellh.ghostty"
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewNotifier builds a Notifier sending to every configured backend. With
+// no backends configured it falls back to desktop notifications, when the
+// build has them. Desktop backends are left out of builds without.
+func NewNotifier(cfg NotifyConfig) (Notifier, error) {
+	for _, a := range cfg.Desktop.Actions {
+		if a != NotifyActionNext && a != NotifyActionSnooze {
+			return nil, fmt.Errorf("unknown notification action %q", a)
+		}
+	}
+
+	backends := cfg.Backends
+	if len(backends) == 0 {
+		backends = []string{NotifyDesktop}
+	}
+	if !desktopNotifySupport {
+		backends = slices.DeleteFunc(slices.Clone(backends), func(b string) bool { return b == NotifyDesktop })
+	}
+
+	var ns multiNotifier
+	for _, b := range backends {
+		switch b {
+		case NotifyDesktop:
+			n, err := newDesktopNotifier(cfg.Desktop)
+			if err != nil {
+				return nil, err
+			}
+			ns = append(ns, n)
+		case NotifyGotify:
+			n, err := newGotifyNotifier(cfg.Gotify)
+			if err != nil {
+				return nil, err
+			}
+			ns = append(ns, n)
+		case NotifyHTTP:
+			n, err := newHTTPNotifier(cfg.HTTP)
+			if err != nil {
+				return nil, err
+			}
+			ns = append(ns, n)
+		case NotifyNone:
+		default:
+			return nil, fmt.Errorf("unknown notifier backend %q", b)
+		}
+	}
+	return ns, nil
+}
+
+// multiNotifier fans a notification out to several backends.
+type multiNotifier []Notifier
+
+func (ns multiNotifier) Notify(title, message string) error {
+	var errs []error
+	for _, n := range ns {
+		errs = append(errs, n.Notify(title, message))
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyActions sends the notification with buttons through the first
+// backend that supports them and without to the others.
+func (ns multiNotifier) NotifyActions(title, message string, labels []string) (int, error) {
+	var (
+		errs    []error
+		buttons actionNotifier
 	)
-	return cmd.Run()
+	for _, n := range ns {
+		if an, ok := n.(actionNotifier); ok && buttons == nil {
+			buttons = an
+			continue
+		}
+		errs = append(errs, n.Notify(title, message))
+	}
+
+	chosen := -1
+	if buttons != nil {
+		var err error
+		chosen, err = buttons.NotifyActions(title, message, labels)
+		errs = append(errs, err)
+	}
+	return chosen, errors.Join(errs...)
+}
+
+// desktopNotifier shows a desktop notification, through terminal-notifier
+// on macOS unless another command is configured.
+type desktopNotifier struct {
+	command  []*template.Template
+	activate string
+}
+
+// desktopArgs is what the arguments of a desktop notification command are
+// executed with.
+type desktopArgs struct {
+	Title, Message, Activate string
+	Actions                  string // the button labels, comma-separated
+}
+
+func newDesktopNotifier(cfg DesktopNotifyConfig) (desktopNotifier, error) {
+	d := desktopNotifier{activate: cfg.Activate}
+	if d.activate == "" {
+		d.activate = defaultActivate
+	}
+	for i, arg := range cfg.Command {
+		t, err := template.New("arg").Parse(arg)
+		if err != nil {
+			return desktopNotifier{}, fmt.Errorf("desktop notifier command argument %d: %w", i+1, err)
+		}
+		d.command = append(d.command, t)
+	}
+	return d, nil
+}
+
+func (d desktopNotifier) Notify(title, message string) error {
+	_, err := d.run(desktopArgs{Title: title, Message: message, Activate: d.activate})
+	return err
+}
+
+// NotifyActions implements actionNotifier. The command is expected to
+// wait for a click and print the label of the button, or its index as
+// notify-send does. terminal-notifier has no buttons, so it only shows the
+// notification.
+func (d desktopNotifier) NotifyActions(title, message string, labels []string) (int, error) {
+	if d.command == nil {
+		return -1, d.Notify(title, message)
+	}
+	out, err := d.run(desktopArgs{
+		Title:    title,
+		Message:  message,
+		Activate: d.activate,
+		Actions:  strings.Join(labels, ","),
+	})
+	if err != nil {
+		return -1, err
+	}
+	return chosenAction(out, labels), nil
+}
+
+// run runs the notification command and returns what it printed.
+func (d desktopNotifier) run(args desktopArgs) (string, error) {
+	if d.command == nil {
+		return "", exec.Command(
+			"terminal-notifier",
+			"-title", args.Title,
+			"-message", args.Message,
+			"-activate", args.Activate,
+		).Run()
+	}
+
+	argv := make([]string, len(d.command))
+	for i, t := range d.command {
+		var arg strings.Builder
+		if err := t.Execute(&arg, args); err != nil {
+			return "", err
+		}
+		argv[i] = arg.String()
+	}
+	out, err := exec.Command(argv[0], argv[1:]...).Output()
+	return string(out), err
+}
+
+// chosenAction finds the button a notification command reported, by label
+// or by index, returning -1 for anything else, such as a dismissal.
+func chosenAction(out string, labels []string) int {
+	out = strings.TrimSpace(out)
+	if i := slices.Index(labels, out); i >= 0 {
+		return i
+	}
+	if i, err := strconv.Atoi(out); err == nil && i >= 0 && i < len(labels) {
+		return i
+	}
+	return -1
+}
+
+// gotifyNotifier pushes messages to a Gotify server.
+type gotifyNotifier struct {
+	url      string
+	token    string
+	priority int
+}
+
+func newGotifyNotifier(cfg GotifyConfig) (gotifyNotifier, error) {
+	if cfg.URL == "" || cfg.Token == "" {
+		return gotifyNotifier{}, errors.New("gotify notifier needs url and token")
+	}
+	return gotifyNotifier{
+		url:      strings.TrimSuffix(cfg.URL, "/") + "/message",
+		token:    cfg.Token,
+		priority: cfg.Priority,
+	}, nil
+}
+
+func (g gotifyNotifier) Notify(title, message string) error {
+	body, err := json.Marshal(map[string]any{
+		"title":    title,
+		"message":  message,
+		"priority": g.priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.token)
+	return send(req)
+}
+
+// httpNotifier sends a templated request to any push service.
+type httpNotifier struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    *template.Template
+}
+
+// defaultHTTPBody is the request body used when none is configured.
+const defaultHTTPBody = `{"title": {{json .Title}}, "message": {{json .Message}}}`
+
+func newHTTPNotifier(cfg HTTPNotifyConfig) (httpNotifier, error) {
+	if cfg.URL == "" {
+		return httpNotifier{}, errors.New("http notifier needs url")
+	}
+
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	text := cfg.Body
+	if text == "" {
+		text = defaultHTTPBody
+	}
+	body, err := template.New("body").Funcs(template.FuncMap{"json": jsonString}).Parse(text)
+	if err != nil {
+		return httpNotifier{}, fmt.Errorf("http notifier body: %w", err)
+	}
+
+	return httpNotifier{method: method, url: cfg.URL, headers: cfg.Headers, body: body}, nil
+}
+
+func (h httpNotifier) Notify(title, message string) error {
+	var body bytes.Buffer
+	data := struct{ Title, Message string }{title, message}
+	if err := h.body.Execute(&body, data); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(h.method, h.url, &body)
+	if err != nil {
+		return err
+	}
+	if _, ok := h.headers["Content-Type"]; !ok {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return send(req)
+}
+
+// jsonString quotes s as a JSON string for use in body templates.
+func jsonString(s string) (string, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// send performs req and treats any non-2xx response as an error.
+func send(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// notifyCmd sends a notification in the background so slow backends don't
+// block the UI, reporting failures as an *Error.
+func notifyCmd(n Notifier, title, message string) tea.Cmd {
+	return func() tea.Msg {
+		if err := n.Notify(title, message); err != nil {
+			return newError(CodeNotify, "send the notification", err)
+		}
+		return nil
+	}
 }
@@ -0,0 +1,7 @@
+//go:build !nonotify
+
+package internal
+
+// desktopNotifySupport reports whether this build shows desktop
+// notifications. Build with the nonotify tag to leave them out.
+const desktopNotifySupport = true
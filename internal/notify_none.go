@@ -0,0 +1,8 @@
+//go:build nonotify
+
+package internal
+
+// desktopNotifySupport reports whether this build shows desktop
+// notifications. This one was built with the nonotify tag, for servers and
+// containers with nothing to show them on.
+const desktopNotifySupport = false
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PhaseCommandsConfig runs commands as work sessions and breaks start, to
+// set up the environment for one and tear it down for the other, such as
+// opening the editor's workspace for work and closing it for a break.
+type PhaseCommandsConfig struct {
+	Work  []PhaseCommand `json:"work"`
+	Break []PhaseCommand `json:"break"`
+}
+
+// PhaseCommand is a shell command run as a session starts.
+type PhaseCommand struct {
+	Run string `json:"run"`
+
+	// Dir is where the command runs, the directory manta was launched in
+	// when empty.
+	Dir string `json:"dir"`
+
+	// Timeout stops the command once it has run this long, 30s when
+	// unset.
+	Timeout Duration `json:"timeout"`
+}
+
+// defaultCommandTimeout is how long a phase command runs at most when its
+// timeout isn't set.
+const defaultCommandTimeout = 30 * time.Second
+
+// Lines of command output kept for the log pane, and shown in it.
+const (
+	logLines     = 200
+	logPaneLines = 8
+)
+
+// validate checks that every command has something to run.
+func (c PhaseCommandsConfig) validate() error {
+	for _, phase := range []struct {
+		name string
+		cmds []PhaseCommand
+	}{{"work", c.Work}, {"break", c.Break}} {
+		for i, cmd := range phase.cmds {
+			if strings.TrimSpace(cmd.Run) == "" {
+				return fmt.Errorf("%s command %d: nothing to run", phase.name, i+1)
+			}
+			if cmd.Timeout < 0 {
+				return fmt.Errorf("%s command %d: negative timeout", phase.name, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// configured reports whether any command is set.
+func (c PhaseCommandsConfig) configured() bool {
+	return len(c.Work) > 0 || len(c.Break) > 0
+}
+
+// phaseOutputMsg carries what a phase command printed.
+type phaseOutputMsg struct {
+	command string
+	output  string
+	err     error
+	at      time.Time
+}
+
+// phaseCommands runs the commands set for the phase of p in the
+// background, each reporting its output with a phaseOutputMsg.
+func (m model) phaseCommands(p Preset) []tea.Cmd {
+	cmds := m.cfg.OnStart.Work
+	if p.Phase == RESTTIME {
+		cmds = m.cfg.OnStart.Break
+	}
+	env := append(os.Environ(),
+		"MANTA_PHASE="+p.Phase,
+		"MANTA_PRESET="+p.Name,
+		"MANTA_TASK="+m.task,
+	)
+	clock := m.clock
+	var run []tea.Cmd
+	for _, c := range cmds {
+		dir := cmp.Or(expandHome(c.Dir), m.dir)
+		timeout := cmp.Or(time.Duration(c.Timeout), defaultCommandTimeout)
+		run = append(run, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			cmd := exec.CommandContext(ctx, "sh", "-c", c.Run)
+			cmd.Dir = dir
+			cmd.Env = env
+			// Don't wait on children that outlive the shell, such as an
+			// editor it started, for the output they hold open.
+			cmd.WaitDelay = time.Second
+			out, err := cmd.CombinedOutput()
+			switch {
+			case ctx.Err() != nil:
+				err = fmt.Errorf("timed out after %s", timeout)
+			case errors.Is(err, exec.ErrWaitDelay):
+				err = nil
+			}
+			return phaseOutputMsg{command: c.Run, output: string(bytes.TrimSpace(out)), err: err, at: clock()}
+		})
+	}
+	return run
+}
+
+// updatePhaseOutput adds the output of a phase command to the log, and
+// reports it when the command failed.
+func (m model) updatePhaseOutput(msg phaseOutputMsg) (model, tea.Cmd) {
+	lines := []string{msg.at.Format("15:04:05") + " $ " + msg.command}
+	if msg.output != "" {
+		for _, line := range strings.Split(msg.output, "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+	if msg.err != nil {
+		lines = append(lines, "  ! "+msg.err.Error())
+		m.fail(newError(CodePhaseCommand, fmt.Sprintf("run %q", msg.command), msg.err))
+	}
+	m.commandLog = append(m.commandLog, lines...)
+	if len(m.commandLog) > logLines {
+		m.commandLog = m.commandLog[len(m.commandLog)-logLines:]
+	}
+	return m, nil
+}
+
+// logView shows the last lines of the command log while the log pane is
+// open.
+func (m model) logView(pad string) string {
+	if !m.showLog {
+		return ""
+	}
+	var s strings.Builder
+	s.WriteString(pad + m.theme.Title.Render(tr("log.title")) + "\n")
+	if len(m.commandLog) == 0 {
+		s.WriteString(pad + m.theme.Help.Render(tr("log.empty")) + "\n")
+	}
+	for _, line := range m.commandLog[max(len(m.commandLog)-logPaneLines, 0):] {
+		s.WriteString(pad + m.theme.Help.Render(line) + "\n")
+	}
+	return s.String() + "\n"
+}
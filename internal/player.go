@@ -1,82 +1,240 @@
 package internal
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"sync"
-	"time"
 
-	"github.com/ebitengine/oto/v3"
-	"github.com/hajimehoshi/go-mp3"
-
-	"github.com/ihorbryk/manta/assets"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
-var (
-	otoCtx  *oto.Context
-	otoOnce sync.Once
+// warningGain is the volume of the default warning sound relative to the
+// end sound.
+const warningGain = 0.4
+
+// Sound backends that can be picked in the config.
+const (
+	BackendAudio = "audio"
+	BackendBell  = "bell"
+	BackendNone  = "none"
+
+	// backendFallback is switched to when there is no audio output, so
+	// sounds go straight to the fallback channels.
+	backendFallback = "fallback"
 )
 
-// initOtoContext initializes the shared Oto context.
-// This function should only be called once via sync.Once.
-// Creating multiple contexts is NOT supported by the Oto library.
-func initOtoContext() {
-	op := &oto.NewContextOptions{}
+// Player plays notification sounds through the shared Oto context.
+type Player struct {
+	backend string
+	sounds  map[string]sound
+	gain    map[string]float64 // scales the volume of some events' sounds
 
-	// Usually 44100 or 48000. Other values might cause distortions in Oto
-	op.SampleRate = 44100
+	// fallbacks are tried in order when sounds can't be played.
+	fallbacks []string
 
-	// Number of channels (aka locations) to play sounds from. Either 1 or 2.
-	// 1 is mono sound, and 2 is stereo (most speakers are stereo).
-	op.ChannelCount = 2
+	mu     sync.Mutex
+	volume float64
+	muted  bool
+	quiet  bool // held back by quiet hours
+	saving bool // held back to save power
+}
 
-	// Format of the source. go-mp3's format is signed 16bit integers.
-	op.Format = oto.FormatSignedInt16LE
+// NewPlayer loads and decodes the configured sounds, so bad files are
+// reported and playing takes no decoding. The audio device itself is opened
+// lazily on the first playback.
+func NewPlayer(cfg SoundConfig) (*Player, error) {
+	backend := cfg.Backend
+	switch backend {
+	case "":
+		backend = BackendAudio
+	case BackendAudio, BackendBell, BackendNone:
+	default:
+		return nil, fmt.Errorf("unknown sound backend %q", backend)
+	}
+	if backend == BackendAudio && !audioSupport {
+		// The same config works on builds without audio; alerts go
+		// straight to the fallback channels.
+		backend = backendFallback
+	}
 
-	// Create the context once and reuse it for all audio playback
-	ctx, readyChan, err := oto.NewContext(op)
-	if err != nil {
-		panic("oto.NewContext failed: " + err.Error())
+	paths := map[string]string{
+		SoundWorkStart: cfg.WorkStart,
+		SoundWorkEnd:   cfg.WorkEnd,
+		SoundRestEnd:   cfg.RestEnd,
+		SoundPreroll:   cfg.Preroll,
+		SoundTimer:     cfg.Timer,
+		SoundWarning:   cfg.Warning,
+		SoundGoal:      cfg.Goal,
 	}
-	// It might take a bit for the hardware audio devices to be ready, so we wait on the channel.
-	<-readyChan
 
-	otoCtx = ctx
-}
+	volume := 1.0
+	if cfg.Volume != nil {
+		volume = *cfg.Volume
+		if volume < 0 || volume > 1 {
+			return nil, fmt.Errorf("volume %v: must be between 0 and 1", volume)
+		}
+	}
 
-func PlayNotification() {
-	// Ensure the Oto context is initialized (only happens once)
-	otoOnce.Do(initOtoContext)
+	fallbacks := cfg.Fallback
+	if len(fallbacks) == 0 {
+		fallbacks = defaultFallbacks
+	}
+	if err := validateFallbacks(fallbacks); err != nil {
+		return nil, err
+	}
 
-	// Read the embedded mp3 file into memory
-	fileBytes, err := assets.NotifySound.ReadFile("notify.mp3")
-	if err != nil {
-		panic("reading embedded notify.mp3 failed: " + err.Error())
+	p := &Player{backend: backend, sounds: make(map[string]sound, len(paths)), volume: volume, gain: map[string]float64{}, fallbacks: fallbacks}
+	// Events sharing a sound share its decoded buffer.
+	loaded := map[string]sound{}
+	for event, path := range paths {
+		path, ok := cfg.soundPath(event, path)
+		if !ok {
+			continue
+		}
+		if event == SoundWarning && path == "" {
+			// The warning reuses the end sound; play it softer so the
+			// two can be told apart.
+			p.gain[SoundWarning] = warningGain
+		}
+		s, ok := loaded[path]
+		if !ok {
+			var err error
+			if s, err = loadSound(path, true); err != nil {
+				return nil, fmt.Errorf("%s sound: %w", event, err)
+			}
+			loaded[path] = s
+		}
+		p.sounds[event] = s
 	}
+	return p, nil
+}
 
-	// Convert the pure bytes into a reader object that can be used with the mp3 decoder
-	fileBytesReader := bytes.NewReader(fileBytes)
+// Backend returns the sound backend in use.
+func (p *Player) Backend() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.backend
+}
 
-	// Decode file
-	decodedMp3, err := mp3.NewDecoder(fileBytesReader)
-	if err != nil {
-		panic("mp3.NewDecoder failed: " + err.Error())
+// Check opens the audio device, if the backend uses one, and reports
+// whether sounds can be played.
+func (p *Player) Check() error {
+	if p.Backend() != BackendAudio {
+		return nil
 	}
+	return openAudio()
+}
+
+// ToggleMute silences or restores playback and reports whether the player
+// is now muted.
+func (p *Player) ToggleMute() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.muted = !p.muted
+	return p.muted
+}
 
-	// Create a new 'player' that will handle our sound. Paused by default.
-	// We reuse the shared context but create a new player for each playback.
-	player := otoCtx.NewPlayer(decodedMp3)
+// SetQuiet holds sounds back, or lets them play again, apart from muting.
+func (p *Player) SetQuiet(quiet bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quiet = quiet
+}
+
+// SetSaving holds sounds back, or lets them play again, to save power.
+func (p *Player) SetSaving(saving bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saving = saving
+}
 
-	// Play starts playing the sound and returns without waiting for it (Play() is async).
-	player.Play()
+// Play plays the sound mapped to event and blocks until it finishes, so
+// the TUI only calls it from soundCmd. It returns an error when audio is
+// unavailable.
+// Nothing is played while muted, quiet or saving power, or for a silent
+// event.
+//
+// When the output device goes away mid-playback, e.g. a Bluetooth headset
+// disconnects, Play returns an error instead of waiting forever. Oto can
+// only open the device once per process, so later calls keep failing fast
+// and callers use the fallback channels.
+func (p *Player) Play(event string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("audio playback failed: %v", r)
+		}
+	}()
+
+	p.mu.Lock()
+	backend, volume, muted := p.backend, p.volume, p.muted || p.quiet || p.saving
+	p.mu.Unlock()
+	s, ok := p.sounds[event]
+	if !ok || muted || volume == 0 || backend == BackendNone {
+		return nil
+	}
+	if backend == BackendBell {
+		ringBell()
+		return nil
+	}
 
-	// We can wait for the sound to finish playing using something like this
-	for player.IsPlaying() {
-		time.Sleep(time.Millisecond)
+	if backend == backendFallback {
+		return errNoAudio
 	}
 
-	// Close the player to free resources after playback completes
-	err = player.Close()
+	if err := openAudio(); err != nil {
+		return err
+	}
+
+	pcm, err := s.stream()
 	if err != nil {
-		panic("player.Close failed: " + err.Error())
+		return err
+	}
+	if g, ok := p.gain[event]; ok {
+		volume *= g
+	}
+	return playPCM(pcm, volume)
+}
+
+// soundCheckMsg reports the outcome of the startup sound check.
+type soundCheckMsg struct{ err error }
+
+// soundCheckCmd opens the audio device in the background at startup. When
+// there is no working output the player switches to the fallback channels,
+// so machines without audio, like containers and remote shells, still get
+// alerts without an error on every session.
+func soundCheckCmd(p *Player) tea.Cmd {
+	if p.Backend() != BackendAudio {
+		return nil
 	}
+	return func() tea.Msg {
+		err := p.Check()
+		if err != nil {
+			p.mu.Lock()
+			p.backend = backendFallback
+			p.mu.Unlock()
+		}
+		return soundCheckMsg{err: err}
+	}
+}
+
+// soundCmd plays the sound for event in the background, falling back to the
+// configured channels when audio is unavailable.
+func soundCmd(p *Player, event string) tea.Cmd {
+	return func() tea.Msg {
+		err := p.Play(event)
+		if err == nil {
+			return nil
+		}
+		msg := fallbackMsg{event: event, channel: p.fallback(event, true)}
+		if !errors.Is(err, errNoAudio) {
+			msg.err = err
+		}
+		return msg
+	}
+}
+
+// ringBell rings the terminal bell, used when audio is unavailable.
+func ringBell() {
+	fmt.Fprint(os.Stdout, "\a")
 }
@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresMigrations upgrade the schema of a shared Postgres history one
+// version at a time. Released migrations never change; new ones are
+// appended.
+var postgresMigrations = []string{
+	// 1: sessions of every user, with the whole session kept as JSON next
+	// to the columns queries filter and sum on.
+	`CREATE TABLE sessions (
+		id         BIGSERIAL PRIMARY KEY,
+		username   TEXT NOT NULL,
+		start_time TIMESTAMPTZ NOT NULL,
+		end_time   TIMESTAMPTZ NOT NULL,
+		phase      TEXT NOT NULL,
+		paused     INTEGER NOT NULL,
+		abandoned  BOOLEAN NOT NULL,
+		data       JSONB NOT NULL
+	);
+	CREATE INDEX sessions_username_start ON sessions (username, start_time);`,
+
+	// 2: changes of settings of every user.
+	`CREATE TABLE changes (
+		id       BIGSERIAL PRIMARY KEY,
+		username TEXT NOT NULL,
+		at       TIMESTAMPTZ NOT NULL,
+		data     JSONB NOT NULL
+	);
+	CREATE INDEX changes_username ON changes (username, id);`,
+}
+
+// postgresMigrationLock is the advisory lock key held while migrating, so
+// clients starting together don't apply a migration twice.
+const postgresMigrationLock = 0x6d616e7461 // "manta"
+
+// postgresStore keeps the history in a Postgres database shared by a team,
+// each user seeing only their own sessions.
+type postgresStore struct {
+	url  string
+	user string
+
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// newPostgresStore returns a store for the database at url, keeping the
+// sessions of name, or of the logged-in user when name is empty.
+func newPostgresStore(url, name string) (*postgresStore, error) {
+	if url == "" {
+		return nil, errors.New("postgres storage needs a url")
+	}
+	if name == "" {
+		u, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("postgres storage: no user set and %w", err)
+		}
+		name = u.Username
+	}
+	return &postgresStore{url: url, user: name}, nil
+}
+
+// open connects on first use and brings the schema up to date.
+func (p *postgresStore) open() (*sql.DB, error) {
+	p.once.Do(func() {
+		db, err := sql.Open("postgres", p.url)
+		if err != nil {
+			p.err = err
+			return
+		}
+		if err := migratePostgres(db); err != nil {
+			db.Close()
+			p.err = fmt.Errorf("migrate history database: %w", err)
+			return
+		}
+		p.db = db
+	})
+	return p.db, p.err
+}
+
+// migratePostgres applies the migrations the database hasn't seen yet, all
+// in one transaction.
+func migratePostgres(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, postgresMigrationLock); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+
+	var version int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(postgresMigrations) {
+		return fmt.Errorf("schema version %d is newer than this manta (%d), upgrade manta", version, len(postgresMigrations))
+	}
+	for i := version; i < len(postgresMigrations); i++ {
+		if _, err := tx.Exec(postgresMigrations[i]); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the connections to the database, and keeps it from being
+// opened when it wasn't yet.
+func (p *postgresStore) Close() error {
+	p.once.Do(func() { p.err = errStoreClosed })
+	if p.db == nil {
+		return nil
+	}
+	return p.db.Close()
+}
+
+// Append implements Store.
+func (p *postgresStore) Append(s Session) error {
+	db, err := p.open()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO sessions (username, start_time, end_time, phase, paused, abandoned, data) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		p.user, s.Start, s.End, s.Phase, s.Paused, s.Abandoned, string(data))
+	return err
+}
+
+// Query implements Store.
+func (p *postgresStore) Query(q Query) ([]Session, error) {
+	db, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	where, args := p.where(q)
+	rows, err := db.Query(`SELECT data FROM sessions`+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var s Session
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Aggregate implements Store.
+func (p *postgresStore) Aggregate(q Query) (Totals, error) {
+	db, err := p.open()
+	if err != nil {
+		return Totals{}, err
+	}
+	where, args := p.where(q)
+	var t Totals
+	var focus float64 // seconds
+	err = db.QueryRow(`SELECT
+		COUNT(*) FILTER (WHERE NOT abandoned),
+		COUNT(*) FILTER (WHERE abandoned),
+		COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) - paused), 0),
+		COALESCE(SUM(jsonb_array_length(data->'interruptions')), 0)
+		FROM sessions`+where, args...).Scan(&t.Finished, &t.Abandoned, &focus, &t.Interruptions)
+	t.Focus = time.Duration(focus * float64(time.Second))
+	return t, err
+}
+
+// Rewrite implements Store for the sessions of the store's user, in a
+// single transaction.
+func (p *postgresStore) Rewrite(edit func(Session) Session) error {
+	db, err := p.open()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, data FROM sessions WHERE username = $1`, p.user)
+	if err != nil {
+		return err
+	}
+	updates, err := rewriteRows(rows, edit)
+	if err != nil {
+		return err
+	}
+	for id, data := range updates {
+		if _, err := tx.Exec(`UPDATE sessions SET data = $1 WHERE id = $2`, data, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AppendChange implements Store.
+func (p *postgresStore) AppendChange(c Change) error {
+	db, err := p.open()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO changes (username, at, data) VALUES ($1, $2, $3)`, p.user, c.At, string(data))
+	return err
+}
+
+// Changes implements Store for the changes of the store's user.
+func (p *postgresStore) Changes() ([]Change, error) {
+	db, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT data FROM changes WHERE username = $1 ORDER BY id`, p.user)
+	if err != nil {
+		return nil, err
+	}
+	return scanChanges(rows)
+}
+
+// where renders q as an SQL WHERE clause limited to the store's user, and
+// its arguments.
+func (p *postgresStore) where(q Query) (string, []any) {
+	conds := []string{"username = $1"}
+	args := []any{p.user}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !q.From.IsZero() {
+		add("start_time >= $%d", q.From)
+	}
+	if !q.To.IsZero() {
+		add("start_time < $%d", q.To)
+	}
+	if q.Phase != "" {
+		add("phase = $%d", q.Phase)
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
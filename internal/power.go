@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PowerConfig saves power while the computer runs on its battery: the
+// display refreshes once a second, the progress bar stops gliding and
+// sounds are held back.
+type PowerConfig struct {
+	// Saver is when power is saved: "battery" whenever the computer runs
+	// on its battery, "low" only once the battery runs low. Empty never
+	// saves power.
+	Saver string `json:"saver"`
+
+	// Low is the charge, in percent, below which the battery runs low;
+	// 20 when unset.
+	Low int `json:"low"`
+
+	// Notify sends a notification when saving power starts.
+	Notify bool `json:"notify"`
+}
+
+// Values of PowerConfig.Saver.
+const (
+	SaverBattery = "battery"
+	SaverLow     = "low"
+)
+
+// defaultLowBattery is the charge below which the battery runs low.
+const defaultLowBattery = 20
+
+// validate checks that the saver is known and the low charge a percent.
+func (c PowerConfig) validate() error {
+	switch c.Saver {
+	case "", SaverBattery, SaverLow:
+	default:
+		return fmt.Errorf("power saver %q: want %q or %q", c.Saver, SaverBattery, SaverLow)
+	}
+	if c.Low < 0 || c.Low > 100 {
+		return fmt.Errorf("low battery %d%%: want 0 to 100", c.Low)
+	}
+	return nil
+}
+
+// saves reports whether power is saved with battery.
+func (c PowerConfig) saves(b battery) bool {
+	low := c.Low
+	if low == 0 {
+		low = defaultLowBattery
+	}
+	switch c.Saver {
+	case SaverBattery:
+		return b.discharging
+	case SaverLow:
+		return b.discharging && b.percent < low
+	}
+	return false
+}
+
+// battery is the state of the computer's battery.
+type battery struct {
+	discharging bool // the computer runs on the battery
+	percent     int  // charge left
+}
+
+// powerCheckInterval is how often the battery is checked.
+const powerCheckInterval = 30 * time.Second
+
+// powerMsg reports the state of the battery.
+type powerMsg struct {
+	battery battery
+	err     error
+}
+
+// powerCheckCmd checks the battery, right away when now is set and after
+// powerCheckInterval otherwise.
+func powerCheckCmd(now bool) tea.Cmd {
+	check := func(time.Time) tea.Msg {
+		b, err := batteryState()
+		return powerMsg{battery: b, err: err}
+	}
+	if now {
+		return func() tea.Msg { return check(time.Time{}) }
+	}
+	return after(powerCheckInterval, check)
+}
+
+// batteryState reads the state of the battery from sysfs on Linux and
+// from pmset on macOS. A computer without a battery never discharges.
+func batteryState() (battery, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return sysfsBattery("/sys/class/power_supply")
+
+	case "darwin":
+		out, err := exec.Command("pmset", "-g", "batt").Output()
+		if err != nil {
+			return battery{}, err
+		}
+		return parsePmset(string(out)), nil
+	}
+	return battery{}, errors.New("battery status is not available on this system")
+}
+
+// sysfsBattery reads the first battery under dir, such as
+// /sys/class/power_supply/BAT0.
+func sysfsBattery(dir string) (battery, error) {
+	supplies, err := os.ReadDir(dir)
+	if err != nil {
+		return battery{}, err
+	}
+	read := func(name, file string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, name, file))
+		return strings.TrimSpace(string(data))
+	}
+	for _, s := range supplies {
+		if read(s.Name(), "type") != "Battery" {
+			continue
+		}
+		percent, _ := strconv.Atoi(read(s.Name(), "capacity"))
+		return battery{discharging: read(s.Name(), "status") == "Discharging", percent: percent}, nil
+	}
+	return battery{}, nil
+}
+
+// pmsetCharge matches the charge in the output of pmset -g batt.
+var pmsetCharge = regexp.MustCompile(`(\d+)%`)
+
+// parsePmset reads the output of pmset -g batt, such as:
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=1234)	85%; discharging; 4:10 remaining present: true
+func parsePmset(out string) battery {
+	var b battery
+	b.discharging = strings.Contains(out, "'Battery Power'")
+	if match := pmsetCharge.FindStringSubmatch(out); match != nil {
+		b.percent, _ = strconv.Atoi(match[1])
+	}
+	return b
+}
+
+// updatePower saves power, or stops saving it, for the state of the
+// battery and checks it again.
+func (m model) updatePower(msg powerMsg) (model, tea.Cmd) {
+	if msg.err != nil {
+		m.status = tr("status.power_disabled", msg.err)
+		return m, nil
+	}
+	saving := m.cfg.Power.saves(msg.battery)
+	if saving == m.saving {
+		return m, powerCheckCmd(false)
+	}
+	m.saving = saving
+	m.player.SetSaving(saving)
+	if !saving {
+		m.status = tr("status.power_off")
+		return m, powerCheckCmd(false)
+	}
+	m.status = tr("status.power_on", msg.battery.percent)
+	cmds := []tea.Cmd{powerCheckCmd(false)}
+	if m.cfg.Power.Notify {
+		cmds = append(cmds, m.pending.track(notifyCmd(m.notifier, tr("notify.power"), tr("notify.power_body", msg.battery.percent))))
+	}
+	return m, tea.Batch(cmds...)
+}
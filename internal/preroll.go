@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// start begins p. A work session goes through the configured ritual and
+// pre-roll countdown first.
+func (m *model) start(p Preset) tea.Cmd {
+	m.ritualSkipped = nil
+	if p.Phase == WORKTIME {
+		m.nudge()
+		if len(m.cfg.Ritual) > 0 {
+			m.openRitual(p)
+			return nil
+		}
+	}
+	return m.countdown(p)
+}
+
+// countdown begins p after the pre-roll countdown when p is a work
+// session.
+func (m *model) countdown(p Preset) tea.Cmd {
+	if p.Phase != WORKTIME || m.cfg.Preroll <= 0 {
+		return m.begin(p)
+	}
+	m.preroll = &p
+	m.prerollEnd = m.now.Add(time.Duration(m.cfg.Preroll))
+	m.timer.Stop()
+	m.removeState()
+	return nil
+}
+
+// updatePreroll starts the pending session once the countdown is over.
+func (m model) updatePreroll() (model, tea.Cmd) {
+	if m.now.Before(m.prerollEnd) {
+		return m, m.tickCmd()
+	}
+	p := *m.preroll
+	m.preroll = nil
+	return m, tea.Batch(m.tickCmd(), m.begin(p), m.pending.track(soundCmd(m.player, SoundPreroll)))
+}
+
+// prerollKeys lets the start key skip the countdown and the reset key
+// cancel it.
+func (m model) prerollKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Start):
+		m.prerollEnd = m.now
+		return m.updatePreroll()
+
+	case key.Matches(msg, m.keys.Reset):
+		m.preroll = nil
+	}
+	return m, nil
+}
+
+// prerollView counts down to the start of the session.
+func (m model) prerollView() string {
+	left := int((m.prerollEnd.Sub(m.now) + time.Second - 1) / time.Second)
+	return "\n" +
+		m.theme.Title.Render(tr("preroll.title", m.preroll.Name, left)) + "\n\n" +
+		m.theme.Help.Render(tr("preroll.help",
+			m.keys.Start.Help().Key, m.keys.Reset.Help().Key)) + "\n"
+}
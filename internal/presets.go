@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Preset is a named session length offered in the chooser. Phase tells
+// whether it is focus time (work) or a break (rest), which decides the
+// sound, integrations and what follows it.
+type Preset struct {
+	Name     string   `json:"name"`
+	Duration Duration `json:"duration"`
+	Phase    string   `json:"phase"`
+}
+
+// defaultPresets are offered when the config defines none.
+var defaultPresets = []Preset{
+	{Name: WORKTIME, Duration: Duration(25 * time.Minute), Phase: WORKTIME},
+	{Name: RESTTIME, Duration: Duration(5 * time.Minute), Phase: RESTTIME},
+}
+
+// Duration is a time.Duration written as "25m" or "1h30m" in the config.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"25m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// newPresets validates the configured presets, falling back to the
+// defaults when there are none. A preset without a phase is work.
+func newPresets(cfg []Preset) ([]Preset, error) {
+	if len(cfg) == 0 {
+		return defaultPresets, nil
+	}
+
+	presets := make([]Preset, len(cfg))
+	seen := make(map[string]bool, len(cfg))
+	for i, p := range cfg {
+		if p.Name == "" {
+			return nil, fmt.Errorf("preset %d: needs a name", i+1)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("preset %q: defined twice", p.Name)
+		}
+		seen[p.Name] = true
+
+		if p.Duration <= 0 {
+			return nil, fmt.Errorf("preset %q: needs a positive duration", p.Name)
+		}
+		if p.Phase == "" {
+			p.Phase = WORKTIME
+		}
+		if p.Phase != WORKTIME && p.Phase != RESTTIME {
+			return nil, fmt.Errorf("preset %q: phase must be %q or %q", p.Name, WORKTIME, RESTTIME)
+		}
+		presets[i] = p
+	}
+	return presets, nil
+}
+
+// nextPreset returns the preset that follows p: the first break after
+// focus time and the first focus preset after a break.
+func nextPreset(presets []Preset, p Preset) Preset {
+	want := RESTTIME
+	if p.Phase == RESTTIME {
+		want = WORKTIME
+	}
+	for _, next := range presets {
+		if next.Phase == want {
+			return next
+		}
+	}
+	return p
+}
+
+// FindPreset returns the configured preset called name, or the first one
+// when name is empty.
+func FindPreset(cfg []Preset, name string) (Preset, error) {
+	presets, err := newPresets(cfg)
+	if err != nil {
+		return Preset{}, err
+	}
+	if name == "" {
+		return presets[0], nil
+	}
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("no preset named %q", name)
+}
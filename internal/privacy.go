@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// PrivacyConfig limits what the history, the daily note and the time
+// tracker keep, for data policies that don't allow free text or task
+// names at rest.
+type PrivacyConfig struct {
+	// DropNotes leaves session and interruption notes out of the history.
+	DropNotes bool `json:"drop_notes"`
+
+	// HashTasks keeps a hash of each task instead of its name, keyed with
+	// a secret kept in the data directory. Sessions on the same task
+	// still add up in reports and estimates.
+	HashTasks bool `json:"hash_tasks"`
+}
+
+// enabled reports whether anything is redacted.
+func (p PrivacyConfig) enabled() bool {
+	return p.DropNotes || p.HashTasks
+}
+
+// Prefixes of a hashed task name: taskHashPrefix for a keyed hash, and
+// legacyHashPrefix for the plain hashes kept before there was a key.
+const (
+	taskHashPrefix   = "hmac:"
+	legacyHashPrefix = "sha256:"
+)
+
+// taskKeyPath returns the location of the secret task names are hashed
+// with.
+func taskKeyPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "task.key"), nil
+}
+
+// loadTaskKey reads the secret task names are hashed with. When there is
+// none yet it creates one if create is set, else it returns nil.
+func loadTaskKey(create bool) ([]byte, error) {
+	path, err := taskKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if !create {
+		return nil, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return loadTaskKey(false) // made by another manta meanwhile
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return key, f.Close()
+}
+
+// hashTask returns the hash kept in place of task, keyed with key so it
+// can't be matched against a list of likely names. A task that is already
+// a hash is returned as is.
+func hashTask(key []byte, task string) string {
+	if task == "" || strings.HasPrefix(task, taskHashPrefix) || strings.HasPrefix(task, legacyHashPrefix) {
+		return task
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(task))
+	return taskHashPrefix + hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// redactor removes from sessions what a PrivacyConfig leaves out.
+type redactor struct {
+	privacy PrivacyConfig
+	key     []byte // the secret tasks are hashed with, when they are
+}
+
+// redactor returns the redactor of p, creating the secret tasks are
+// hashed with on first need.
+func (p PrivacyConfig) redactor() (redactor, error) {
+	r := redactor{privacy: p}
+	if p.HashTasks {
+		key, err := loadTaskKey(true)
+		if err != nil {
+			return redactor{}, fmt.Errorf("privacy: task key: %w", err)
+		}
+		r.key = key
+	}
+	return r, nil
+}
+
+// redact returns s with what r leaves out removed.
+func (r redactor) redact(s Session) Session {
+	if r.privacy.DropNotes {
+		s.Note = ""
+		if len(s.Interruptions) > 0 {
+			interruptions := make([]Interruption, len(s.Interruptions))
+			for i, in := range s.Interruptions {
+				in.Note = ""
+				interruptions[i] = in
+			}
+			s.Interruptions = interruptions
+		}
+	}
+	if r.privacy.HashTasks {
+		s.Task = hashTask(r.key, s.Task)
+	}
+	return s
+}
+
+// privateStore redacts sessions before they are saved.
+type privateStore struct {
+	Store
+	redactor redactor
+}
+
+// Append implements Store.
+func (p privateStore) Append(s Session) error {
+	return p.Store.Append(p.redactor.redact(s))
+}
+
+// Close closes the store it redacts for, when that needs closing.
+func (p privateStore) Close() error {
+	if c, ok := p.Store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// RedactHistory applies p to the sessions already in the history and
+// returns how many were changed.
+func RedactHistory(p PrivacyConfig) (int, error) {
+	if !p.enabled() {
+		return 0, errors.New("nothing to redact: set privacy in the config or say what to redact")
+	}
+	redactor, err := p.redactor()
+	if err != nil {
+		return 0, err
+	}
+	store, err := history()
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	err = store.Rewrite(func(s Session) Session {
+		r := redactor.redact(s)
+		if !reflect.DeepEqual(r, s) {
+			changed++
+		}
+		return r
+	})
+	return changed, err
+}
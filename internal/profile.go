@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LoadProfile reads the config with the named profile laid over it. An
+// empty name reads the config as it is.
+func LoadProfile(name string) (Config, error) {
+	cfg, err := LoadConfig()
+	if err != nil || name == "" {
+		return cfg, err
+	}
+	raw, ok := cfg.Profiles[name]
+	if !ok {
+		return cfg, fmt.Errorf("unknown profile %q", name)
+	}
+	// Settings the profile has replace those of the config; objects are
+	// merged key by key, so a profile can change a single sound.
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("profile %q: %w", name, err)
+	}
+	cfg.Profile = name
+	return cfg, nil
+}
+
+// profileNames returns the names of the configured profiles, sorted.
+func (c Config) profileNames() []string {
+	return slices.Sorted(maps.Keys(c.Profiles))
+}
+
+// openProfilePicker asks for the profile to switch to.
+func (m *model) openProfilePicker() tea.Cmd {
+	label := tr("prompt.profile", strings.Join(m.cfg.profileNames(), ", "))
+	return m.openPrompt(promptProfile, label, m.cfg.Profile)
+}
+
+// pickProfile quits the interface to have it started again with the
+// named profile, as its sounds, notifications and integrations all
+// change.
+func (m model) pickProfile(name string) (model, tea.Cmd) {
+	if name == m.cfg.Profile {
+		return m, nil
+	}
+	if _, ok := m.cfg.Profiles[name]; name != "" && !ok {
+		m.status = tr("status.unknown_profile", name)
+		return m, nil
+	}
+	m.switchTo = &name
+	return m, tea.Quit
+}
+
+// PickedProfile reports the profile picked in the interface that final
+// quit with, to start it again with.
+func PickedProfile(final tea.Model) (string, bool) {
+	m, ok := final.(model)
+	if !ok || m.switchTo == nil {
+		return "", false
+	}
+	return *m.switchTo, true
+}
+
+// profileView names the profile in use, if any, for the chooser heading.
+func (m model) profileView() string {
+	if m.cfg.Profile == "" {
+		return ""
+	}
+	return " (" + m.cfg.Profile + ")"
+}
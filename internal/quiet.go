@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// QuietConfig holds back sounds at set hours and during calls, leaving
+// the flash and notifications to tell a session ended.
+type QuietConfig struct {
+	// Hours is when sounds are held back every day, such as
+	// "22:00-08:00". Empty sets no hours.
+	Hours string `json:"hours"`
+
+	// Calls holds sounds back while the microphone or camera is in use.
+	Calls bool `json:"calls"`
+
+	// Apps holds sounds back while any of these processes runs, such as
+	// "CptHost", which runs during a Zoom meeting on macOS.
+	Apps []string `json:"apps"`
+}
+
+// detects reports whether quiet depends on what runs on the machine.
+func (c QuietConfig) detects() bool {
+	return c.Calls || len(c.Apps) > 0
+}
+
+// quietHours is a daily stretch of time, in minutes after midnight. It
+// runs past midnight when from is after to.
+type quietHours struct {
+	from, to int
+}
+
+// parseQuietHours reads hours such as "22:00-08:00".
+func parseQuietHours(s string) (*quietHours, error) {
+	if s == "" {
+		return nil, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("quiet hours %q: want HH:MM-HH:MM", s)
+	}
+	var q quietHours
+	for _, part := range []struct {
+		text string
+		into *int
+	}{{from, &q.from}, {to, &q.to}} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part.text))
+		if err != nil {
+			return nil, fmt.Errorf("quiet hours %q: want HH:MM-HH:MM", s)
+		}
+		*part.into = t.Hour()*60 + t.Minute()
+	}
+	if q.from == q.to {
+		return nil, fmt.Errorf("quiet hours %q: start and end are the same", s)
+	}
+	return &q, nil
+}
+
+// contains reports whether t falls within the hours.
+func (q *quietHours) contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if q.from < q.to {
+		return minute >= q.from && minute < q.to
+	}
+	return minute >= q.from || minute < q.to
+}
+
+// quietState tracks whether sounds are held back.
+type quietState struct {
+	hours    *quietHours
+	detected bool // a call or a listed app was found at the last check
+	due      bool // the hours or the detection ask for quiet
+	override bool // the user turned the quiet that is due on or off
+	on       bool // sounds are held back
+}
+
+// quietCheckInterval is how often calls and apps are looked for.
+const quietCheckInterval = 10 * time.Second
+
+// quietMsg reports whether a call or one of the listed apps was found.
+type quietMsg bool
+
+// quietCheckCmd looks for a call or one of apps after quietCheckInterval.
+func quietCheckCmd(cfg QuietConfig) tea.Cmd {
+	return after(quietCheckInterval, func(time.Time) tea.Msg {
+		if cfg.Calls {
+			if active, err := inCall(); err == nil && active {
+				return quietMsg(true)
+			}
+		}
+		return quietMsg(appRunning(cfg.Apps))
+	})
+}
+
+// appRunning reports whether a process named as one of apps runs.
+func appRunning(apps []string) bool {
+	for _, name := range apps {
+		if exec.Command("pgrep", "-x", name).Run() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// updateQuiet takes in what the last check found and checks again.
+func (m model) updateQuiet(msg quietMsg) (model, tea.Cmd) {
+	m.quiet.detected = bool(msg)
+	m.syncQuiet()
+	return m, quietCheckCmd(m.cfg.Quiet)
+}
+
+// syncQuiet holds sounds back while quiet is due, unless overridden. The
+// override lasts until quiet starts or ends on its own.
+func (m *model) syncQuiet() {
+	due := m.quiet.hours.contains(m.now) || m.quiet.detected
+	if due != m.quiet.due {
+		m.quiet.due = due
+		m.quiet.override = false
+	}
+	if on := due != m.quiet.override; on != m.quiet.on {
+		m.quiet.on = on
+		m.player.SetQuiet(on)
+	}
+}
+
+// toggleQuiet turns the quiet that is due off, or quiet on when it isn't
+// due.
+func (m *model) toggleQuiet() {
+	m.quiet.override = !m.quiet.override
+	m.syncQuiet()
+	if m.quiet.on {
+		m.status = tr("status.quiet")
+	} else {
+		m.status = tr("status.sounds_on")
+	}
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReadingConfig points at saved articles to suggest during breaks. Source
+// is an RSS or Atom feed URL or file, or a Pocket HTML export.
+type ReadingConfig struct {
+	Source string `json:"source"`
+}
+
+// article is a saved link that can be read during a break.
+type article struct {
+	Title string
+	Link  string
+}
+
+// articleMsg carries the article suggested for the current break.
+type articleMsg article
+
+// feed covers both RSS and Atom documents.
+type feed struct {
+	Items []struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Link  struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// pocketLink matches the links in a Pocket HTML export.
+var pocketLink = regexp.MustCompile(`<a href="([^"]+)"[^>]*>([^<]*)</a>`)
+
+// suggestCmd picks a random article from source in the background.
+func suggestCmd(source string) tea.Cmd {
+	if source == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		articles, err := loadArticles(source)
+		if err != nil {
+			return newError(CodeReading, "load the reading list", err)
+		}
+		if len(articles) == 0 {
+			return nil
+		}
+		return articleMsg(articles[rand.IntN(len(articles))])
+	}
+}
+
+// loadArticles reads every article from a feed URL or a local file.
+func loadArticles(source string) ([]article, error) {
+	data, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("<?xml")) && pocketLink.Match(data) {
+		var articles []article
+		for _, m := range pocketLink.FindAllSubmatch(data, -1) {
+			articles = append(articles, article{
+				Title: html.UnescapeString(string(m[2])),
+				Link:  html.UnescapeString(string(m[1])),
+			})
+		}
+		return articles, nil
+	}
+
+	var f feed
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", source, err)
+	}
+
+	var articles []article
+	for _, it := range f.Items {
+		articles = append(articles, article{Title: strings.TrimSpace(it.Title), Link: strings.TrimSpace(it.Link)})
+	}
+	for _, e := range f.Entries {
+		articles = append(articles, article{Title: strings.TrimSpace(e.Title), Link: e.Link.Href})
+	}
+	return articles, nil
+}
+
+func readSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(expandHome(source))
+	}
+
+	resp, err := httpClient.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
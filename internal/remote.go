@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// remoteActions maps the commands that drive the running session from
+// another shell, such as `manta pause`, to the key actions they perform.
+var remoteActions = map[string]string{
+	"pause":  "pause",
+	"resume": "pause",
+	"skip":   "skip",
+	"stop":   "reset",
+}
+
+// remoteWait is how long a command waits for the running manta to save the
+// state it left the session in.
+const remoteWait = 2 * time.Second
+
+// Remote runs command, one of pause, resume, skip and stop, on the session
+// of the running manta and writes the state it left the session in to w:
+// the status line, or the state as JSON with asJSON. Pausing a paused
+// session or resuming a running one does nothing.
+func Remote(w io.Writer, command string, asJSON bool) error {
+	action, ok := remoteActions[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q", command)
+	}
+	before, err := loadState()
+	if err != nil {
+		return fmt.Errorf("read state: %w", err)
+	}
+	if before.Phase == "" {
+		return errors.New("no session is running")
+	}
+
+	after := before
+	done := command == "pause" && before.Paused || command == "resume" && !before.Paused
+	if !done {
+		if err := sendControl(action); err != nil {
+			return err
+		}
+		if after, err = awaitState(before); err != nil {
+			return fmt.Errorf("read state: %w", err)
+		}
+	}
+	return writeRemote(w, after, asJSON)
+}
+
+// awaitState reads the state file until it no longer holds before, or
+// remoteWait has passed.
+func awaitState(before State) (State, error) {
+	deadline := time.Now().Add(remoteWait)
+	for {
+		s, err := loadState()
+		if err != nil || !s.UpdatedAt.Equal(before.UpdatedAt) || s.Phase != before.Phase || time.Now().After(deadline) {
+			return s, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeRemote writes s as the status line, or "stopped" once no session
+// runs, or as JSON shaped like the state of the HTTP API.
+func writeRemote(w io.Writer, s State, asJSON bool) error {
+	now := time.Now()
+	if asJSON {
+		res := apiState{}
+		if s.Phase != "" {
+			res = apiState{Running: true, Left: s.Left(now), State: &s}
+		}
+		return json.NewEncoder(w).Encode(res)
+	}
+	line, err := renderStatus(template.Must(template.New("status").Parse(DefaultStatusFormat)), s, now)
+	if err != nil {
+		return err
+	}
+	if line == "" {
+		line = "stopped"
+	}
+	_, err = fmt.Fprintln(w, line)
+	return err
+}
@@ -0,0 +1,361 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// FormatText renders a report for the terminal. PrintReport also accepts
+// FormatMarkdown.
+const FormatText = "text"
+
+// reportRow sums the work done on one day or task.
+type reportRow struct {
+	Name     string
+	Day      time.Time // midnight of the day, for day rows
+	Sessions int       // finished work sessions
+	Focus    time.Duration
+	Changes  []string // changes of settings made on the day, for day rows
+}
+
+// report summarizes the work done over a period.
+type report struct {
+	Title string
+	Days  []reportRow
+	Tasks []reportRow
+	Total reportRow
+	Best  *reportRow // the day with the most focus, nil when nothing was done
+
+	// LowConfidence counts finished sessions with long stretches
+	// without input.
+	LowConfidence int
+
+	// Interruptions counts the interruptions logged, of which Internal
+	// and External were tagged with their kind.
+	Interruptions, Internal, External int
+
+	// RitualSkips counts how often each step of the focus ritual was
+	// skipped.
+	RitualSkips map[string]int
+
+	// Estimates compares the estimated tasks worked on with their
+	// estimates over the whole history. Accuracy is how many times their
+	// estimates the finished ones took, over AccuracyTasks tasks.
+	Estimates     []estimateRow
+	Accuracy      float64
+	AccuracyTasks int
+
+	// BreakAway is how much of the BreakTime of the Breaks that were
+	// checked was spent away from the screen.
+	BreakAway, BreakTime time.Duration
+	Breaks               int
+
+	// Budgets compares the focus on tags with their weekly budgets.
+	Budgets []budgetRow
+}
+
+// focus returns the time s was worked, leaving out pauses.
+func (s Session) focus() time.Duration {
+	return s.End.Sub(s.Start) - time.Duration(s.Paused)*time.Second
+}
+
+// PrintReport writes a summary of the work done within [from, to): finished
+// sessions and focus time per day and per task, and against the weekly
+// budgets of tags.
+func PrintReport(w io.Writer, format, title string, from, to time.Time, budgets map[string]Duration) error {
+	r, err := newReport(title, from, to, budgets)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatText:
+		return reportText(w, r)
+	case FormatMarkdown:
+		return reportMarkdown(w, r)
+	default:
+		return fmt.Errorf("unknown format %q, want %s or %s", format, FormatText, FormatMarkdown)
+	}
+}
+
+// Week returns the Monday-to-Monday week containing t, in local time.
+func Week(t time.Time) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// newReport sums up the history within [from, to). Abandoned sessions count
+// toward focus time but not toward finished sessions.
+func newReport(title string, from, to time.Time, budgets map[string]Duration) (report, error) {
+	sessions, err := querySessions(Query{From: from, To: to, Phase: WORKTIME})
+	if err != nil {
+		return report{}, fmt.Errorf("read history: %w", err)
+	}
+
+	r := report{Title: title, Total: reportRow{Name: "Total"}}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		r.Days = append(r.Days, reportRow{Name: day.Format("Mon 02 Jan"), Day: day})
+	}
+
+	tasks := map[string]*reportRow{}
+	for _, s := range sessions {
+		done := 0
+		if !s.Abandoned {
+			done = 1
+			if s.LowConfidence {
+				r.LowConfidence++
+			}
+		}
+
+		internal, external := countInterruptions(s.Interruptions)
+		r.Interruptions += len(s.Interruptions)
+		r.Internal += internal
+		r.External += external
+		for _, step := range s.RitualSkipped {
+			if r.RitualSkips == nil {
+				r.RitualSkips = map[string]int{}
+			}
+			r.RitualSkips[step]++
+		}
+
+		start := s.Start.In(from.Location())
+		day := &r.Days[daysBetween(from, start)]
+		name := cmp.Or(s.Task, "(no task)")
+		task, ok := tasks[name]
+		if !ok {
+			task = &reportRow{Name: name}
+			tasks[name] = task
+		}
+		for _, row := range []*reportRow{day, task, &r.Total} {
+			row.Sessions += done
+			row.Focus += s.focus()
+		}
+	}
+
+	if len(budgets) > 0 {
+		r.Budgets = budgetRows(budgets, sessions, len(r.Days))
+	}
+
+	all, err := querySessions(Query{Phase: WORKTIME})
+	if err != nil {
+		return report{}, fmt.Errorf("read history: %w", err)
+	}
+	rows := estimateRows(all)
+	for _, row := range rows {
+		if _, ok := tasks[row.Task]; ok {
+			r.Estimates = append(r.Estimates, row)
+		}
+	}
+	asOf := to
+	if now := time.Now(); now.Before(to) {
+		asOf = now
+	}
+	r.Accuracy, r.AccuracyTasks = estimateAccuracy(rows, asOf)
+
+	breaks, err := querySessions(Query{From: from, To: to, Phase: RESTTIME})
+	if err != nil {
+		return report{}, fmt.Errorf("read history: %w", err)
+	}
+	for _, s := range breaks {
+		if s.Away != nil {
+			r.Breaks++
+			r.BreakAway += time.Duration(*s.Away) * time.Second
+			r.BreakTime += s.focus()
+		}
+	}
+
+	changes, err := loadChanges()
+	if err != nil {
+		return report{}, fmt.Errorf("read history: %w", err)
+	}
+	for _, c := range changeMarkers(changes, from, to) {
+		day := &r.Days[daysBetween(from, c.At.In(from.Location()))]
+		day.Changes = append(day.Changes, c.Text)
+	}
+
+	for i := range r.Days {
+		if r.Days[i].Focus > 0 && (r.Best == nil || r.Days[i].Focus > r.Best.Focus) {
+			r.Best = &r.Days[i]
+		}
+	}
+	for _, task := range tasks {
+		r.Tasks = append(r.Tasks, *task)
+	}
+	slices.SortFunc(r.Tasks, func(a, b reportRow) int {
+		return cmp.Or(cmp.Compare(b.Focus, a.Focus), cmp.Compare(a.Name, b.Name))
+	})
+	return r, nil
+}
+
+// daysBetween counts the calendar days from the day of from to the day of
+// t, which may differ from the number of 24 hour periods across DST changes.
+func daysBetween(from, t time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a) / (24 * time.Hour))
+}
+
+// reportText writes the report with a bar per day.
+func reportText(w io.Writer, r report) error {
+	fmt.Fprintf(w, "%s\n\n", r.Title)
+
+	var most time.Duration
+	for _, d := range r.Days {
+		most = max(most, d.Focus)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, d := range r.Days {
+		bar := ""
+		if most > 0 {
+			bar = strings.Repeat("█", int(20*d.Focus/most))
+		}
+		if len(d.Changes) > 0 {
+			bar = strings.TrimLeft(bar+"  ◆ "+strings.Join(d.Changes, "; "), " ")
+		}
+		fmt.Fprintf(tw, "%s\t%d 🍅\t%s\t%s\n", d.Name, d.Sessions, hoursView(d.Focus), bar)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%s, %s of focus\n", pomodorosView(r), hoursView(r.Total.Focus))
+	if r.Best != nil {
+		fmt.Fprintf(w, "Most productive day: %s (%s)\n", r.Best.Name, hoursView(r.Best.Focus))
+	}
+	if r.Interruptions > 0 {
+		fmt.Fprintf(w, "Logged %s\n", interruptionsText(r.Interruptions, r.Internal, r.External))
+	}
+	if len(r.RitualSkips) > 0 {
+		fmt.Fprintln(w, ritualSkipsText(r.RitualSkips))
+	}
+	if r.Breaks > 0 {
+		fmt.Fprintln(w, complianceText(r.BreakAway, r.BreakTime, r.Breaks))
+	}
+	if len(r.Budgets) > 0 {
+		fmt.Fprint(w, "\nBudgets\n")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, b := range r.Budgets {
+			fmt.Fprintf(tw, "  #%s\t%s/%s\t%.0f%%\n", b.Tag, hoursView(b.Used), hoursView(b.Budget), b.share())
+		}
+		tw.Flush()
+	}
+	if len(r.Tasks) == 0 {
+		return nil
+	}
+
+	fmt.Fprint(w, "\nTasks\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, t := range r.Tasks {
+		fmt.Fprintf(tw, "  %s\t%d 🍅\t%s\n", t.Name, t.Sessions, hoursView(t.Focus))
+	}
+	if err := tw.Flush(); err != nil || len(r.Estimates) == 0 {
+		return err
+	}
+
+	fmt.Fprint(w, "\nEstimates\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range r.Estimates {
+		fmt.Fprintf(tw, "  %s\t%d/%d 🍅\t%.0f%%\n", e.Task, e.Done, e.Estimate, e.ratio()*100)
+	}
+	tw.Flush()
+	if r.AccuracyTasks > 0 {
+		fmt.Fprintln(w, accuracyText(r))
+	}
+	return nil
+}
+
+// accuracyText sums up how well the finished tasks were estimated.
+func accuracyText(r report) string {
+	noun := "tasks"
+	if r.AccuracyTasks == 1 {
+		noun = "task"
+	}
+	return fmt.Sprintf("Finished tasks took %.1f× their estimates (%d %s)", r.Accuracy, r.AccuracyTasks, noun)
+}
+
+// reportMarkdown writes the report as markdown tables.
+func reportMarkdown(w io.Writer, r report) error {
+	fmt.Fprintf(w, "# %s\n\n", r.Title)
+	fmt.Fprintf(w, "%s, %s of focus", pomodorosView(r), hoursView(r.Total.Focus))
+	if r.Best != nil {
+		fmt.Fprintf(w, ", most on %s (%s)", r.Best.Name, hoursView(r.Best.Focus))
+	}
+	if r.Interruptions > 0 {
+		fmt.Fprintf(w, ", %s", interruptionsText(r.Interruptions, r.Internal, r.External))
+	}
+	if len(r.RitualSkips) > 0 {
+		fmt.Fprintf(w, ", %s", ritualSkipsText(r.RitualSkips))
+	}
+	fmt.Fprint(w, ".\n\n")
+	if r.Breaks > 0 {
+		fmt.Fprintf(w, "%s.\n\n", complianceText(r.BreakAway, r.BreakTime, r.Breaks))
+	}
+
+	fmt.Fprintln(w, "| Day | Pomodoros | Focus |")
+	fmt.Fprintln(w, "|---|---:|---:|")
+	var changes []string
+	for _, d := range r.Days {
+		fmt.Fprintf(w, "| %s | %d | %s |\n", d.Name, d.Sessions, hoursView(d.Focus))
+		for _, c := range d.Changes {
+			changes = append(changes, fmt.Sprintf("- %s: %s", d.Name, c))
+		}
+	}
+	if len(changes) > 0 {
+		fmt.Fprint(w, "\n## Settings changes\n\n"+strings.Join(changes, "\n")+"\n")
+	}
+	if len(r.Budgets) > 0 {
+		fmt.Fprint(w, "\n## Budgets\n\n")
+		fmt.Fprintln(w, "| Tag | Focus | Budget | Used |")
+		fmt.Fprintln(w, "|---|---:|---:|---:|")
+		for _, b := range r.Budgets {
+			fmt.Fprintf(w, "| #%s | %s | %s | %.0f%% |\n", b.Tag, hoursView(b.Used), hoursView(b.Budget), b.share())
+		}
+	}
+	if len(r.Tasks) == 0 {
+		return nil
+	}
+
+	fmt.Fprint(w, "\n## Tasks\n\n")
+	fmt.Fprintln(w, "| Task | Pomodoros | Focus |")
+	fmt.Fprintln(w, "|---|---:|---:|")
+	for _, t := range r.Tasks {
+		if _, err := fmt.Fprintf(w, "| %s | %d | %s |\n", t.Name, t.Sessions, hoursView(t.Focus)); err != nil {
+			return err
+		}
+	}
+	if len(r.Estimates) == 0 {
+		return nil
+	}
+
+	fmt.Fprint(w, "\n## Estimates\n\n")
+	fmt.Fprintln(w, "| Task | Done | Estimate | Share |")
+	fmt.Fprintln(w, "|---|---:|---:|---:|")
+	for _, e := range r.Estimates {
+		fmt.Fprintf(w, "| %s | %d | %d | %.0f%% |\n", e.Task, e.Done, e.Estimate, e.ratio()*100)
+	}
+	if r.AccuracyTasks > 0 {
+		_, err := fmt.Fprintf(w, "\n%s.\n", accuracyText(r))
+		return err
+	}
+	return nil
+}
+
+// pomodorosView counts the finished sessions, noting how many of them had
+// long stretches without input.
+func pomodorosView(r report) string {
+	s := fmt.Sprintf("%d pomodoros", r.Total.Sessions)
+	if r.LowConfidence > 0 {
+		s += fmt.Sprintf(" (%d low confidence)", r.LowConfidence)
+	}
+	return s
+}
+
+// hoursView formats a duration as hours and minutes, like 2h05m.
+func hoursView(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,313 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+// resumePath returns where a session left running at quit is kept.
+func resumePath() (string, error) {
+	path, err := statePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "resume.json"), nil
+}
+
+// keepForResume moves the state file aside when manta quits during a
+// session, so the next start can offer to resume it without `manta
+// status` reporting a timer that no longer runs.
+func keepForResume() error {
+	from, err := statePath()
+	if err != nil {
+		return err
+	}
+	to, err := resumePath()
+	if err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// loadInterrupted returns a session that was running when manta last quit
+// or crashed, and forgets it. A state file still in use by another manta
+// is left alone.
+func loadInterrupted() (*State, error) {
+	paths := make([]string, 0, 2)
+	if path, err := resumePath(); err == nil {
+		paths = append(paths, path)
+	}
+	if path, err := statePath(); err == nil && !otherInstance() {
+		// Left behind by a crash.
+		paths = append(paths, path)
+	}
+
+	var found *State
+	var errs []error
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil && found == nil {
+			var s State
+			if err = json.Unmarshal(data, &s); err == nil && s.Phase != "" && !s.Start.IsZero() {
+				found = &s
+			}
+		}
+		errs = append(errs, err)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return found, errors.Join(errs...)
+}
+
+// otherInstance reports whether another manta is serving the control
+// socket.
+func otherInstance() bool {
+	path, err := controlPath()
+	if err != nil {
+		return false
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// heartbeatEvery is how often the state file of a running session is
+// rewritten, so that after a crash or a power cut its UpdatedAt tells when
+// manta was last running.
+const heartbeatEvery = time.Minute
+
+// heartbeat rewrites the state file when it is older than heartbeatEvery.
+func (m *model) heartbeat() {
+	if m.timer.Running() && m.now.Sub(m.stateAt) >= heartbeatEvery {
+		m.writeState()
+	}
+}
+
+var darwinBootTime = regexp.MustCompile(`sec = (\d+)`)
+
+// bootTime returns when the machine last started: from /proc/stat on
+// Linux and the kern.boottime sysctl on macOS.
+func bootTime() (time.Time, error) {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/stat")
+		if err != nil {
+			return time.Time{}, err
+		}
+		for line := range strings.Lines(string(data)) {
+			if sec, ok := strings.CutPrefix(line, "btime "); ok {
+				n, err := strconv.ParseInt(strings.TrimSpace(sec), 10, 64)
+				if err != nil {
+					return time.Time{}, fmt.Errorf("/proc/stat: %w", err)
+				}
+				return time.Unix(n, 0), nil
+			}
+		}
+		return time.Time{}, errors.New("/proc/stat: no boot time")
+
+	case "darwin":
+		out, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+		if err != nil {
+			return time.Time{}, err
+		}
+		// The value looks like "{ sec = 1760000000, usec = 0 } Thu Oct ...".
+		sec := darwinBootTime.FindSubmatch(out)
+		if sec == nil {
+			return time.Time{}, fmt.Errorf("kern.boottime: unexpected %q", out)
+		}
+		n, _ := strconv.ParseInt(string(sec[1]), 10, 64)
+		return time.Unix(n, 0), nil
+	}
+	return time.Time{}, errors.New("boot time is not available on this system")
+}
+
+// shutDownSince reports whether the machine restarted after the state s
+// was last written, which means it shut down mid-session. Where the boot
+// time is unknown, it is taken that it didn't.
+func shutDownSince(s State) bool {
+	boot, err := bootTime()
+	return err == nil && boot.After(s.UpdatedAt)
+}
+
+// restore picks up an interrupted session where it left off. Time spent
+// with manta closed counts as if it had kept running, unless the session
+// was paused or the machine was off; a session cut off by a shutdown
+// continues with the time it had left then.
+func (m *model) restore(s State) {
+	p := Preset{Name: s.Preset, Phase: s.Phase}
+	for _, preset := range m.presets {
+		if preset.Name == s.Preset && preset.Phase == s.Phase {
+			p = preset
+		}
+	}
+	m.preset = p
+	m.task = s.Task
+	m.tags = s.Tags
+	m.interruptions = s.Interruptions
+	planned := time.Duration(s.Planned) * time.Second
+	end := s.EndTime
+	if s.Paused {
+		// The end had the session been resumed right away.
+		end = s.PausedAt.Add(time.Duration(s.Remaining) * time.Second)
+	}
+	m.timer = timer.Restore(s.Start, planned, end)
+	if s.Stopwatch {
+		m.timer = timer.RestoreStopwatch(s.Start, end)
+	}
+	if s.Paused {
+		m.timer.Pause(s.PausedAt)
+	} else if m.shutDown && m.timer.Pause(s.UpdatedAt) {
+		m.timer.Resume(m.now)
+	}
+	m.timer.WarnBefore(m.now, warnings(m.cfg.Notify, p)...)
+	if s.Overtime {
+		m.timer.StartOvertime()
+	}
+}
+
+// offerResume handles a session interrupted by a quit, a crash or a
+// shutdown. One that is still running, or was cut off by a shutdown, is
+// offered for resuming, completing or abandoning; one that ended while
+// manta was closed is saved as finished.
+func (m model) offerResume(s State) model {
+	if shutDownSince(s) {
+		m.interrupted = &s
+		m.shutDown = true
+		return m
+	}
+
+	restored := m
+	restored.restore(s)
+	if restored.timer.Remaining(restored.now) > 0 || s.Paused || s.Overtime || s.Stopwatch {
+		m.interrupted = &s
+		return m
+	}
+
+	session := restored.session()
+	if err := appendSession(session); err != nil {
+		m.fail(newError(CodeHistoryWrite, "save the session", err))
+		return m
+	}
+	shared := m.privacy.redact(session)
+	if err := m.dailyNote.logFinished(shared); err != nil {
+		m.fail(newError(CodeDailyNote, "write the daily note", err))
+	}
+	// Sent along with the queue once the program runs.
+	if err := m.tracking.later(shared); err != nil {
+		m.fail(newError(CodeTimeTracking, "queue the time entry", err))
+	}
+	if dayOf(session.End) == dayOf(m.now) {
+		if session.Phase == WORKTIME {
+			m.tally().done++
+		} else {
+			m.countRest(false)
+		}
+	}
+	m.status = tr("status.ended_closed", s.Preset, session.End.Local().Format("15:04"))
+	return m
+}
+
+// resumeKeys answers what to do with the interrupted session: resume it,
+// save it as finished at the time it was cut off, or save it as
+// abandoned.
+func (m model) resumeKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Yes):
+		m.restore(*m.interrupted)
+		m.interrupted = nil
+		m.writeState()
+		return m, tea.Batch(m.emit(EventResume), velocityCmd(m.cfg.Estimates, m.task, 0, m.now))
+
+	case key.Matches(msg, m.keys.Complete):
+		s := m.interruptedSession()
+		if dayOf(s.End) == dayOf(m.now) {
+			if s.Phase == WORKTIME {
+				m.tally().done++
+			} else {
+				m.countRest(false)
+			}
+		}
+		m.interrupted = nil
+		m.shutDown = false
+		return m, m.record(s)
+
+	case key.Matches(msg, m.keys.No):
+		s := m.interruptedSession()
+		s.Abandoned = !m.interrupted.Stopwatch
+		m.interrupted = nil
+		m.shutDown = false
+		return m, m.record(s)
+	}
+	return m, nil
+}
+
+// interruptedSession returns the interrupted session as it was when it
+// was cut off.
+func (m model) interruptedSession() Session {
+	restored := m
+	restored.now = m.interrupted.UpdatedAt
+	restored.restore(*m.interrupted)
+	return restored.session()
+}
+
+// resumeView asks what to do with the interrupted session.
+func (m model) resumeView() string {
+	if m.interrupted == nil {
+		return ""
+	}
+	if m.shutDown {
+		return m.theme.Status.Render(tr("resume.shutdown",
+			shutDownText(*m.interrupted), m.keys.Yes.Help().Key, m.keys.Complete.Help().Key, m.keys.No.Help().Key)) + "\n\n"
+	}
+	restored := m
+	restored.restore(*m.interrupted)
+	left := restored.timer.SecondsLeft(restored.now)
+	state := tr("resume.left", left/60, left%60)
+	switch {
+	case m.interrupted.Overtime:
+		state = tr("resume.overtime")
+	case m.interrupted.Stopwatch:
+		state = tr("resume.counted", clockText(restored.timer.Elapsed(restored.now)))
+	}
+	return m.theme.Status.Render(tr("resume.ask",
+		m.interrupted.Preset, state, m.keys.Yes.Help().Key, m.keys.No.Help().Key, m.keys.Complete.Help().Key)) + "\n\n"
+}
+
+// shutDownText tells what a shutdown cut off of the session s, such as
+// `Work session "API draft" had 9m left at shutdown`.
+func shutDownText(s State) string {
+	what := tr("shutdown.session", s.Preset)
+	if s.Task != "" {
+		what = tr("shutdown.task", s.Preset, s.Task)
+	}
+	left := time.Duration(s.Left(s.UpdatedAt)) * time.Second
+	switch {
+	case s.Stopwatch:
+		return tr("shutdown.stopwatch", what, untilView(-left))
+	case s.Overtime || left <= 0:
+		return tr("shutdown.ended", what, untilView(-left))
+	}
+	return tr("shutdown.left", what, untilView(left))
+}
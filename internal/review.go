@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reviewQuestions are asked after going through last week's stats.
+var reviewQuestions = []string{
+	"What went well?",
+	"What got in the way?",
+	"What will you focus on next week?",
+}
+
+// reviewModel walks through a weekly review: last week's stats first, then
+// one question per screen.
+type reviewModel struct {
+	theme   Theme
+	from    time.Time // the Monday the week starts on
+	report  report
+	stats   string // the report rendered for the terminal
+	step    int    // 0 shows the stats, then one step per question
+	answers []string
+	input   textinput.Model
+	path    string // where the review was saved
+	err     error
+}
+
+// RunReview runs the weekly review of last week and saves it as a markdown
+// file in manta's data directory.
+func RunReview(cfg Config) error {
+	theme, err := newTheme(cfg.Theme)
+	if err != nil {
+		return err
+	}
+
+	thisWeek, _ := Week(time.Now())
+	from := thisWeek.AddDate(0, 0, -7)
+	r, err := newReport("Week of "+from.Format("2 January 2006"), from, thisWeek, nil)
+	if err != nil {
+		return err
+	}
+	var stats strings.Builder
+	if err := reportText(&stats, r); err != nil {
+		return err
+	}
+
+	final, err := tea.NewProgram(reviewModel{
+		theme:  theme,
+		from:   from,
+		report: r,
+		stats:  stats.String(),
+		input:  textinput.New(),
+	}).Run()
+	if err != nil {
+		return err
+	}
+	m := final.(reviewModel)
+	if m.err != nil {
+		return m.err
+	}
+	if m.path != "" {
+		fmt.Println("Saved the review to", m.path)
+	}
+	return nil
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return m, tea.Quit
+
+	case tea.KeyEnter:
+		if m.step > 0 {
+			m.answers = append(m.answers, strings.TrimSpace(m.input.Value()))
+			m.input.Reset()
+		}
+		m.step++
+		if m.step <= len(reviewQuestions) {
+			return m, m.input.Focus()
+		}
+		m.path, m.err = saveReview(m.from, m.report, m.answers)
+		return m, tea.Quit
+	}
+
+	if m.step == 0 {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m reviewModel) View() string {
+	if m.step > len(reviewQuestions) {
+		return ""
+	}
+
+	title := m.theme.Title.Render("Weekly review") + "\n\n"
+	if m.step == 0 {
+		return title + m.stats + "\n" +
+			m.theme.Help.Render("enter continue • esc quit") + "\n"
+	}
+
+	return title +
+		m.theme.Help.Render(fmt.Sprintf("%d/%d", m.step, len(reviewQuestions))) + " " +
+		reviewQuestions[m.step-1] + "\n\n" +
+		m.input.View() + "\n\n" +
+		m.theme.Help.Render("enter next • esc quit without saving") + "\n"
+}
+
+// saveReview writes the stats of the week starting on from and the answers
+// to reviews/YYYY-Www.md in the data directory and returns its path.
+func saveReview(from time.Time, r report, answers []string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "reviews")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := reportMarkdown(&b, r); err != nil {
+		return "", err
+	}
+	b.WriteString("\n## Reflections\n")
+	for i, q := range reviewQuestions {
+		fmt.Fprintf(&b, "\n### %s\n\n%s\n", q, answers[i])
+	}
+
+	year, week := from.ISOWeek()
+	path := filepath.Join(dir, fmt.Sprintf("%d-W%02d.md", year, week))
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ritualStep is where a step of the focus ritual stands.
+type ritualStep int
+
+const (
+	stepPending ritualStep = iota
+	stepDone
+	stepSkipped
+)
+
+// ritual is the checklist gone through before a work session starts.
+type ritual struct {
+	preset Preset       // the session that starts once it is through
+	steps  []ritualStep // for each item of the configured ritual
+	cursor int
+}
+
+// openRitual holds p back until the configured ritual is through.
+func (m *model) openRitual(p Preset) {
+	m.ritual = &ritual{preset: p, steps: make([]ritualStep, len(m.cfg.Ritual))}
+	m.timer.Stop()
+	m.removeState()
+}
+
+// ritualKeys ticks off or skips the selected step, moves between steps or
+// cancels the session with the reset key. The session starts when no
+// step is left.
+func (m model) ritualKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	r := m.ritual
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		r.cursor = (r.cursor + len(r.steps) - 1) % len(r.steps)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		r.cursor = (r.cursor + 1) % len(r.steps)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Start, m.keys.Pause):
+		r.steps[r.cursor] = stepDone
+
+	case key.Matches(msg, m.keys.Skip):
+		r.steps[r.cursor] = stepSkipped
+
+	case key.Matches(msg, m.keys.Reset):
+		m.ritual = nil
+		return m, nil
+
+	default:
+		return m, nil
+	}
+
+	next := slices.Index(r.steps, stepPending)
+	if next >= 0 {
+		r.cursor = next
+		return m, nil
+	}
+
+	m.ritual = nil
+	m.ritualSkipped = nil
+	for i, step := range r.steps {
+		if step == stepSkipped {
+			m.ritualSkipped = append(m.ritualSkipped, m.cfg.Ritual[i])
+		}
+	}
+	return m, m.countdown(r.preset)
+}
+
+// ritualView lists the steps left before the session starts.
+func (m model) ritualView() string {
+	r := m.ritual
+	var s strings.Builder
+	s.WriteString("\n" + m.theme.Title.Render(tr("ritual.title", r.preset.Name)) + "\n\n")
+	for i, item := range m.cfg.Ritual {
+		cursor := "  "
+		if i == r.cursor {
+			cursor = "> "
+		}
+		mark := "[ ]"
+		switch r.steps[i] {
+		case stepDone:
+			mark = "[x]"
+		case stepSkipped:
+			mark = "[-]"
+		}
+		line := cursor + mark + " " + item
+		if i == r.cursor {
+			line = m.theme.Selected.Render(line)
+		}
+		s.WriteString(line + "\n")
+	}
+	s.WriteString("\n" + m.theme.Help.Render(tr("ritual.help",
+		m.keys.Start.Help().Key, m.keys.Skip.Help().Key, m.keys.Reset.Help().Key)) + "\n")
+	return s.String()
+}
+
+// ritualSkipsText sums up the skipped ritual steps, the most skipped
+// first.
+func ritualSkipsText(skips map[string]int) string {
+	steps := slices.SortedFunc(maps.Keys(skips), func(a, b string) int {
+		return cmp.Or(cmp.Compare(skips[b], skips[a]), cmp.Compare(a, b))
+	})
+	total := 0
+	counts := make([]string, len(steps))
+	for i, step := range steps {
+		total += skips[step]
+		counts[i] = fmt.Sprintf("%s %d", step, skips[step])
+	}
+	id := "ritual.skipped"
+	if total == 1 {
+		id = "ritual.skipped_one"
+	}
+	return tr(id, total, strings.Join(counts, ", "))
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ParseClock returns the next time the wall clock shows s, given as HH:MM,
+// after now: later today, or tomorrow when that time has passed.
+func ParseClock(s string, now time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation("15:04", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+// WithSchedule makes the model start the named preset at the given time.
+// An empty name picks the first preset.
+func (m model) WithSchedule(name string, at time.Time) (model, error) {
+	p := m.presets[0]
+	if name != "" {
+		found := false
+		for _, preset := range m.presets {
+			if preset.Name == name {
+				p, found = preset, true
+				break
+			}
+		}
+		if !found {
+			return m, fmt.Errorf("no preset named %q", name)
+		}
+	}
+	m.schedule(p, at)
+	return m, nil
+}
+
+// schedule sets p to start at the given time, dropping any session waiting
+// to start.
+func (m *model) schedule(p Preset, at time.Time) {
+	m.scheduled = &p
+	m.scheduledAt = wallClock(at)
+	m.timer.Stop()
+	m.ritual = nil
+	m.preroll = nil
+}
+
+// updateSchedule starts the scheduled session once its time has come and
+// sends a reminder that it began.
+func (m model) updateSchedule() (model, tea.Cmd) {
+	if m.now.Before(m.scheduledAt) {
+		return m, m.tickCmd()
+	}
+	p := *m.scheduled
+	m.scheduled = nil
+	start := m.start(p)
+	notify := m.pending.track(notifyCmd(m.notifier, tr("notify.started", p.Name), tr("notify.scheduled", p.Name, m.scheduledAt.Format("15:04"))))
+	return m, tea.Batch(m.tickCmd(), start, notify)
+}
+
+// scheduleKeys lets the start key begin the scheduled session right away
+// and the reset key cancel it.
+func (m model) scheduleKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Start):
+		p := *m.scheduled
+		m.scheduled = nil
+		return m, m.start(p)
+
+	case key.Matches(msg, m.keys.Reset):
+		m.scheduled = nil
+		m.status = tr("status.schedule_cancelled")
+	}
+	return m, nil
+}
+
+// scheduleView counts down to the scheduled session.
+func (m model) scheduleView() string {
+	return "\n" +
+		m.theme.Title.Render(tr("schedule.title", m.scheduled.Name, untilView(m.scheduledAt.Sub(m.now)))) + "\n\n" +
+		m.theme.Help.Render(tr("schedule.help",
+			m.scheduledAt.Format("15:04"), m.keys.Start.Help().Key, m.keys.Reset.Help().Key)) + "\n\n" +
+		m.inputView("") +
+		m.statusView("")
+}
+
+// untilView formats the wait before a scheduled session, rounded up so it
+// never reads zero before the session starts.
+func untilView(d time.Duration) string {
+	switch {
+	case d >= time.Hour:
+		d = (d + time.Minute - 1).Truncate(time.Minute)
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int((d+time.Minute-1)/time.Minute))
+	default:
+		return fmt.Sprintf("%ds", int((d+time.Second-1)/time.Second))
+	}
+}
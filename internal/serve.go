@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ihorbryk/manta/assets"
+)
+
+// DefaultListen is the address manta serve listens on unless told
+// otherwise, reachable from this machine only.
+const DefaultListen = "localhost:7770"
+
+// apiState is the running session as returned by GET /state.
+type apiState struct {
+	Running bool `json:"running"`
+	Left    int  `json:"left,omitempty"` // seconds
+	*State
+}
+
+// Serve runs the HTTP API on addr until ctx is done. Like the tray it runs
+// alongside the TUI, reading the state file and driving the timer through
+// the control socket:
+//
+//	GET  /                 a control page for phones
+//	GET  /state            the running session
+//	POST /actions/{action} a key action such as pause, skip or reset
+//	GET  /events           session events as server-sent events
+//
+// When token is set every request needs it as a bearer token, and browser
+// pages on other sites may call the API; without one, only the control
+// page may, so another web page can't drive the timer. When login, a
+// user:password pair, is set, requests may log in with basic auth
+// instead, which the browser asks for when the page is opened.
+func Serve(ctx context.Context, addr, token, login string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", serveRemote)
+	mux.HandleFunc("GET /state", serveState)
+	mux.HandleFunc("POST /actions/{action}", serveAction)
+	mux.HandleFunc("GET /events", serveEvents)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           authorize(token, login, mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorize checks the bearer token or basic auth login of requests, when
+// either is set, and answers CORS preflight requests for the token.
+// Requests from other sites' pages need the token.
+func authorize(token, login string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r, login != "") {
+			if token == "" {
+				httpError(w, http.StatusForbidden, "browser requests need manta serve -token")
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		if token == "" && login == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" &&
+			subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if user, password, ok := r.BasicAuth(); ok && login != "" &&
+			subtle.ConstantTimeCompare([]byte(user+":"+password), []byte(login)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		msg := "missing or wrong token"
+		if login != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="manta", charset="UTF-8"`)
+			msg = "missing or wrong login"
+		}
+		httpError(w, http.StatusUnauthorized, msg)
+	})
+}
+
+// sameOrigin reports whether r comes from a page served here, such as the
+// control page, or not from a browser page at all. DNS rebinding can give
+// any site the origin of a domain name pointing here, so without a login,
+// which browsers keep per site, only pages opened by IP address or as
+// localhost count.
+func sameOrigin(r *http.Request, login bool) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return false
+	}
+	host := u.Hostname()
+	return login || host == "localhost" || net.ParseIP(host) != nil
+}
+
+// serveRemote serves the control page, with the time left and big buttons
+// to pause, skip, start and stop sessions from a phone.
+func serveRemote(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(assets.RemotePage)
+}
+
+// serveState returns the running session, if any.
+func serveState(w http.ResponseWriter, r *http.Request) {
+	s, err := loadState()
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	res := apiState{}
+	if s.Phase != "" {
+		res = apiState{Running: true, Left: s.Left(time.Now()), State: &s}
+	}
+	writeJSON(w, res)
+}
+
+// serveAction performs a key action in the running manta.
+func serveAction(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("action")
+	keys := defaultKeys()
+	if _, ok := keys.actions()[action]; !ok {
+		httpError(w, http.StatusNotFound, fmt.Sprintf("unknown action %q", action))
+		return
+	}
+	if err := sendControl(action); err != nil {
+		httpError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+// serveEvents streams the session events of the running manta until the
+// client goes away or manta quits.
+func serveEvents(w http.ResponseWriter, r *http.Request) {
+	path, err := controlPath()
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		httpError(w, http.StatusServiceUnavailable, "manta is not running")
+		return
+	}
+	defer conn.Close()
+	go func() {
+		<-r.Context().Done()
+		conn.Close()
+	}()
+
+	fmt.Fprintln(conn, "events")
+	lines := bufio.NewScanner(conn)
+	if !lines.Scan() || lines.Text() != "ok" {
+		httpError(w, http.StatusServiceUnavailable, "manta doesn't stream events")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for lines.Scan() {
+		var ev Event
+		if json.Unmarshal(lines.Bytes(), &ev) != nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, lines.Bytes()); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func httpError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shells manta shell-init has a snippet for.
+const (
+	ShellZsh  = "zsh"
+	ShellBash = "bash"
+	ShellFish = "fish"
+)
+
+// shellSnippets show the running timer in the prompt and define aliases.
+// The status is read once per prompt from `manta status`, which only reads
+// the state file. @FORMAT@ is replaced with the status format.
+var shellSnippets = map[string]string{
+	ShellZsh: `# manta: add to ~/.zshrc with  eval "$(manta shell-init zsh)"
+_manta_status() { MANTA_STATUS="$(manta status -format '@FORMAT@' 2>/dev/null)"; }
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd _manta_status
+setopt prompt_subst
+[[ $RPROMPT == *MANTA_STATUS* ]] || RPROMPT='${MANTA_STATUS}'"${RPROMPT:+ $RPROMPT}"
+`,
+	ShellBash: `# manta: add to ~/.bashrc with  eval "$(manta shell-init bash)"
+_manta_status() { MANTA_STATUS="$(manta status -format '@FORMAT@' 2>/dev/null)"; }
+[[ ";$PROMPT_COMMAND;" == *";_manta_status;"* ]] || PROMPT_COMMAND="_manta_status${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+[[ $PS1 == *MANTA_STATUS* ]] || PS1='${MANTA_STATUS:+$MANTA_STATUS }'"$PS1"
+`,
+	ShellFish: `# manta: add to ~/.config/fish/config.fish with  manta shell-init fish | source
+function _manta_status --on-event fish_prompt
+    set -g MANTA_STATUS (manta status -format '@FORMAT@' 2>/dev/null)
+end
+if not functions -q fish_right_prompt
+    function fish_right_prompt
+        echo -n $MANTA_STATUS
+    end
+end
+`,
+}
+
+// shellAliases are defined by every snippet.
+var shellAliases = [][2]string{
+	{"mt", "manta"},
+	{"mts", "manta status"},
+	{"mtr", "manta report"},
+	{"mtw", "manta run --no-ui"},
+}
+
+// ShellInit writes the snippet for shell, to be evaluated from its startup
+// file.
+func ShellInit(w io.Writer, shell string) error {
+	snippet, ok := shellSnippets[shell]
+	if !ok {
+		return fmt.Errorf("unknown shell %q, want %s, %s or %s", shell, ShellZsh, ShellBash, ShellFish)
+	}
+
+	var s strings.Builder
+	s.WriteString(strings.ReplaceAll(snippet, "@FORMAT@", compactStatusFormat))
+	for _, a := range shellAliases {
+		fmt.Fprintf(&s, "alias %s='%s'\n", a[0], a[1])
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shutdownTimeout bounds how long Shutdown waits for background work.
+const shutdownTimeout = 5 * time.Second
+
+// tracker counts side effects still running in the background, so shutdown
+// can wait for them instead of cutting them off.
+type tracker struct {
+	wg sync.WaitGroup
+}
+
+// track wraps cmd so the tracker knows when it finishes.
+func (t *tracker) track(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	t.wg.Add(1)
+	return func() tea.Msg {
+		defer t.wg.Done()
+		return cmd()
+	}
+}
+
+// wait blocks until every tracked command has finished or timeout passes.
+// It reports whether everything finished.
+func (t *tracker) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Shutdown finishes the side effects of the final model once the program
+// has exited, whether through the quit key or SIGTERM. It lets hooks and
+// notifications in flight complete, saves a session left in the end menu,
+// delivers a quit event for a session that is still running so
+// integrations can restore their state, restores the tmux window name and
+// stops the ambient track.
+// The state file of a running session is kept aside so the next start can
+// offer to resume it; otherwise it is removed.
+func Shutdown(final tea.Model) {
+	m, ok := final.(model)
+	if !ok {
+		return
+	}
+
+	m.pending.wait(shutdownTimeout)
+
+	if m.ended != nil {
+		reportShutdown(CodeHistoryWrite, "save the session", appendSession(*m.ended))
+	}
+	if m.snoozing != nil {
+		reportShutdown(CodeHistoryWrite, "save the session", appendSession(m.snoozing.session(m.wallNow())))
+	}
+	m.summarizeOnQuit()
+
+	m.tmux.close()
+	m.ambient.close()
+
+	if m.timer.Running() {
+		m.now = m.wallNow()
+		reportShutdown(CodeEvent, "deliver the quit event", m.events.dispatch(m.event(EventQuit)))
+		// Saved as of now, so a session cut off by a shutdown is
+		// resumed with the time it had left then.
+		reportShutdown(CodeState, "keep the session for resuming", errors.Join(saveState(m.state()), keepForResume()))
+		return
+	}
+	reportShutdown(CodeState, "remove the state file", clearState())
+}
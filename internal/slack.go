@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlackConfig sets the user's Slack status during work sessions.
+type SlackConfig struct {
+	// Token is a user token (xoxp-...) with the users.profile:write and,
+	// for DND, dnd:write scopes. Empty turns the integration off.
+	Token string `json:"token"`
+
+	// Emoji is the status emoji, ":tomato:" when unset.
+	Emoji string `json:"emoji"`
+
+	// DND also snoozes Slack notifications until the session ends.
+	DND bool `json:"dnd"`
+}
+
+// slackAPI is the base URL of the Slack Web API.
+var slackAPI = "https://slack.com/api/"
+
+// slack keeps the Slack status in sync with work sessions.
+type slack struct {
+	token  string
+	emoji  string
+	dnd    bool
+	active bool
+}
+
+// newSlack returns the Slack integration, or nil when no token is set.
+func newSlack(cfg SlackConfig) listener {
+	if cfg.Token == "" {
+		return nil
+	}
+	return &slack{token: cfg.Token, emoji: cmp.Or(cfg.Emoji, ":tomato:"), dnd: cfg.DND}
+}
+
+// handle shows "Focusing until HH:MM" while a work session runs and clears
+// it when the session pauses or stops. The status also expires on its
+// own at the end, in case manta isn't around to clear it.
+func (s *slack) handle(ev Event) error {
+	if ev.Phase != WORKTIME {
+		return nil
+	}
+
+	switch ev.Name {
+	case EventStart, EventResume:
+		s.active = true
+		err := s.setStatus("Focusing until "+ev.EndTime.Local().Format("15:04"), s.emoji, ev.EndTime)
+		if s.dnd {
+			minutes := int(ev.EndTime.Sub(ev.Time).Round(time.Minute) / time.Minute)
+			err = errors.Join(err, s.call("dnd.setSnooze", url.Values{"num_minutes": {strconv.Itoa(max(minutes, 1))}}))
+		}
+		return err
+
+	case EventEnd, EventPause, EventSkip, EventReset, EventQuit:
+		if !s.active {
+			return nil
+		}
+		s.active = false
+		err := s.setStatus("", "", time.Time{})
+		if s.dnd {
+			err = errors.Join(err, s.call("dnd.endSnooze", nil))
+		}
+		return err
+	}
+	return nil
+}
+
+// setStatus sets the status text and emoji, or clears them when empty.
+func (s *slack) setStatus(text, emoji string, expires time.Time) error {
+	var expiration int64
+	if !expires.IsZero() {
+		expiration = expires.Unix()
+	}
+	profile, err := json.Marshal(map[string]any{
+		"status_text":       text,
+		"status_emoji":      emoji,
+		"status_expiration": expiration,
+	})
+	if err != nil {
+		return err
+	}
+	return s.call("users.profile.set", url.Values{"profile": {string(profile)}})
+}
+
+// call invokes a Slack Web API method. Slack reports most failures with a
+// 200 status and "ok": false.
+func (s *slack) call(method string, params url.Values) error {
+	req, err := http.NewRequest(http.MethodPost, slackAPI+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(resp.Body)
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		return fmt.Errorf("slack %s: %s", method, resp.Status)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack %s: %s", method, result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sleepGap is how long ticks must stop arriving before manta assumes the
+// machine was asleep.
+const sleepGap = 15 * time.Second
+
+// What happens to a running session when the computer wakes up.
+const (
+	SleepAsk   = "ask"   // pause it from the moment it slept and ask whether the sleep counts
+	SleepPause = "pause" // pause it from the moment it slept, leaving the sleep out
+	SleepCount = "count" // keep it running as if the computer had stayed awake
+)
+
+// validateSleep checks the sleep setting of the config.
+func validateSleep(policy string) error {
+	switch policy {
+	case "", SleepAsk, SleepPause, SleepCount:
+		return nil
+	}
+	return fmt.Errorf("sleep: unknown setting %q, want %q, %q or %q", policy, SleepAsk, SleepPause, SleepCount)
+}
+
+// detectSleep handles a running session when the gap since the previous
+// tick shows the machine was suspended. Unless the sleep counts, the
+// session is paused from the last tick before it.
+func (m model) detectSleep(tick time.Time) (model, tea.Cmd) {
+	last := m.lastTick
+	m.lastTick = tick
+	if last.IsZero() || tick.Sub(last) < sleepGap ||
+		!m.timer.Running() || m.timer.Paused() || m.timer.Overtime() {
+		return m, nil
+	}
+
+	// The session ended while the computer slept. Unless sleep pauses
+	// sessions there is nothing to ask; the tick goes on to fire the
+	// missed end and records it on time.
+	policy := cmp.Or(m.cfg.Sleep, SleepAsk)
+	expired := m.timer.Remaining(m.now) <= 0 && !m.timer.Stopwatch()
+	if policy == SleepCount || policy == SleepAsk && expired {
+		if expired {
+			m.status = tr("status.ended_asleep",
+				m.preset.Name, m.timer.End(m.now).Format("15:04"))
+		}
+		return m, nil
+	}
+
+	m.timer.Pause(last)
+	if policy == SleepPause {
+		m.status = tr("status.paused_asleep", last.Format("15:04"))
+	} else {
+		m.slept = true
+	}
+	m.writeState()
+	return m, m.emit(EventPause)
+}
+
+// sleepKeys answers the question asked after a sleep. Counting the sleep
+// resumes as if the session had kept running; otherwise the sleep is
+// treated as a pause.
+func (m model) sleepKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Yes):
+		m.timer.Unpause(m.now)
+	case key.Matches(msg, m.keys.No):
+		m.timer.Resume(m.now)
+	default:
+		return m, nil
+	}
+	m.slept = false
+	m.writeState()
+	return m, m.emit(EventResume)
+}
+
+// sleepView asks whether the time asleep counts toward the session.
+func (m model) sleepView(pad string) string {
+	if !m.slept {
+		return ""
+	}
+	away := m.now.Sub(m.timer.PausedAt()).Round(time.Minute)
+	return pad + m.theme.Status.Render(tr("sleep.ask",
+		away, m.keys.Yes.Help().Key, m.keys.No.Help().Key)) + "\n\n"
+}
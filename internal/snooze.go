@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+// Snooze lengths the config allows, and the one used when it is unset.
+const (
+	minSnooze     = 2 * time.Minute
+	maxSnooze     = 5 * time.Minute
+	defaultSnooze = 5 * time.Minute
+)
+
+// snoozing is a session that ended, holding off the next one for a while.
+type snoozing struct {
+	ended Session
+	timer timer.Timer
+}
+
+// validateSnooze checks the configured snooze length.
+func validateSnooze(d Duration) error {
+	if d != 0 && (time.Duration(d) < minSnooze || time.Duration(d) > maxSnooze) {
+		return fmt.Errorf("snooze must be between %v and %v, not %v", minSnooze, maxSnooze, time.Duration(d))
+	}
+	return nil
+}
+
+// snoozeLength returns how long a snooze lasts.
+func (c Config) snoozeLength() time.Duration {
+	if c.Snooze == 0 {
+		return defaultSnooze
+	}
+	return time.Duration(c.Snooze)
+}
+
+// snoozeLabel names the snooze in menus and notifications.
+func (m model) snoozeLabel() string {
+	return tr("menu.snooze", timerLength(m.cfg.snoozeLength()))
+}
+
+// snooze leaves the end menu and holds off the next session. The session
+// that ended is saved once the snooze is over, with the time snoozed.
+func (m model) snooze() (model, tea.Cmd) {
+	m.snoozing = &snoozing{ended: *m.ended, timer: timer.Snooze(m.cfg.snoozeLength(), m.now)}
+	m.ended = nil
+	return m, nil
+}
+
+// updateSnooze starts the next session once the snooze runs out.
+func (m model) updateSnooze() (model, tea.Cmd) {
+	if m.snoozing.timer.Tick(m.now) != timer.SnoozeOver {
+		return m, m.tickCmd()
+	}
+	next, cmd := m.endSnooze(true)
+	return next, tea.Batch(m.tickCmd(), cmd, m.pending.track(soundCmd(m.player, SoundPreroll)))
+}
+
+// session returns the session that ended with the time snoozed until now.
+func (z *snoozing) session(now time.Time) Session {
+	s := z.ended
+	s.Snoozed = int(min(z.timer.Elapsed(now), z.timer.Length()) / time.Second)
+	return s
+}
+
+// endSnooze saves the session that ended with the time snoozed, and starts
+// the next session unless the snooze was cancelled.
+func (m model) endSnooze(next bool) (model, tea.Cmd) {
+	s := m.snoozing.session(m.now)
+	m.snoozing = nil
+
+	if !next {
+		return m, m.record(s)
+	}
+	return m, tea.Batch(m.record(s), m.start(nextPreset(m.presets, m.preset)))
+}
+
+// snoozeKeys lets the start key cut the snooze short and the reset key
+// go back to the presets instead.
+func (m model) snoozeKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Start):
+		return m.endSnooze(true)
+
+	case key.Matches(msg, m.keys.Reset):
+		return m.endSnooze(false)
+	}
+	return m, nil
+}
+
+// snoozeView counts down to the next session.
+func (m model) snoozeView() string {
+	next := nextPreset(m.presets, m.preset)
+	return "\n" +
+		m.theme.Title.Render(tr("snooze.title", next.Name, clockText(m.snoozing.timer.Remaining(m.now)))) + "\n\n" +
+		m.theme.Help.Render(tr("snooze.help",
+			m.keys.Start.Help().Key, m.keys.Reset.Help().Key)) + "\n"
+}
@@ -0,0 +1,247 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ihorbryk/manta/assets"
+)
+
+// sampleRate is the rate the shared Oto context is opened with. Sounds
+// recorded at another rate would play at the wrong speed.
+const sampleRate = 44100
+
+// Sound events that can be mapped to their own sound file.
+const (
+	SoundWorkStart = "work_start"
+	SoundWorkEnd   = "work_end"
+	SoundRestEnd   = "rest_end"
+	SoundPreroll   = "preroll"
+	SoundTimer     = "timer"
+	SoundWarning   = "warning"
+	SoundGoal      = "goal_reached"
+)
+
+// SoundSilent maps an event to no sound at all.
+const SoundSilent = "none"
+
+// silentByDefault are the events without a sound unless one is set.
+var silentByDefault = map[string]bool{
+	SoundWorkStart: true,
+	SoundGoal:      true,
+}
+
+// soundPath resolves the sound of event from its setting in the config,
+// then a file named after the event in the theme directory, such as
+// work_end.mp3, then the embedded sound. It reports false when the event
+// is silent.
+func (c SoundConfig) soundPath(event, path string) (string, bool) {
+	switch {
+	case path == SoundSilent:
+		return "", false
+	case path != "":
+		return path, true
+	}
+	if c.Theme != "" {
+		for _, ext := range slices.Sorted(maps.Keys(decoders)) {
+			themed := filepath.Join(expandHome(c.Theme), event+ext)
+			if _, err := os.Stat(themed); err == nil {
+				return themed, true
+			}
+		}
+	}
+	return "", !silentByDefault[event]
+}
+
+// decoder turns an encoded sound into signed 16-bit little-endian stereo PCM.
+type decoder func(r io.ReadSeeker) (io.Reader, error)
+
+// sound is an encoded sound together with the decoder able to play it.
+type sound struct {
+	name   string
+	data   []byte
+	decode decoder
+	pcm    []byte // the decoded sound, when it was decoded up front
+}
+
+// stream returns a fresh PCM stream of the sound. Sounds decoded up front
+// are read from the same buffer every time.
+func (s sound) stream() (io.Reader, error) {
+	if s.pcm != nil {
+		return bytes.NewReader(s.pcm), nil
+	}
+	pcm, err := s.decode(bytes.NewReader(s.data))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", s.name, err)
+	}
+	return pcm, nil
+}
+
+// decoders maps file extensions to the decoder handling them.
+var decoders = map[string]decoder{
+	".mp3": decodeMP3,
+	".wav": decodeWAV,
+}
+
+// defaultSound returns the embedded notification sound.
+func defaultSound() (sound, error) {
+	data, err := assets.NotifySound.ReadFile("notify.mp3")
+	if err != nil {
+		return sound{}, fmt.Errorf("read notify.mp3: %w", err)
+	}
+	return sound{name: "notify.mp3", data: data, decode: decodeMP3}, nil
+}
+
+// loadSound reads a sound file and checks that it can be decoded, so bad
+// files are reported at startup rather than when the session ends. With
+// preload the decoded sound is kept, so alerts play without decoding;
+// long tracks such as ambient sound are decoded as they play instead.
+func loadSound(path string, preload bool) (sound, error) {
+	s, err := readSound(path)
+	if err != nil || !audioSupport {
+		// Without audio sounds are never played, so they aren't
+		// decoded either.
+		return s, err
+	}
+
+	pcm, err := s.stream()
+	if err != nil {
+		return sound{}, err
+	}
+	if !preload {
+		return s, nil
+	}
+	if s.pcm, err = io.ReadAll(pcm); err != nil {
+		return sound{}, fmt.Errorf("decode %s: %w", s.name, err)
+	}
+	return s, nil
+}
+
+// readSound reads the encoded sound at path, or the embedded one when path
+// is empty.
+func readSound(path string) (sound, error) {
+	if path == "" {
+		return defaultSound()
+	}
+	path = expandHome(path)
+
+	decode, ok := decoders[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return sound{}, fmt.Errorf("%s: unsupported sound format (use .mp3 or .wav)", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sound{}, err
+	}
+
+	return sound{name: path, data: data, decode: decode}, nil
+}
+
+// decodeWAV reads an uncompressed 16-bit PCM WAV file. Mono files are
+// upmixed to stereo.
+func decodeWAV(r io.ReadSeeker) (io.Reader, error) {
+	var riff struct {
+		ID     [4]byte
+		Size   uint32
+		Format [4]byte
+	}
+	if err := binary.Read(r, binary.LittleEndian, &riff); err != nil {
+		return nil, err
+	}
+	if string(riff.ID[:]) != "RIFF" || string(riff.Format[:]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+
+	var channels uint16
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &chunk); err != nil {
+			return nil, fmt.Errorf("missing data chunk: %w", err)
+		}
+
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			var f struct {
+				AudioFormat   uint16
+				Channels      uint16
+				SampleRate    uint32
+				ByteRate      uint32
+				BlockAlign    uint16
+				BitsPerSample uint16
+			}
+			if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+				return nil, err
+			}
+			if f.AudioFormat != 1 || f.BitsPerSample != 16 {
+				return nil, errors.New("only 16-bit PCM WAV files are supported")
+			}
+			if f.Channels != 1 && f.Channels != 2 {
+				return nil, fmt.Errorf("%d channels are not supported", f.Channels)
+			}
+			if f.SampleRate != sampleRate {
+				return nil, fmt.Errorf("sample rate %d Hz is not supported, use %d Hz", f.SampleRate, sampleRate)
+			}
+			channels = f.Channels
+			if _, err := r.Seek(int64(chunk.Size)-16, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+
+		case "data":
+			if channels == 0 {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			data := io.LimitReader(r, int64(chunk.Size))
+			if channels == 1 {
+				return &monoToStereo{r: data}, nil
+			}
+			return data, nil
+
+		default:
+			// Chunks are padded to an even size.
+			if _, err := r.Seek(int64(chunk.Size+chunk.Size%2), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// monoToStereo duplicates every 16-bit sample into both channels.
+type monoToStereo struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (m *monoToStereo) Read(p []byte) (int, error) {
+	n := len(p) / 4 * 2
+	if n == 0 {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(m.buf) < n {
+		m.buf = make([]byte, n)
+	}
+	n, err := io.ReadFull(m.r, m.buf[:n])
+	n -= n % 2
+	for i := 0; i < n; i += 2 {
+		copy(p[i*2:], m.buf[i:i+2])
+		copy(p[i*2+2:], m.buf[i:i+2])
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+		if n == 0 {
+			err = io.EOF
+		}
+	}
+	return n * 2, err
+}
@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema creates the sessions and changes tables. The whole session
+// is kept as JSON, with the columns queries filter and sum on alongside it.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id        INTEGER PRIMARY KEY,
+	start     INTEGER NOT NULL, -- unix nanoseconds
+	end       INTEGER NOT NULL, -- unix nanoseconds
+	phase     TEXT NOT NULL,
+	paused    INTEGER NOT NULL, -- seconds
+	abandoned INTEGER NOT NULL,
+	data      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_start ON sessions (start);
+CREATE TABLE IF NOT EXISTS changes (
+	id   INTEGER PRIMARY KEY,
+	at   INTEGER NOT NULL, -- unix nanoseconds
+	data TEXT NOT NULL
+);
+`
+
+// sqliteStore keeps the history in an SQLite database.
+type sqliteStore struct {
+	path string // empty for history.db in the data directory
+
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// open opens the database on first use, creating it as needed.
+func (s *sqliteStore) open() (*sql.DB, error) {
+	s.once.Do(func() {
+		path, err := dataFile(s.path, "history.db")
+		if err != nil {
+			s.err = err
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			s.err = err
+			return
+		}
+		db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+		if err != nil {
+			s.err = err
+			return
+		}
+		if _, err := db.Exec(sqliteSchema); err != nil {
+			db.Close()
+			s.err = err
+			return
+		}
+		s.db = db
+	})
+	return s.db, s.err
+}
+
+// Close closes the database, and keeps it from being opened when it
+// wasn't yet.
+func (s *sqliteStore) Close() error {
+	s.once.Do(func() { s.err = errStoreClosed })
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Append implements Store.
+func (s *sqliteStore) Append(session Session) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO sessions (start, end, phase, paused, abandoned, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		session.Start.UnixNano(), session.End.UnixNano(), session.Phase, session.Paused, session.Abandoned, string(data))
+	return err
+}
+
+// Query implements Store.
+func (s *sqliteStore) Query(q Query) ([]Session, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	where, args := q.where()
+	rows, err := db.Query(`SELECT data FROM sessions`+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Aggregate implements Store.
+func (s *sqliteStore) Aggregate(q Query) (Totals, error) {
+	db, err := s.open()
+	if err != nil {
+		return Totals{}, err
+	}
+	where, args := q.where()
+	var t Totals
+	var focus int64
+	err = db.QueryRow(`SELECT
+		COALESCE(SUM(NOT abandoned), 0),
+		COALESCE(SUM(abandoned), 0),
+		COALESCE(SUM(end - start - paused * 1000000000), 0),
+		COALESCE(SUM(json_array_length(data, '$.interruptions')), 0)
+		FROM sessions`+where, args...).Scan(&t.Finished, &t.Abandoned, &focus, &t.Interruptions)
+	t.Focus = time.Duration(focus)
+	return t, err
+}
+
+// Rewrite implements Store, in a single transaction.
+func (s *sqliteStore) Rewrite(edit func(Session) Session) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, data FROM sessions`)
+	if err != nil {
+		return err
+	}
+	updates, err := rewriteRows(rows, edit)
+	if err != nil {
+		return err
+	}
+	for id, data := range updates {
+		if _, err := tx.Exec(`UPDATE sessions SET data = ? WHERE id = ?`, data, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// rewriteRows reads id and data rows and returns the data edit changes,
+// by id.
+func rewriteRows(rows *sql.Rows, edit func(Session) Session) (map[int64]string, error) {
+	defer rows.Close()
+	updates := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, err
+		}
+		edited, err := json.Marshal(edit(session))
+		if err != nil {
+			return nil, err
+		}
+		if string(edited) != string(data) {
+			updates[id] = string(edited)
+		}
+	}
+	return updates, rows.Err()
+}
+
+// AppendChange implements Store.
+func (s *sqliteStore) AppendChange(c Change) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO changes (at, data) VALUES (?, ?)`, c.At.UnixNano(), string(data))
+	return err
+}
+
+// Changes implements Store.
+func (s *sqliteStore) Changes() ([]Change, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT data FROM changes ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanChanges(rows)
+}
+
+// scanChanges reads data rows of changes.
+func scanChanges(rows *sql.Rows) ([]Change, error) {
+	defer rows.Close()
+	var changes []Change
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var c Change
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// where renders q as an SQL WHERE clause and its arguments.
+func (q Query) where() (string, []any) {
+	var conds []string
+	var args []any
+	if !q.From.IsZero() {
+		conds = append(conds, "start >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		conds = append(conds, "start < ?")
+		args = append(args, q.To.UnixNano())
+	}
+	if q.Phase != "" {
+		conds = append(conds, "phase = ?")
+		args = append(args, q.Phase)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
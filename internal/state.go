@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is the snapshot of the running timer shared with other processes
+// through the state file.
+type State struct {
+	Phase     string    `json:"phase"`
+	Preset    string    `json:"preset"`
+	Task      string    `json:"task,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Paused    bool      `json:"paused"`
+	Remaining int       `json:"remaining"`
+	EndTime   time.Time `json:"end_time"`
+	Overtime  bool      `json:"overtime,omitempty"`
+	Stopwatch bool      `json:"stopwatch,omitempty"` // counts up with no end
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Enough to pick the session up again after manta was closed.
+	Start    time.Time `json:"start"`
+	Planned  int       `json:"planned"` // seconds
+	PausedAt time.Time `json:"paused_at,omitzero"`
+
+	Interruptions []Interruption `json:"interruptions,omitempty"`
+}
+
+// Left returns the number of seconds left in the session at the given time.
+// It is negative in overtime, and minus the time elapsed for a stopwatch.
+func (s State) Left(now time.Time) int {
+	if s.Paused {
+		return s.Remaining
+	}
+	return int(s.EndTime.Sub(now).Round(time.Second) / time.Second)
+}
+
+// statePath returns the location of the state file.
+func statePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manta", "state.json"), nil
+}
+
+// saveState writes the state file atomically so readers never see a
+// partially written snapshot.
+func saveState(s State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadState reads the state file. A missing file yields a zero State.
+func loadState() (State, error) {
+	var s State
+
+	path, err := statePath()
+	if err != nil {
+		return s, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// clearState removes the state file once no session is running.
+func clearState() error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultStatusFormat is the template used by `manta status` when no
+// format is given.
+const DefaultStatusFormat = "{{.Icon}} {{.Remaining}} {{.Preset}}"
+
+// compactStatusFormat fits the timer where space is tight, such as window
+// titles and the tray.
+const compactStatusFormat = "{{.Icon}} {{.Remaining}}"
+
+// statusLine holds the fields available to status templates.
+type statusLine struct {
+	Icon      string
+	Phase     string
+	Preset    string
+	Task      string
+	Remaining string
+	End       string
+	Paused    bool
+}
+
+// PrintStatus writes the current timer state as a single line rendered with
+// the given template. Nothing is printed when no session is running, so the
+// output can be embedded in tmux, waybar or polybar as is.
+func PrintStatus(w io.Writer, format string) error {
+	tmpl, err := template.New("status").Parse(format)
+	if err != nil {
+		return fmt.Errorf("parse status format: %w", err)
+	}
+
+	s, err := loadState()
+	if err != nil {
+		return fmt.Errorf("read state: %w", err)
+	}
+
+	line, err := renderStatus(tmpl, s, time.Now())
+	if err != nil || line == "" {
+		return err
+	}
+	_, err = fmt.Fprintln(w, line)
+	return err
+}
+
+// renderStatus renders s with tmpl, or returns "" when no session is
+// running at now.
+func renderStatus(tmpl *template.Template, s State, now time.Time) (string, error) {
+	left := s.Left(now)
+	if s.Phase == "" || (left < 0 && !s.Overtime && !s.Stopwatch) {
+		return "", nil
+	}
+
+	icon := "🍅"
+	if s.Phase == RESTTIME {
+		icon = "☕"
+	}
+	if s.Stopwatch {
+		icon = "⏱"
+	}
+	if s.Paused {
+		icon = "⏸"
+	}
+
+	remaining := fmt.Sprintf("%02d:%02d", left/60, left%60)
+	if s.Overtime || s.Stopwatch {
+		remaining = fmt.Sprintf("+%02d:%02d", -left/60, -left%60)
+	}
+
+	line := statusLine{
+		Icon:      icon,
+		Phase:     s.Phase,
+		Preset:    cmp.Or(s.Preset, s.Phase),
+		Task:      s.Task,
+		Remaining: remaining,
+		End:       s.EndTime.Format("15:04"),
+		Paused:    s.Paused,
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, line); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stopwatchPreset is the session a stopwatch runs as: work with no set
+// length.
+var stopwatchPreset = Preset{Name: "stopwatch", Phase: WORKTIME}
+
+// lapsShown is how many of the latest laps the session view lists.
+const lapsShown = 5
+
+// lap marks a lap of the running stopwatch.
+func (m *model) lap() {
+	m.laps = append(m.laps, m.timer.Elapsed(m.now))
+	m.status = tr("status.lap", len(m.laps), clockText(m.laps[len(m.laps)-1]))
+}
+
+// stopStopwatch stops the stopwatch and keeps the time counted as a
+// finished work session, which goes to the end menu like any other.
+func (m model) stopStopwatch() (model, tea.Cmd) {
+	m.status = ""
+	cmds := []tea.Cmd{m.emit(EventEnd), m.completeWork()}
+	cmds = append(cmds, m.finish())
+	return m, tea.Batch(cmds...)
+}
+
+// clockText formats d as minutes and seconds, or hours, minutes and
+// seconds from an hour on.
+func clockText(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
+// lapsView lists the latest laps with their split times.
+func (m model) lapsView(pad string) string {
+	if len(m.laps) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	for i := max(0, len(m.laps)-lapsShown); i < len(m.laps); i++ {
+		split := m.laps[i]
+		if i > 0 {
+			split -= m.laps[i-1]
+		}
+		s.WriteString(pad + m.theme.Help.Render(tr("stopwatch.lap", i+1, clockText(m.laps[i]), clockText(split))) + "\n")
+	}
+	return s.String() + "\n"
+}
+
+// lapSeconds returns the laps in seconds into the session, as saved in the
+// history.
+func lapSeconds(laps []time.Duration) []int {
+	if len(laps) == 0 {
+		return nil
+	}
+	secs := make([]int, len(laps))
+	for i, l := range laps {
+		secs[i] = int(l / time.Second)
+	}
+	return secs
+}
@@ -0,0 +1,375 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage backends.
+const (
+	StorageJSONL    = "jsonl"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+// StorageConfig selects where the session history is kept.
+type StorageConfig struct {
+	// Backend is "jsonl" (the default), "sqlite" or "postgres".
+	Backend string `json:"backend"`
+
+	// Path overrides the location of the history file or database.
+	Path string `json:"path"`
+
+	// URL is the connection string of a Postgres database shared by a
+	// team, e.g. "postgres://manta@db.example.com/manta".
+	URL string `json:"url"`
+
+	// User names whose sessions are kept in a shared database, the login
+	// name when unset.
+	User string `json:"user"`
+}
+
+// Store keeps the session history.
+type Store interface {
+	// Append adds a finished session.
+	Append(s Session) error
+
+	// Query returns the sessions matching q in the order they were added.
+	Query(q Query) ([]Session, error)
+
+	// Aggregate sums up the sessions matching q.
+	Aggregate(q Query) (Totals, error)
+
+	// Rewrite replaces every session with what edit returns for it.
+	Rewrite(edit func(Session) Session) error
+
+	// AppendChange records a change of settings.
+	AppendChange(c Change) error
+
+	// Changes returns the changes of settings in the order they were
+	// recorded.
+	Changes() ([]Change, error)
+}
+
+// Query selects sessions by start time and phase. Zero fields match every
+// session.
+type Query struct {
+	From  time.Time // sessions starting at or after From
+	To    time.Time // sessions starting before To
+	Phase string
+}
+
+// match reports whether s is selected by q.
+func (q Query) match(s Session) bool {
+	if !q.From.IsZero() && s.Start.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !s.Start.Before(q.To) {
+		return false
+	}
+	return q.Phase == "" || s.Phase == q.Phase
+}
+
+// Totals sums up a set of sessions.
+type Totals struct {
+	Finished  int           // sessions that ran to the end
+	Abandoned int           // sessions skipped or stopped early
+	Focus     time.Duration // time spent in sessions, pauses excluded
+
+	// Interruptions counts the interruptions logged in the sessions.
+	Interruptions int
+}
+
+// add counts s into t.
+func (t *Totals) add(s Session) {
+	if s.Abandoned {
+		t.Abandoned++
+	} else {
+		t.Finished++
+	}
+	t.Focus += s.focus()
+	t.Interruptions += len(s.Interruptions)
+}
+
+// errStoreClosed is returned by a store used after it was closed.
+var errStoreClosed = errors.New("the history was closed")
+
+var (
+	historyMu    sync.Mutex
+	historyStore Store
+	historyErr   error
+	historyCfg   historyConfig // the settings historyStore was opened with
+)
+
+// historyConfig is what selects the store of the history.
+type historyConfig struct {
+	storage StorageConfig
+	privacy PrivacyConfig
+}
+
+// openHistory returns the store of the history cfg selects.
+func openHistory(cfg historyConfig) (Store, error) {
+	store, err := newStore(cfg.storage)
+	if err != nil || !cfg.privacy.enabled() {
+		return store, err
+	}
+	redactor, err := cfg.privacy.redactor()
+	if err != nil {
+		return nil, err
+	}
+	return privateStore{store, redactor}, nil
+}
+
+// useHistory keeps the history where cfg, with its profile laid over it,
+// says. The store in use is kept when cfg selects the same one, and closed
+// when it doesn't.
+func useHistory(cfg Config) error {
+	want := historyConfig{cfg.Storage, cfg.Privacy}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if historyStore != nil && historyCfg == want {
+		return nil
+	}
+	store, err := openHistory(want)
+	if err != nil {
+		return err
+	}
+	if c, ok := historyStore.(io.Closer); ok {
+		c.Close() // nothing is left to do with it either way
+	}
+	historyStore, historyErr, historyCfg = store, nil, want
+	return nil
+}
+
+// history returns the store useHistory picked or, when none was, the one
+// selected in the config file, opened on first use.
+func history() (Store, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if historyStore == nil && historyErr == nil {
+		cfg, err := LoadConfig()
+		if err != nil {
+			historyErr = err
+			return nil, err
+		}
+		historyCfg = historyConfig{cfg.Storage, cfg.Privacy}
+		historyStore, historyErr = openHistory(historyCfg)
+	}
+	return historyStore, historyErr
+}
+
+// newStore returns the store cfg describes.
+func newStore(cfg StorageConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", StorageJSONL:
+		return jsonlStore{path: expandHome(cfg.Path)}, nil
+	case StorageSQLite:
+		return &sqliteStore{path: expandHome(cfg.Path)}, nil
+	case StoragePostgres:
+		return newPostgresStore(cfg.URL, cfg.User)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q, want %s, %s or %s", cfg.Backend, StorageJSONL, StorageSQLite, StoragePostgres)
+	}
+}
+
+// dataFile returns path, or the named file in the data directory when path
+// is empty.
+func dataFile(path, name string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// jsonlStore keeps the history as one JSON session per line.
+type jsonlStore struct {
+	path string // empty for history.jsonl in the data directory
+}
+
+// Append implements Store.
+func (j jsonlStore) Append(s Session) error {
+	path, err := dataFile(j.path, "history.jsonl")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Query implements Store. A missing file yields no sessions.
+func (j jsonlStore) Query(q Query) ([]Session, error) {
+	path, err := dataFile(j.path, "history.jsonl")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sessions []Session
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Session
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if q.match(s) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, scanner.Err()
+}
+
+// Aggregate implements Store.
+func (j jsonlStore) Aggregate(q Query) (Totals, error) {
+	sessions, err := j.Query(q)
+	if err != nil {
+		return Totals{}, err
+	}
+	var t Totals
+	for _, s := range sessions {
+		t.add(s)
+	}
+	return t, nil
+}
+
+// changesFile returns the file changes of settings are kept in, next to
+// the history.
+func (j jsonlStore) changesFile() (string, error) {
+	path, err := dataFile(j.path, "history.jsonl")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "-changes.jsonl", nil
+}
+
+// AppendChange implements Store.
+func (j jsonlStore) AppendChange(c Change) error {
+	path, err := j.changesFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Changes implements Store. A missing file yields no changes.
+func (j jsonlStore) Changes() ([]Change, error) {
+	path, err := j.changesFile()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var changes []Change
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var c Change
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, scanner.Err()
+}
+
+// Rewrite implements Store. The new history is written next to the old
+// one and moved over it, so a failure leaves the old one in place.
+func (j jsonlStore) Rewrite(edit func(Session) Session) error {
+	path, err := dataFile(j.path, "history.jsonl")
+	if err != nil {
+		return err
+	}
+	sessions, err := j.Query(Query{})
+	if err != nil || len(sessions) == 0 {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".history-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	for _, s := range sessions {
+		data, err := json.Marshal(edit(s))
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
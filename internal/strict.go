@@ -0,0 +1,354 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StrictConfig blocks distracting sites during work sessions by pointing
+// them at an unroutable address in the hosts file.
+type StrictConfig struct {
+	// Block lists the domains to block, e.g. "news.ycombinator.com". Their
+	// www. subdomain is blocked too.
+	Block []string `json:"block"`
+
+	// Hosts is the hosts file to edit, the system one when unset.
+	Hosts string `json:"hosts"`
+}
+
+// Markers around the lines strict mode adds to the hosts file, so they can
+// be found and removed again even after a crash.
+const (
+	strictBegin = "# manta strict mode: begin"
+	strictEnd   = "# manta strict mode: end"
+)
+
+// strictBackup is added to the name of the hosts file for the copy of it
+// kept while sites are blocked, to restore should the file be lost.
+const strictBackup = ".manta-backup"
+
+// sudoKeepAlive is how often the cached sudo credentials are refreshed, well
+// within sudo's default 15 minute timeout.
+const sudoKeepAlive = 5 * time.Minute
+
+// hostname matches the domains strict mode accepts: dot-separated labels
+// of letters, digits and hyphens. Anything else could add lines of its own
+// to the hosts file.
+var hostname = regexp.MustCompile(`(?i)^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)*$`)
+
+// sudoKeeper starts keepSudo once, however many times strict mode is set
+// up.
+var sudoKeeper sync.Once
+
+// strict blocks the configured domains exactly while a work session runs.
+type strict struct {
+	hosts   string
+	domains []string
+	sudo    bool // the hosts file is only writable through sudo
+	active  bool
+}
+
+// hostsPath returns the system hosts file.
+func hostsPath() string {
+	if runtime.GOOS == "windows" {
+		return `C:\Windows\System32\drivers\etc\hosts`
+	}
+	return "/etc/hosts"
+}
+
+// newStrict returns the strict mode integration, or nil when no domains are
+// blocked. When the hosts file can only be edited as root it asks for the
+// sudo password right away, while the terminal is still free, and keeps the
+// credentials fresh from then on. Sites left blocked by a crashed manta are
+// unblocked.
+func newStrict(cfg StrictConfig) (listener, error) {
+	if len(cfg.Block) == 0 {
+		return nil, nil
+	}
+	for _, d := range cfg.Block {
+		if len(d) > 253 || !hostname.MatchString(d) {
+			return nil, fmt.Errorf("strict mode: invalid domain %q", d)
+		}
+	}
+
+	s, err := openHosts(cfg.Hosts)
+	if err != nil {
+		return nil, fmt.Errorf("strict mode: %w", err)
+	}
+	s.domains = cfg.Block
+	if s.sudo {
+		sudoKeeper.Do(func() { go keepSudo() })
+	}
+
+	if !otherInstance() {
+		if err := s.write(false); err != nil {
+			return nil, fmt.Errorf("strict mode: unblock sites left blocked: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// openHosts checks that the hosts file at path, or the system one, can be
+// edited, logging in to sudo when only root can write it. The file is
+// replaced rather than written in place, so its directory must be writable
+// too.
+func openHosts(path string) (*strict, error) {
+	s := &strict{hosts: cmp.Or(expandHome(path), hostsPath())}
+	if resolved, err := filepath.EvalSymlinks(s.hosts); err == nil {
+		s.hosts = resolved
+	}
+	err := writable(s.hosts)
+	switch {
+	case err == nil:
+	case runtime.GOOS == "windows":
+		return nil, fmt.Errorf("%w (run manta as administrator)", err)
+	default:
+		s.sudo = true
+		if err := sudoLogin(); err != nil {
+			return nil, fmt.Errorf("sudo is needed to edit %s: %w", s.hosts, err)
+		}
+	}
+	return s, nil
+}
+
+// writable checks that the file at path can be written and replaced.
+func writable(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".manta-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// handle blocks the sites while a work session runs and unblocks them when
+// it pauses, ends or stops.
+func (s *strict) handle(ev Event) error {
+	if ev.Phase != WORKTIME {
+		return nil
+	}
+
+	switch ev.Name {
+	case EventStart, EventResume:
+		return s.set(true)
+	case EventEnd, EventPause, EventSkip, EventReset, EventQuit:
+		return s.set(false)
+	}
+	return nil
+}
+
+func (s *strict) set(active bool) error {
+	if s.active == active {
+		return nil
+	}
+	if err := s.write(active); err != nil {
+		return fmt.Errorf("strict mode: %w", err)
+	}
+	s.active = active
+	return nil
+}
+
+// write rewrites the hosts file with the blocked sites, or without them,
+// leaving every other line as it was. The file is replaced in one step, so
+// it is never left half written, and a copy of it without the sites is
+// kept while they are blocked. Should the hosts file be lost or emptied
+// all the same, it is restored from that copy.
+func (s *strict) write(block bool) error {
+	backup := s.hosts + strictBackup
+	data, err := os.ReadFile(s.hosts)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	current := data
+	if len(bytes.TrimSpace(data)) == 0 {
+		if saved, err := os.ReadFile(backup); err == nil {
+			data = saved
+		}
+	}
+	kept := stripStrict(data)
+	if !block && bytes.Equal(kept, current) {
+		return s.remove(backup)
+	}
+	if block {
+		if len(kept) > 0 && kept[len(kept)-1] != '\n' {
+			kept = append(kept, '\n')
+		}
+		if err := s.replace(backup, kept); err != nil {
+			return fmt.Errorf("back up %s: %w", s.hosts, err)
+		}
+		kept = append(kept, strictLines(s.domains)...)
+	}
+
+	if err := s.replace(s.hosts, kept); err != nil {
+		return err
+	}
+	flushDNS(s.sudo)
+	if !block {
+		return s.remove(backup)
+	}
+	return nil
+}
+
+// replace writes data to a temporary file next to path and renames it over
+// path, through sudo when only root can write there. The file keeps its
+// mode, 0644 when it is new.
+func (s *strict) replace(path string, data []byte) error {
+	mode := fs.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	if s.sudo {
+		tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".manta-%d", os.Getpid()))
+		script := `cat > "$1" && chmod "$2" "$1" && mv -f "$1" "$3" || { rm -f "$1"; exit 1; }`
+		cmd := exec.Command("sudo", "-n", "sh", "-c", script, "sh", tmp, fmt.Sprintf("%o", mode), path)
+		cmd.Stdin = bytes.NewReader(data)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("sudo write %s: %w: %s", path, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".manta-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// remove removes the file at path, through sudo when only root can write
+// there. A missing file is fine.
+func (s *strict) remove(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if s.sudo {
+		if out, err := exec.Command("sudo", "-n", "rm", "-f", path).CombinedOutput(); err != nil {
+			return fmt.Errorf("sudo rm %s: %w: %s", path, err, bytes.TrimSpace(out))
+		}
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// stripStrict returns the hosts file data without the lines strict mode
+// added.
+func stripStrict(data []byte) []byte {
+	var out []byte
+	inside := false
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		switch strings.TrimSpace(string(line)) {
+		case strictBegin:
+			inside = true
+			continue
+		case strictEnd:
+			inside = false
+			continue
+		}
+		if !inside {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+// strictLines returns the hosts file lines blocking domains over IPv4 and
+// IPv6.
+func strictLines(domains []string) []byte {
+	var b bytes.Buffer
+	b.WriteString(strictBegin + "\n")
+	for _, d := range domains {
+		names := []string{d}
+		if !strings.HasPrefix(d, "www.") {
+			names = append(names, "www."+d)
+		}
+		for _, name := range names {
+			fmt.Fprintf(&b, "0.0.0.0 %s\n:: %s\n", name, name)
+		}
+	}
+	b.WriteString(strictEnd + "\n")
+	return b.Bytes()
+}
+
+// sudoLogin makes sure sudo can run without asking, prompting for the
+// password on the terminal when it can't yet.
+func sudoLogin() error {
+	if exec.Command("sudo", "-n", "true").Run() == nil {
+		return nil
+	}
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return errors.New("sudo asks for a password and there is no terminal to enter it")
+	}
+	fmt.Fprintln(os.Stderr, "manta: strict mode edits the hosts file with sudo")
+	cmd := exec.Command("sudo", "-v")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// keepSudo refreshes the cached sudo credentials for as long as manta runs,
+// so the sites can still be unblocked at the end of a long session.
+func keepSudo() {
+	for range time.Tick(sudoKeepAlive) {
+		_ = exec.Command("sudo", "-n", "-v").Run()
+	}
+}
+
+// flushDNS drops cached lookups so hosts file changes apply right away.
+// Only macOS caches them by default.
+func flushDNS(sudo bool) {
+	if runtime.GOOS != "darwin" {
+		return
+	}
+	for _, args := range [][]string{{"dscacheutil", "-flushcache"}, {"killall", "-HUP", "mDNSResponder"}} {
+		if sudo {
+			args = append([]string{"sudo", "-n"}, args...)
+		}
+		_ = exec.Command(args[0], args[1:]...).Run()
+	}
+}
+
+// Unblock removes the sites strict mode blocked from the hosts file, for
+// when manta couldn't do it itself, restoring the file from its backup
+// when it was lost.
+func Unblock(cfg StrictConfig) error {
+	s, err := openHosts(cfg.Hosts)
+	if err != nil {
+		return err
+	}
+	return s.write(false)
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testHosts = "127.0.0.1 localhost\n::1 localhost\n"
+
+// newTestHosts writes data to a hosts file of the test's own and returns
+// its path.
+func newTestHosts(t *testing.T, data string) string {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir()) // no control socket, so no other manta
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readHosts returns the contents of the hosts file at path.
+func readHosts(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStripStrict(t *testing.T) {
+	block := strictBegin + "\n0.0.0.0 example.com\n" + strictEnd + "\n"
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"no block", testHosts, testHosts},
+		{"block at the end", testHosts + block, testHosts},
+		{"block in the middle", "127.0.0.1 localhost\n" + block + "::1 localhost\n", testHosts},
+		{"two blocks", block + testHosts + block, testHosts},
+		{"indented markers", "  " + strictBegin + "\n0.0.0.0 example.com\n\t" + strictEnd + "\r\n" + testHosts, testHosts},
+		{"no end marker", testHosts + strictBegin + "\n0.0.0.0 example.com\n", testHosts},
+		{"no final newline", "127.0.0.1 localhost", "127.0.0.1 localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stripStrict([]byte(tt.in))); got != tt.want {
+				t.Errorf("stripStrict(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictLines(t *testing.T) {
+	tests := []struct {
+		domains []string
+		want    string
+	}{
+		{nil, strictBegin + "\n" + strictEnd + "\n"},
+		{[]string{"example.com"}, strictBegin + "\n" +
+			"0.0.0.0 example.com\n:: example.com\n" +
+			"0.0.0.0 www.example.com\n:: www.example.com\n" +
+			strictEnd + "\n"},
+		{[]string{"www.example.com", "news.example.org"}, strictBegin + "\n" +
+			"0.0.0.0 www.example.com\n:: www.example.com\n" +
+			"0.0.0.0 news.example.org\n:: news.example.org\n" +
+			"0.0.0.0 www.news.example.org\n:: www.news.example.org\n" +
+			strictEnd + "\n"},
+	}
+	for _, tt := range tests {
+		if got := string(strictLines(tt.domains)); got != tt.want {
+			t.Errorf("strictLines(%q) = %q, want %q", tt.domains, got, tt.want)
+		}
+	}
+}
+
+func TestStrictBlocks(t *testing.T) {
+	path := newTestHosts(t, testHosts)
+	l, err := newStrict(StrictConfig{Block: []string{"example.com"}, Hosts: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.handle(Event{Name: EventStart, Phase: WORKTIME}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readHosts(t, path), testHosts+string(strictLines([]string{"example.com"})); got != want {
+		t.Errorf("blocked hosts file = %q, want %q", got, want)
+	}
+	if got := readHosts(t, path+strictBackup); got != testHosts {
+		t.Errorf("backup = %q, want %q", got, testHosts)
+	}
+
+	if err := l.handle(Event{Name: EventEnd, Phase: WORKTIME}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readHosts(t, path); got != testHosts {
+		t.Errorf("unblocked hosts file = %q, want %q", got, testHosts)
+	}
+	if _, err := os.Stat(path + strictBackup); !os.IsNotExist(err) {
+		t.Errorf("backup left behind: %v", err)
+	}
+}
+
+func TestStrictRestoresBackup(t *testing.T) {
+	path := newTestHosts(t, testHosts)
+	l, err := newStrict(StrictConfig{Block: []string{"example.com"}, Hosts: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.handle(Event{Name: EventStart, Phase: WORKTIME}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unblock(StrictConfig{Hosts: path}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readHosts(t, path); got != testHosts {
+		t.Errorf("restored hosts file = %q, want %q", got, testHosts)
+	}
+}
+
+func TestStrictRefusesInvalidDomains(t *testing.T) {
+	for _, domain := range []string{
+		"",
+		"example.com\n1.2.3.4 bank.example.com",
+		"example.com # comment",
+		"-example.com",
+		"example-.com",
+		"example..com",
+		".example.com",
+		"exa_mple.com",
+		strings.Repeat("a.", 127) + "com",
+	} {
+		path := newTestHosts(t, testHosts)
+		if _, err := newStrict(StrictConfig{Block: []string{"example.org", domain}, Hosts: path}); err == nil {
+			t.Errorf("newStrict accepts %q", domain)
+		}
+		if got := readHosts(t, path); got != testHosts {
+			t.Errorf("hosts file changed to %q for %q", got, domain)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SummaryConfig sends a summary of the day's work: sessions finished,
+// focus time, tasks worked on and how far the daily goal got.
+type SummaryConfig struct {
+	// At is when the summary is sent each day, as HH:MM. Empty sends
+	// none at a set time.
+	At string `json:"at"`
+
+	// OnQuit sends the summary, and prints it, when manta quits.
+	OnQuit bool `json:"on_quit"`
+}
+
+// daySummary sums up the work sessions of a day.
+type daySummary struct {
+	finished int
+	focus    time.Duration
+	tasks    int
+	goal     int // work sessions to complete, 0 without a goal
+}
+
+// summarize sums up the work sessions saved so far on the day of now.
+func summarize(now time.Time, goal int) (daySummary, error) {
+	y, mo, d := now.Date()
+	sessions, err := querySessions(Query{From: time.Date(y, mo, d, 0, 0, 0, 0, now.Location()), Phase: WORKTIME})
+	if err != nil {
+		return daySummary{}, err
+	}
+	sum := daySummary{goal: goal}
+	tasks := map[string]bool{}
+	for _, s := range sessions {
+		if !s.Abandoned {
+			sum.finished++
+		}
+		sum.focus += s.focus()
+		if s.Task != "" && !tasks[s.Task] {
+			tasks[s.Task] = true
+			sum.tasks++
+		}
+	}
+	return sum, nil
+}
+
+// text is the body of the summary notification.
+func (s daySummary) text() string {
+	text := tr("notify.summary_body", s.finished, hoursView(s.focus), s.tasks)
+	if s.goal > 0 {
+		text += tr("notify.summary_goal", s.finished, s.goal)
+	}
+	return text
+}
+
+// summaryMsg carries the summary of the day read in the background.
+type summaryMsg struct {
+	text string
+}
+
+// summaryCmd sums up the day of now in the background.
+func summaryCmd(now time.Time, goal int) tea.Cmd {
+	return func() tea.Msg {
+		sum, err := summarize(now, goal)
+		if err != nil {
+			return newError(CodeHistoryRead, "read the history", err)
+		}
+		return summaryMsg{text: sum.text()}
+	}
+}
+
+// summaryTick sums up the day once the time set for the summary comes,
+// and schedules the next one for the same time tomorrow.
+func (m *model) summaryTick() tea.Cmd {
+	if m.summaryAt.IsZero() || m.now.Before(m.summaryAt) {
+		return nil
+	}
+	m.summaryAt, _ = ParseClock(m.cfg.Summary.At, m.now)
+	return m.pending.track(summaryCmd(m.now, m.cfg.Goal))
+}
+
+// updateSummary shows the summary of the day and sends it as a
+// notification.
+func (m model) updateSummary(msg summaryMsg) (model, tea.Cmd) {
+	title := tr("notify.summary")
+	m.status = fmt.Sprintf("%s: %s", title, msg.text)
+	return m, m.pending.track(notifyCmd(m.notifier, title, msg.text))
+}
+
+// summarizeOnQuit prints and sends the summary of the day as manta quits,
+// when the config asks for it.
+func (m model) summarizeOnQuit() {
+	if !m.cfg.Summary.OnQuit {
+		return
+	}
+	sum, err := summarize(m.wallNow(), m.cfg.Goal)
+	if err != nil {
+		reportShutdown(CodeHistoryRead, "read the history", err)
+		return
+	}
+	title := tr("notify.summary")
+	fmt.Printf("%s: %s\n", title, sum.text())
+	reportShutdown(CodeNotify, "send the notification", m.notifier.Notify(title, sum.text()))
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// TagRule adds Tag to sessions started from a directory matching Path or
+// working on a task matching Task. Both are glob patterns; a directory
+// matches when it or one of its parents does, so "~/work/acme/*" covers
+// every project below it.
+type TagRule struct {
+	Path string `json:"path"`
+	Task string `json:"task"`
+	Tag  string `json:"tag"`
+}
+
+// tagRules is the validated list of auto-tagging rules.
+type tagRules []TagRule
+
+// newTagRules checks the rules and expands ~ in their paths.
+func newTagRules(cfg []TagRule) (tagRules, error) {
+	rules := make(tagRules, len(cfg))
+	for i, r := range cfg {
+		if r.Tag == "" {
+			return nil, fmt.Errorf("tag rule %d: needs a tag", i+1)
+		}
+		if r.Path == "" && r.Task == "" {
+			return nil, fmt.Errorf("tag rule %d: needs a path or a task pattern", i+1)
+		}
+		r.Path = expandHome(r.Path)
+		if _, err := filepath.Match(r.Path, ""); err != nil {
+			return nil, fmt.Errorf("tag rule %d: path %q: %w", i+1, r.Path, err)
+		}
+		if _, err := path.Match(r.Task, ""); err != nil {
+			return nil, fmt.Errorf("tag rule %d: task %q: %w", i+1, r.Task, err)
+		}
+		rules[i] = r
+	}
+	return rules, nil
+}
+
+// tags returns the hashtags of the task followed by the tags of every rule
+// matching dir or the task.
+func (rs tagRules) tags(dir, task string) []string {
+	tags := taskTags(task)
+	for _, r := range rs {
+		if slices.Contains(tags, r.Tag) {
+			continue
+		}
+		if (r.Path != "" && dirMatches(r.Path, dir)) ||
+			(r.Task != "" && task != "" && taskMatches(r.Task, task)) {
+			tags = append(tags, r.Tag)
+		}
+	}
+	return tags
+}
+
+// dirMatches reports whether dir or one of its parents matches pattern.
+func dirMatches(pattern, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	for {
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// taskMatches reports whether the task matches pattern, ignoring case.
+func taskMatches(pattern, task string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(task))
+	return ok
+}
+
+// taskTags returns the #hashtags in a task name, without the hash, in the
+// order they appear.
+func taskTags(task string) []string {
+	var tags []string
+	for _, word := range strings.Fields(task) {
+		tag := strings.TrimPrefix(word, "#")
+		if tag == word || tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// launchDir returns the directory manta was started from, or "" when it
+// can't be determined.
+func launchDir() string {
+	dir, _ := os.Getwd()
+	return dir
+}
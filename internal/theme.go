@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ThemeConfig selects a built-in theme and optionally overrides its colors.
+// Colors are hex values such as "#626262".
+type ThemeConfig struct {
+	Name          string `json:"name"`
+	ProgressStart string `json:"progress_start"`
+	ProgressEnd   string `json:"progress_end"`
+	Accent        string `json:"accent"`
+	Help          string `json:"help"`
+	Overtime      string `json:"overtime"`
+
+	// Urgency colors the progress bar by the time left instead of with the
+	// gradient: from green through amber to red as a work session runs
+	// out, and the other way round during a break.
+	Urgency bool `json:"urgency"`
+
+	// Light and Dark name the themes used on light and dark backgrounds,
+	// "light" and the theme picked by name when unset. Setting either
+	// one switches between them to match the terminal and the system.
+	Light string `json:"light"`
+	Dark  string `json:"dark"`
+}
+
+// themes are the built-in color sets.
+var themes = map[string]ThemeConfig{
+	"default": {
+		ProgressStart: "#5A56E0",
+		ProgressEnd:   "#EE6FF8",
+		Accent:        "#EE6FF8",
+		Help:          "#626262",
+		Overtime:      "#FF5F87",
+	},
+	"solarized": {
+		ProgressStart: "#268BD2",
+		ProgressEnd:   "#2AA198",
+		Accent:        "#B58900",
+		Help:          "#586E75",
+		Overtime:      "#DC322F",
+	},
+	"gruvbox": {
+		ProgressStart: "#B8BB26",
+		ProgressEnd:   "#FE8019",
+		Accent:        "#FABD2F",
+		Help:          "#928374",
+		Overtime:      "#FB4934",
+	},
+	"light": {
+		ProgressStart: "#5A56E0",
+		ProgressEnd:   "#C2185B",
+		Accent:        "#8E24AA",
+		Help:          "#8A8A8A",
+		Overtime:      "#D70000",
+	},
+	"high-contrast": {
+		ProgressStart: "#FFFF00",
+		ProgressEnd:   "#FFFF00",
+		Accent:        "#FFFF00",
+		Help:          "#FFFFFF",
+		Overtime:      "#FF0000",
+	},
+}
+
+// Theme holds every style used by View.
+type Theme struct {
+	ProgressStart string
+	ProgressEnd   string
+	Urgency       bool
+	Title         lipgloss.Style
+	Selected      lipgloss.Style
+	Help          lipgloss.Style
+	Status        lipgloss.Style
+	Overtime      lipgloss.Style
+	HelpStyles    help.Styles
+}
+
+// newTheme resolves the configured theme, applying any color overrides on
+// top of the named built-in theme.
+func newTheme(cfg ThemeConfig) (Theme, error) {
+	return namedTheme(cfg, cfg.Name)
+}
+
+// auto reports whether the theme follows the background.
+func (c ThemeConfig) auto() bool {
+	return c.Light != "" || c.Dark != ""
+}
+
+// newThemes resolves the light and dark variants of the configured theme.
+func newThemes(cfg ThemeConfig) (light, dark Theme, err error) {
+	if light, err = namedTheme(cfg, cmp.Or(cfg.Light, "light")); err != nil {
+		return
+	}
+	dark, err = namedTheme(cfg, cmp.Or(cfg.Dark, cfg.Name))
+	return
+}
+
+// namedTheme resolves the built-in theme called name with the color
+// overrides of cfg.
+func namedTheme(cfg ThemeConfig, name string) (Theme, error) {
+	if name == "" {
+		name = "default"
+	}
+	base, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(themeNames(), ", "))
+	}
+
+	if cfg.ProgressStart != "" {
+		base.ProgressStart = cfg.ProgressStart
+	}
+	if cfg.ProgressEnd != "" {
+		base.ProgressEnd = cfg.ProgressEnd
+	}
+	if cfg.Accent != "" {
+		base.Accent = cfg.Accent
+	}
+	if cfg.Help != "" {
+		base.Help = cfg.Help
+	}
+	if cfg.Overtime != "" {
+		base.Overtime = cfg.Overtime
+	}
+
+	accent := lipgloss.Color(base.Accent)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color(base.Help))
+
+	helpStyles := help.New().Styles
+	helpStyles.ShortKey = muted.Bold(true)
+	helpStyles.ShortDesc = muted
+	helpStyles.ShortSeparator = muted
+	helpStyles.FullKey = muted.Bold(true)
+	helpStyles.FullDesc = muted
+	helpStyles.FullSeparator = muted
+
+	return Theme{
+		ProgressStart: base.ProgressStart,
+		ProgressEnd:   base.ProgressEnd,
+		Urgency:       cfg.Urgency,
+		Title:         lipgloss.NewStyle().Foreground(accent).Bold(true),
+		Selected:      lipgloss.NewStyle().Foreground(accent),
+		Help:          muted,
+		Status:        muted.Italic(true),
+		Overtime:      lipgloss.NewStyle().Foreground(lipgloss.Color(base.Overtime)).Bold(true),
+		HelpStyles:    helpStyles,
+	}, nil
+}
+
+// progressBar returns a progress bar using the theme gradient, or a solid
+// fill restyled as time runs out with urgency colors.
+func (t Theme) progressBar() progress.Model {
+	if t.Urgency {
+		return progress.New(progress.WithSolidFill(urgencyColors[0]))
+	}
+	return progress.New(progress.WithGradient(t.ProgressStart, t.ProgressEnd))
+}
+
+// urgencyColors are the progress bar colors with all of the time left,
+// half of it and none.
+var urgencyColors = [3]string{"#04B575", "#FFB000", "#FF4F4F"}
+
+// urgencySteps is how many times the urgency color changes over a session,
+// at every 5% of the time.
+const urgencySteps = 20
+
+// urgencyStep returns how far a session done the given share has come,
+// from 0 when it starts to urgencySteps at its end.
+func urgencyStep(done float64) int {
+	return int(min(max(done, 0), 1) * urgencySteps)
+}
+
+// urgencyColor returns the color of step, blended between the urgency
+// colors.
+func urgencyColor(step int) string {
+	at := float64(step) / urgencySteps * 2
+	from := min(int(at), 1)
+	return blendHex(urgencyColors[from], urgencyColors[from+1], at-float64(from))
+}
+
+// blendHex mixes the #RRGGBB colors a and b, t of the way to b.
+func blendHex(a, b string, t float64) string {
+	var ar, ag, ab, br, bg, bb int
+	fmt.Sscanf(a, "#%02x%02x%02x", &ar, &ag, &ab)
+	fmt.Sscanf(b, "#%02x%02x%02x", &br, &bg, &bb)
+	mix := func(x, y int) int {
+		return x + int(math.Round(float64(y-x)*t))
+	}
+	return fmt.Sprintf("#%02X%02X%02X", mix(ar, br), mix(ag, bg), mix(ab, bb))
+}
+
+// themeNames returns the names of the built-in themes in sorted order.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
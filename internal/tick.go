@@ -6,10 +6,110 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Refresh rates of the display. Ticking several times a second keeps the
+// progress bar moving smoothly; the countdown text still changes once a
+// second.
+const (
+	defaultTick = 250 * time.Millisecond
+	minTick     = 50 * time.Millisecond
+	maxTick     = time.Second
+)
+
+// Output lag, measured as how late ticks are handled, beyond which the
+// terminal is taken to be on a slow link, such as SSH over a poor
+// connection, and below which it is fast again. The gap between them
+// keeps the display from switching back and forth.
+const (
+	slowLag = 200 * time.Millisecond
+	fastLag = 50 * time.Millisecond
+)
+
+// slowStatus is the message shown while the display refreshes less for a
+// slow link.
+const slowStatus = "status.slow"
+
 type tickMsg time.Time
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second*1, func(t time.Time) tea.Msg {
-		return tickMsg(t)
+// tickEvery returns the refresh interval set in the config, kept within
+// minTick and maxTick.
+func tickEvery(d Duration) time.Duration {
+	if d == 0 {
+		return defaultTick
+	}
+	return min(max(time.Duration(d), minTick), maxTick)
+}
+
+// after returns a command sending the message fn makes once d has passed.
+// It is tea.Tick but for the screen tests, which hold such timers to move
+// time by hand.
+var after = tea.Tick
+
+// tickCmd schedules the next refresh of the display. It comes no more
+// often than MaxFPS allows, and once a second on a slow link or while
+// saving power.
+func (m model) tickCmd() tea.Cmd {
+	every := tickEvery(m.cfg.Tick)
+	if m.cfg.MaxFPS > 0 {
+		every = min(max(every, time.Second/time.Duration(m.cfg.MaxFPS)), maxTick)
+	}
+	if m.slow || m.saving {
+		every = maxTick
+	}
+	clock := m.clock
+	return after(every, func(time.Time) tea.Msg {
+		return tickMsg(clock())
 	})
 }
+
+// measureLag tracks how late the tick that fired at fired is handled. The
+// view is rendered between messages, so a terminal that is slow to take
+// the output delays the ticks behind it. The lag is smoothed over the
+// last few ticks before deciding the link is slow, or fast again.
+func (m *model) measureLag(fired time.Time) {
+	lag := max(m.clock().Sub(fired), 0)
+	m.lag = (m.lag*7 + lag) / 8
+
+	switch {
+	case !m.slow && m.lag > slowLag:
+		m.slow = true
+		m.status = tr(slowStatus)
+	case m.slow && m.lag < fastLag:
+		m.slow = false
+		if m.status == tr(slowStatus) {
+			m.status = ""
+		}
+	}
+}
+
+// still reports whether the progress bar jumps to its place instead of
+// gliding there: when frames are rationed, motion is reduced or power
+// saved.
+func (m model) still() bool {
+	return m.slow || m.saving || m.cfg.MaxFPS > 0 || m.cfg.ReducedMotion || m.cfg.Accessible
+}
+
+// urgencyTick restyles the progress bar for the time left when the theme
+// colors it by urgency. The color of a break runs the other way, from red
+// to green.
+func (m *model) urgencyTick() {
+	if !m.theme.Urgency || !m.timer.Running() || m.timer.Stopwatch() {
+		return
+	}
+	step := urgencyStep(m.timer.Progress(m.now))
+	if m.preset.Phase == RESTTIME {
+		step = urgencySteps - step
+	}
+	if step != m.urgency {
+		m.urgency = step
+		m.progress.FullColor = urgencyColor(step)
+	}
+}
+
+// progressView renders the progress bar, animated unless it is kept
+// still.
+func (m model) progressView() string {
+	if m.still() {
+		return m.progress.ViewAs(m.timer.Progress(m.now))
+	}
+	return m.progress.View()
+}
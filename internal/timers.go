@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+// namedTimer is a countdown running alongside the session, such as tea
+// steeping. It is not recorded in the history.
+type namedTimer struct {
+	name  string
+	timer timer.Timer
+}
+
+// parseTimer reads a timer given as "[name] duration", e.g. "tea 4m".
+// Without a name the timer is named after its duration.
+func parseTimer(s string) (string, time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", 0, fmt.Errorf("want a duration, e.g. tea 4m")
+	}
+	last := fields[len(fields)-1]
+	d, err := time.ParseDuration(last)
+	if err != nil || d <= 0 {
+		return "", 0, fmt.Errorf("invalid duration %q, e.g. tea 4m", last)
+	}
+	name := strings.Join(fields[:len(fields)-1], " ")
+	if name == "" {
+		name = last
+	}
+	return name, d, nil
+}
+
+// addTimer starts a named timer now and focuses it.
+func (m *model) addTimer(name string, d time.Duration) {
+	m.timers = append(m.timers, namedTimer{name: name, timer: timer.Start(d, m.now)})
+	m.focus = len(m.timers)
+}
+
+// focused returns the named timer the pause and stop keys act on, or nil
+// when they act on the session.
+func (m model) focused() *namedTimer {
+	if m.focus == 0 || m.focus > len(m.timers) {
+		return nil
+	}
+	return &m.timers[m.focus-1]
+}
+
+// timerKeys routes keys to the focused named timer: pause toggles it and
+// stop removes it.
+func (m model) timerKeys(msg tea.KeyMsg) (model, tea.Cmd) {
+	t := m.focused()
+	switch {
+	case key.Matches(msg, m.keys.Pause):
+		if t.timer.Paused() {
+			t.timer.Resume(m.now)
+		} else {
+			t.timer.Pause(m.now)
+		}
+
+	case key.Matches(msg, m.keys.Reset):
+		m.status = tr("status.timer_stopped", t.name)
+		m.removeTimer(m.focus - 1)
+	}
+	return m, nil
+}
+
+// removeTimer drops the i-th named timer, keeping the focus on the same
+// timer or moving it to the session.
+func (m *model) removeTimer(i int) {
+	m.timers = append(m.timers[:i:i], m.timers[i+1:]...)
+	switch {
+	case m.focus == i+1:
+		m.focus = 0
+	case m.focus > i+1:
+		m.focus--
+	}
+}
+
+// tickTimers advances the named timers, alerting for and removing the ones
+// that have run out.
+func (m *model) tickTimers() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := 0; i < len(m.timers); i++ {
+		t := &m.timers[i]
+		if t.timer.Paused() || t.timer.Tick(m.now) != timer.Completed {
+			continue
+		}
+		m.status = tr("status.timer_done", t.name)
+		cmds = append(cmds,
+			m.pending.track(soundCmd(m.player, SoundTimer)),
+			m.pending.track(notifyCmd(m.notifier, tr("notify.timer", t.name), tr("notify.timer_body", timerLength(t.timer.Length())))),
+		)
+		m.removeTimer(i)
+		i--
+	}
+	return tea.Batch(cmds...)
+}
+
+// timersView lists the named timers, highlighting the focused one.
+func (m model) timersView(pad string) string {
+	if len(m.timers) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	for i, t := range m.timers {
+		left := t.timer.SecondsLeft(m.now)
+		line := fmt.Sprintf("⏲ %s %02d:%02d", t.name, left/60, left%60)
+		if t.timer.Paused() {
+			line += tr("view.paused")
+		}
+		if m.focus == i+1 {
+			line = m.theme.Selected.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		s.WriteString(pad + line + "\n")
+	}
+	return s.String() + "\n"
+}
+
+// timerLength formats the length of a named timer, e.g. "4m" or "1h30m".
+func timerLength(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
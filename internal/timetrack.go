@@ -0,0 +1,409 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TimeTrackingConfig logs each finished work session as a time entry in
+// Toggl Track or Clockify.
+type TimeTrackingConfig struct {
+	// Service is "toggl" or "clockify". Empty turns the integration off.
+	Service string `json:"service"`
+
+	// Token is the API token, found in the profile settings of either
+	// service.
+	Token string `json:"token"`
+
+	// Workspace is the ID of the workspace the entries go to.
+	Workspace string `json:"workspace"`
+
+	// Project is the ID of the project the entries are filed under, if
+	// any.
+	Project string `json:"project"`
+
+	// Tags label the entries, ["pomodoro"] when unset. The session's own
+	// tags are added to them.
+	Tags []string `json:"tags"`
+}
+
+// Time tracking services.
+const (
+	ServiceToggl    = "toggl"
+	ServiceClockify = "clockify"
+)
+
+// Base URLs of the time tracking APIs.
+var (
+	togglAPI    = "https://api.track.toggl.com/api/v9/"
+	clockifyAPI = "https://api.clockify.me/api/v1/"
+)
+
+// trackAttempts is the number of attempts at sending an entry before it
+// is queued.
+const trackAttempts = 3
+
+// trackBackoff is the wait before the first retry, doubling after each.
+var trackBackoff = time.Second
+
+// trackedEntry is a finished work session as the time trackers take it.
+type trackedEntry struct {
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	Duration    int       `json:"duration"` // seconds of focus
+	Tags        []string  `json:"tags"`
+}
+
+// timeTracker sends time entries to Toggl Track or Clockify. Entries that
+// can't be sent for network trouble wait in a queue, sent ahead of the
+// next entry or when manta starts.
+type timeTracker struct {
+	cfg       TimeTrackingConfig
+	workspace int // the workspace ID as Toggl takes it
+
+	mu   sync.Mutex        // serializes sending and the queue
+	tags map[string]string // Clockify tag IDs by name
+}
+
+// newTimeTracker returns the time tracker, or nil when it is disabled.
+func newTimeTracker(cfg TimeTrackingConfig) (*timeTracker, error) {
+	switch cfg.Service {
+	case "":
+		return nil, nil
+	case ServiceToggl, ServiceClockify:
+	default:
+		return nil, fmt.Errorf("time tracking: unknown service %q, want %q or %q", cfg.Service, ServiceToggl, ServiceClockify)
+	}
+	if cfg.Token == "" || cfg.Workspace == "" {
+		return nil, errors.New("time tracking: needs a token and a workspace")
+	}
+	t := &timeTracker{cfg: cfg, tags: map[string]string{}}
+	if cfg.Service == ServiceToggl {
+		var err error
+		if t.workspace, err = strconv.Atoi(cfg.Workspace); err != nil {
+			return nil, fmt.Errorf("time tracking: workspace %q: want a number", cfg.Workspace)
+		}
+		if _, err := strconv.Atoi(cmp.Or(cfg.Project, "0")); err != nil {
+			return nil, fmt.Errorf("time tracking: project %q: want a number", cfg.Project)
+		}
+	}
+	if len(t.cfg.Tags) == 0 {
+		t.cfg.Tags = []string{"pomodoro"}
+	}
+	return t, nil
+}
+
+// tracks reports whether s is sent: only finished work sessions are.
+func (t *timeTracker) tracks(s Session) bool {
+	return t != nil && s.Phase == WORKTIME && !s.Abandoned
+}
+
+// entry returns the time entry of s.
+func (t *timeTracker) entry(s Session) trackedEntry {
+	tags := slices.Clone(t.cfg.Tags)
+	for _, tag := range s.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return trackedEntry{
+		Description: cmp.Or(s.Task, s.Preset),
+		Start:       s.Start,
+		Duration:    int(s.focus() / time.Second),
+		Tags:        tags,
+	}
+}
+
+// cmd sends s in the background, reporting failures as an *Error. It is
+// nil when s isn't sent.
+func (t *timeTracker) cmd(s Session) tea.Cmd {
+	if !t.tracks(s) {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := t.send(t.entry(s)); err != nil {
+			return newError(CodeTimeTracking, "send the time entry", err)
+		}
+		return nil
+	}
+}
+
+// flushCmd sends the queued entries in the background.
+func (t *timeTracker) flushCmd() tea.Cmd {
+	if t == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if err := t.flush(); err != nil {
+			return newError(CodeTimeTracking, "send the queued time entries", err)
+		}
+		return nil
+	}
+}
+
+// later queues s to be sent with the next entry, for when manta can't
+// wait for the network.
+func (t *timeTracker) later(s Session) error {
+	if !t.tracks(s) {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	queue, err := loadTrackQueue()
+	if err != nil {
+		return err
+	}
+	return saveTrackQueue(append(queue, t.entry(s)))
+}
+
+// send sends the queued entries and then e. When the service can't be
+// reached, e is queued behind them and the error says so.
+func (t *timeTracker) send(e trackedEntry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.flush()
+	var offline *offlineError
+	if !errors.As(err, &offline) {
+		err = errors.Join(err, t.retry(e))
+	}
+	if !errors.As(err, &offline) {
+		return err
+	}
+	queue, qerr := loadTrackQueue()
+	if qerr == nil {
+		qerr = saveTrackQueue(append(queue, e))
+	}
+	if qerr != nil {
+		return errors.Join(err, qerr)
+	}
+	return fmt.Errorf("%w; queued to send later", err)
+}
+
+// flush sends the queued entries, keeping those that can't be sent for
+// now. Entries the service turns down are dropped.
+func (t *timeTracker) flush() error {
+	queue, err := loadTrackQueue()
+	if err != nil || len(queue) == 0 {
+		return err
+	}
+	var kept []trackedEntry
+	var errs []error
+	for i, e := range queue {
+		err := t.post(e)
+		var offline *offlineError
+		if errors.As(err, &offline) {
+			kept = queue[i:]
+			errs = append(errs, err)
+			break
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(append(errs, saveTrackQueue(kept))...)
+}
+
+// retry posts e, trying again with a growing wait while the service
+// can't be reached.
+func (t *timeTracker) retry(e trackedEntry) error {
+	wait := trackBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = t.post(e)
+		var offline *offlineError
+		if !errors.As(err, &offline) || attempt == trackAttempts {
+			return err
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// offlineError is a failure to reach the service, worth retrying: the
+// network or the service is down, or the service asks to slow down.
+type offlineError struct {
+	err error
+}
+
+func (e *offlineError) Error() string { return e.err.Error() }
+func (e *offlineError) Unwrap() error { return e.err }
+
+// post creates e in the service.
+func (t *timeTracker) post(e trackedEntry) error {
+	if t.cfg.Service == ServiceToggl {
+		body := map[string]any{
+			"created_with": "manta",
+			"description":  e.Description,
+			"start":        e.Start.UTC().Format(time.RFC3339),
+			"duration":     e.Duration,
+			"tags":         e.Tags,
+			"workspace_id": t.workspace,
+		}
+		if t.cfg.Project != "" {
+			body["project_id"], _ = strconv.Atoi(t.cfg.Project)
+		}
+		return t.call(http.MethodPost, togglAPI+"workspaces/"+strconv.Itoa(t.workspace)+"/time_entries", body, nil)
+	}
+
+	var tagIDs []string
+	for _, name := range e.Tags {
+		id, err := t.clockifyTag(name)
+		if err != nil {
+			return err
+		}
+		tagIDs = append(tagIDs, id)
+	}
+	body := map[string]any{
+		"description": e.Description,
+		"start":       e.Start.UTC().Format(time.RFC3339),
+		"end":         e.Start.Add(time.Duration(e.Duration) * time.Second).UTC().Format(time.RFC3339),
+		"tagIds":      tagIDs,
+	}
+	if t.cfg.Project != "" {
+		body["projectId"] = t.cfg.Project
+	}
+	return t.call(http.MethodPost, t.clockifyURL("time-entries"), body, nil)
+}
+
+// clockifyURL returns the URL of path within the workspace in Clockify.
+func (t *timeTracker) clockifyURL(path string) string {
+	return clockifyAPI + "workspaces/" + url.PathEscape(t.cfg.Workspace) + "/" + path
+}
+
+// clockifyTag returns the ID of the Clockify tag called name, creating
+// the tag when the workspace has none by that name. Clockify labels
+// entries by tag ID only.
+func (t *timeTracker) clockifyTag(name string) (string, error) {
+	if id, ok := t.tags[name]; ok {
+		return id, nil
+	}
+	type tag struct {
+		ID string `json:"id"`
+	}
+	var found []tag
+	query := url.Values{"name": {name}, "strict-name-search": {"true"}}
+	if err := t.call(http.MethodGet, t.clockifyURL("tags?"+query.Encode()), nil, &found); err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		var created tag
+		if err := t.call(http.MethodPost, t.clockifyURL("tags"), map[string]any{"name": name}, &created); err != nil {
+			return "", err
+		}
+		found = append(found, created)
+	}
+	t.tags[name] = found[0].ID
+	return found[0].ID, nil
+}
+
+// call sends body as JSON to the service and decodes the reply into
+// result, when given.
+func (t *timeTracker) call(method, url string, body, result any) error {
+	var data io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		data = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.cfg.Service == ServiceToggl {
+		req.SetBasicAuth(t.cfg.Token, "api_token")
+	} else {
+		req.Header.Set("X-Api-Key", t.cfg.Token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return &offlineError{fmt.Errorf("%s: %w", t.cfg.Service, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s: %s: %s", t.cfg.Service, resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return &offlineError{err}
+		}
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// trackQueuePath returns the location of the queue of entries waiting to
+// be sent.
+func trackQueuePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manta", "time-entries.json"), nil
+}
+
+// loadTrackQueue reads the queued entries. A missing queue is empty.
+func loadTrackQueue() ([]trackedEntry, error) {
+	path, err := trackQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var queue []trackedEntry
+	err = json.Unmarshal(data, &queue)
+	return queue, err
+}
+
+// saveTrackQueue writes the queued entries, removing the queue when there
+// are none.
+func saveTrackQueue(queue []trackedEntry) error {
+	path, err := trackQueuePath()
+	if err != nil {
+		return err
+	}
+	if len(queue) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(queue)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
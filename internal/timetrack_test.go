@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// trackServer stands in for the time tracking APIs, answering each
+// request with the status its handler returns.
+type trackServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]any
+	status   int
+	reject   string // description of an entry turned down whatever the status
+}
+
+// newTrackServer starts a server answering status and points both time
+// trackers and the queue of entries at places of the test's own.
+func newTrackServer(t *testing.T, status int) *trackServer {
+	t.Helper()
+	s := &trackServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		status := s.status
+		if s.reject != "" && body["description"] == s.reject {
+			status = http.StatusUnprocessableEntity
+		}
+		w.WriteHeader(status)
+		switch {
+		case status >= 300:
+			w.Write([]byte(`{"error":"nope"}`))
+		case strings.HasSuffix(r.URL.Path, "/tags") && r.Method == http.MethodGet:
+			w.Write([]byte(`[]`))
+		case strings.HasSuffix(r.URL.Path, "/tags"):
+			w.Write([]byte(`{"id":"tag-` + body["name"].(string) + `"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	api, clockify, backoff := togglAPI, clockifyAPI, trackBackoff
+	togglAPI, clockifyAPI, trackBackoff = s.URL+"/", s.URL+"/", time.Millisecond
+	t.Cleanup(func() { togglAPI, clockifyAPI, trackBackoff = api, clockify, backoff })
+	return s
+}
+
+// answer makes the server answer status from now on.
+func (s *trackServer) answer(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// sent returns the descriptions of the entries the server got, in order.
+func (s *trackServer) sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sent []string
+	for _, body := range s.bodies {
+		if d, ok := body["description"].(string); ok {
+			sent = append(sent, d)
+		}
+	}
+	return sent
+}
+
+// newTestTracker returns a tracker for service with a token and a
+// workspace.
+func newTestTracker(t *testing.T, service string) *timeTracker {
+	t.Helper()
+	tracker, err := newTimeTracker(TimeTrackingConfig{Service: service, Token: "secret", Workspace: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker
+}
+
+// testEntry returns a 25 minute entry called description.
+func testEntry(description string) trackedEntry {
+	return trackedEntry{
+		Description: description,
+		Start:       time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		Duration:    1500,
+		Tags:        []string{"pomodoro"},
+	}
+}
+
+// queued returns the descriptions of the entries waiting in the queue.
+func queued(t *testing.T) []string {
+	t.Helper()
+	queue, err := loadTrackQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var descriptions []string
+	for _, e := range queue {
+		descriptions = append(descriptions, e.Description)
+	}
+	return descriptions
+}
+
+func TestTrackQueuesOffline(t *testing.T) {
+	tests := []struct {
+		name string
+		down func(*trackServer)
+	}{
+		{"server error", func(s *trackServer) { s.answer(http.StatusBadGateway) }},
+		{"rate limit", func(s *trackServer) { s.answer(http.StatusTooManyRequests) }},
+		{"network", func(s *trackServer) { s.Close() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTrackServer(t, http.StatusOK)
+			tt.down(s)
+			tracker := newTestTracker(t, ServiceToggl)
+
+			err := tracker.send(testEntry("docs"))
+			if err == nil || !strings.Contains(err.Error(), "queued to send later") {
+				t.Fatalf("send() = %v, want it queued", err)
+			}
+			if got, want := queued(t), []string{"docs"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("queue = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTrackFlushesInOrder(t *testing.T) {
+	s := newTrackServer(t, http.StatusServiceUnavailable)
+	tracker := newTestTracker(t, ServiceToggl)
+	for _, d := range []string{"first", "second"} {
+		if err := tracker.send(testEntry(d)); err == nil {
+			t.Fatalf("send(%q) succeeded while the server is down", d)
+		}
+	}
+
+	s.answer(http.StatusOK)
+	if err := tracker.send(testEntry("third")); err != nil {
+		t.Fatal(err)
+	}
+	sent := s.sent()
+	if got, want := sent[len(sent)-3:], []string{"first", "second", "third"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q last, want %q", got, want)
+	}
+	if got := queued(t); len(got) > 0 {
+		t.Errorf("queue = %q, want it empty", got)
+	}
+}
+
+func TestTrackDropsRejected(t *testing.T) {
+	s := newTrackServer(t, http.StatusBadRequest)
+	tracker := newTestTracker(t, ServiceToggl)
+
+	err := tracker.send(testEntry("docs"))
+	if err == nil || strings.Contains(err.Error(), "queued") {
+		t.Fatalf("send() = %v, want a rejection that isn't queued", err)
+	}
+	if len(s.requests) != 1 {
+		t.Errorf("tried %d times, want a rejected entry sent once", len(s.requests))
+	}
+	if got := queued(t); len(got) > 0 {
+		t.Errorf("queue = %q, want it empty", got)
+	}
+
+	// A queued entry turned down on flush is dropped too, and the rest
+	// are still sent.
+	if err := saveTrackQueue([]trackedEntry{testEntry("rejected")}); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.status, s.reject = http.StatusOK, "rejected"
+	s.mu.Unlock()
+	if err := tracker.send(testEntry("accepted")); err == nil {
+		t.Error("send() hides the rejection of the queued entry")
+	}
+	if got := s.sent(); got[len(got)-1] != "accepted" {
+		t.Errorf("sent %q, want the new entry sent after the rejected one", got)
+	}
+	if got := queued(t); len(got) > 0 {
+		t.Errorf("queue = %q, want it empty", got)
+	}
+}
+
+func TestTrackRequests(t *testing.T) {
+	t.Run(ServiceToggl, func(t *testing.T) {
+		s := newTrackServer(t, http.StatusOK)
+		if err := newTestTracker(t, ServiceToggl).send(testEntry("docs")); err != nil {
+			t.Fatal(err)
+		}
+		if len(s.requests) != 1 {
+			t.Fatalf("got %d requests, want 1", len(s.requests))
+		}
+		r := s.requests[0]
+		if r.Method != http.MethodPost || r.URL.Path != "/workspaces/42/time_entries" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "secret" || pass != "api_token" {
+			t.Errorf("basic auth = %q, %q, want the token and api_token", user, pass)
+		}
+		want := map[string]any{
+			"created_with": "manta",
+			"description":  "docs",
+			"start":        "2026-10-16T09:00:00Z",
+			"duration":     1500.0,
+			"tags":         []any{"pomodoro"},
+			"workspace_id": 42.0,
+		}
+		if !reflect.DeepEqual(s.bodies[0], want) {
+			t.Errorf("body = %v, want %v", s.bodies[0], want)
+		}
+	})
+
+	t.Run(ServiceClockify, func(t *testing.T) {
+		s := newTrackServer(t, http.StatusOK)
+		if err := newTestTracker(t, ServiceClockify).send(testEntry("docs")); err != nil {
+			t.Fatal(err)
+		}
+		var calls []string
+		for _, r := range s.requests {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			if got := r.Header.Get("X-Api-Key"); got != "secret" {
+				t.Errorf("%s %s: X-Api-Key = %q, want the token", r.Method, r.URL.Path, got)
+			}
+		}
+		wantCalls := []string{
+			"GET /workspaces/42/tags",
+			"POST /workspaces/42/tags",
+			"POST /workspaces/42/time-entries",
+		}
+		if !reflect.DeepEqual(calls, wantCalls) {
+			t.Fatalf("requests = %q, want %q", calls, wantCalls)
+		}
+		want := map[string]any{
+			"description": "docs",
+			"start":       "2026-10-16T09:00:00Z",
+			"end":         "2026-10-16T09:25:00Z",
+			"tagIds":      []any{"tag-pomodoro"},
+		}
+		if !reflect.DeepEqual(s.bodies[2], want) {
+			t.Errorf("body = %v, want %v", s.bodies[2], want)
+		}
+	})
+}
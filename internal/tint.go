@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// TintConfig tints the terminal background during breaks, an ambient cue
+// that a break is on without a notification.
+type TintConfig struct {
+	// Break is the background color during breaks, e.g. "#1E2B24". Pick
+	// one close to the usual background for a subtle tint. Empty turns
+	// tinting off.
+	Break string `json:"break"`
+}
+
+// hexColor matches the #RRGGBB colors terminals accept in OSC 11.
+var hexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// tint sets the terminal background with OSC 11 while a break runs and
+// resets it with OSC 111 otherwise.
+type tint struct {
+	w      io.Writer
+	color  string
+	active bool
+}
+
+// newTint returns the break tint, or nil when no color is set or stdout
+// isn't a terminal.
+func newTint(cfg TintConfig) (listener, error) {
+	if cfg.Break == "" {
+		return nil, nil
+	}
+	if !hexColor.MatchString(cfg.Break) {
+		return nil, fmt.Errorf("tint: invalid color %q, want #RRGGBB", cfg.Break)
+	}
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return nil, nil
+	}
+	return &tint{w: os.Stdout, color: cfg.Break}, nil
+}
+
+// handle tints the background when a break starts and restores it when
+// the break is over or a work session starts. Pausing a break keeps the
+// tint.
+func (t *tint) handle(ev Event) error {
+	switch {
+	case ev.Name == EventStart:
+		return t.set(ev.Phase == RESTTIME)
+	case ev.Name == EventQuit, ev.Phase == RESTTIME && (ev.Name == EventEnd || ev.Name == EventSkip || ev.Name == EventReset):
+		return t.set(false)
+	}
+	return nil
+}
+
+func (t *tint) set(active bool) error {
+	if t.active == active {
+		return nil
+	}
+	seq := "\x1b]111\x07"
+	if active {
+		seq = "\x1b]11;" + t.color + "\x07"
+	}
+	if _, err := io.WriteString(t.w, seq); err != nil {
+		return fmt.Errorf("tint: %w", err)
+	}
+	t.active = active
+	return nil
+}
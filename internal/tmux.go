@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TmuxConfig renames the tmux window manta runs in to show the timer while
+// a session is active.
+type TmuxConfig struct {
+	RenameWindow bool `json:"rename_window"`
+
+	// Format is a status line template, see manta status.
+	Format string `json:"format"`
+}
+
+// tmux keeps the name of manta's window in sync with the timer. Renames
+// happen on a worker goroutine, so a slow tmux server never holds up the
+// UI, and only the latest name is applied.
+type tmux struct {
+	pane   string
+	format *template.Template
+	names  chan string
+	done   chan struct{}
+
+	// Owned by the worker.
+	last    string
+	renamed bool
+	name    string // window name before manta renamed it
+	auto    bool   // whether tmux was renaming the window automatically
+}
+
+// newTmux returns the tmux integration, or nil when it is disabled or
+// manta doesn't run inside tmux.
+func newTmux(cfg TmuxConfig) (*tmux, error) {
+	if !cfg.RenameWindow || os.Getenv("TMUX") == "" {
+		return nil, nil
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = compactStatusFormat
+	}
+	tmpl, err := template.New("tmux").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("tmux format: %w", err)
+	}
+
+	t := &tmux{
+		pane:   os.Getenv("TMUX_PANE"),
+		format: tmpl,
+		names:  make(chan string, 1),
+		done:   make(chan struct{}),
+	}
+	go t.run()
+	return t, nil
+}
+
+// update names the window after the session in s, or restores the
+// original name when no session is running.
+func (t *tmux) update(s State, now time.Time) {
+	if t == nil {
+		return
+	}
+	name, err := renderStatus(t.format, s, now)
+	if err != nil {
+		return
+	}
+
+	// Drop a name the worker hasn't picked up yet; it is out of date.
+	select {
+	case <-t.names:
+	default:
+	}
+	t.names <- name
+}
+
+// close stops the worker and restores the window name.
+func (t *tmux) close() {
+	if t == nil {
+		return
+	}
+	close(t.names)
+	<-t.done
+	t.apply("")
+}
+
+func (t *tmux) run() {
+	defer close(t.done)
+	for name := range t.names {
+		t.apply(name)
+	}
+}
+
+// apply renames the window, or restores it when name is empty. Failures
+// are ignored; the window just keeps its current name.
+func (t *tmux) apply(name string) {
+	if name == t.last {
+		return
+	}
+	t.last = name
+
+	if name == "" {
+		if !t.renamed {
+			return
+		}
+		t.renamed = false
+		_ = tmuxRun("rename-window", "-t", t.pane, t.name)
+		if t.auto {
+			_ = tmuxRun("set-window-option", "-t", t.pane, "automatic-rename", "on")
+		}
+		return
+	}
+
+	if !t.renamed {
+		out, err := exec.Command("tmux", "display-message", "-p", "-t", t.pane, "#{window_name}\t#{automatic-rename}").Output()
+		if err != nil {
+			return
+		}
+		name, auto, _ := strings.Cut(strings.TrimRight(string(out), "\n"), "\t")
+		t.name, t.auto, t.renamed = name, auto == "1", true
+	}
+	_ = tmuxRun("rename-window", "-t", t.pane, name)
+}
+
+// tmuxRun runs a tmux command.
+func tmuxRun(args ...string) error {
+	return exec.Command("tmux", args...).Run()
+}
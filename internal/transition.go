@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// transition is the screen announcing a change from work to a break or
+// back. The session behind it is already running.
+type transition struct {
+	phase string    // phase the session changed to
+	end   time.Time // when the screen goes away by itself
+}
+
+// announcePhase shows the transition screen when p changes the phase from
+// that of the session before it. Screen readers get the notification
+// alone.
+func (m *model) announcePhase(p Preset) {
+	if m.cfg.Transition <= 0 || m.cfg.Accessible || m.preset.Phase == "" || m.preset.Phase == p.Phase {
+		return
+	}
+	m.transition = &transition{
+		phase: p.Phase,
+		end:   m.now.Add(time.Duration(m.cfg.Transition)),
+	}
+}
+
+// transitionTick takes the transition screen away once its time is up.
+func (m *model) transitionTick() {
+	if m.transition != nil && !m.now.Before(m.transition.end) {
+		m.transition = nil
+	}
+}
+
+// transitionView fills the screen with the new phase and a row of dots
+// counting down to the session behind it.
+func (m model) transitionView() string {
+	title, body := tr("transition.work"), tr("transition.work_body", m.preset.Name)
+	if m.transition.phase == RESTTIME {
+		title, body = tr("transition.rest"), tr("transition.rest_body", m.preset.Name)
+	}
+	left := int((m.transition.end.Sub(m.now) + time.Second - 1) / time.Second)
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		m.theme.Title.Render(title),
+		"",
+		body,
+		"",
+		m.theme.Help.Render(strings.Repeat("• ", max(left, 0))),
+		m.theme.Help.Render(tr("transition.dismiss")),
+	)
+	if m.width == 0 || m.height == 0 {
+		return "\n" + content + "\n"
+	}
+	return place(m.width, m.height, content)
+}
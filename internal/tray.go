@@ -0,0 +1,122 @@
+//go:build !notray && (cgo || !darwin)
+
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+	"text/template"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// RunTray shows the running timer in the system tray or menu bar, with
+// items to pause, skip and quit it. It runs alongside the TUI, reading the
+// state file and driving the timer through the control socket, and
+// returns when the tray is closed.
+func RunTray() error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := useLanguage(cfg.Language); err != nil {
+		return err
+	}
+	systray.Run(trayReady, nil)
+	return nil
+}
+
+func trayReady() {
+	systray.SetIcon(trayIcon())
+	systray.SetTitle("manta")
+	systray.SetTooltip("manta")
+
+	pause := systray.AddMenuItem(tr("tray.pause"), tr("tray.pause_tip"))
+	skip := systray.AddMenuItem(tr("tray.skip"), tr("tray.skip_tip"))
+	systray.AddSeparator()
+	quit := systray.AddMenuItem(tr("tray.quit"), tr("tray.quit_tip"))
+	closeTray := systray.AddMenuItem(tr("tray.close"), tr("tray.close_tip"))
+
+	tmpl := template.Must(template.New("tray").Parse(compactStatusFormat))
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			s, _ := loadState()
+			line, _ := renderStatus(tmpl, s, time.Now())
+			if line == "" {
+				systray.SetTitle("manta")
+				systray.SetTooltip(tr("tray.idle"))
+				pause.Disable()
+				skip.Disable()
+				continue
+			}
+
+			systray.SetTitle(line)
+			systray.SetTooltip(s.Preset)
+			pause.SetTitle(tr("tray.pause"))
+			if s.Paused {
+				pause.SetTitle(tr("tray.resume"))
+			}
+			pause.Enable()
+			skip.Enable()
+		}
+	}()
+
+	go func() {
+		for {
+			var err error
+			select {
+			case <-pause.ClickedCh:
+				err = sendControl("pause")
+			case <-skip.ClickedCh:
+				err = sendControl("skip")
+			case <-quit.ClickedCh:
+				err = sendControl("quit")
+			case <-closeTray.ClickedCh:
+				systray.Quit()
+				return
+			}
+			if err != nil {
+				systray.SetTooltip(err.Error())
+			}
+		}
+	}()
+}
+
+// trayIcon draws a tomato-colored dot, wrapped in an ICO container on
+// Windows.
+func trayIcon() []byte {
+	const size = 32
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	tomato := color.NRGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}
+	for y := range size {
+		for x := range size {
+			dx, dy := x-size/2, y-size/2
+			if dx*dx+dy*dy <= (size/2-2)*(size/2-2) {
+				img.Set(x, y, tomato)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	if runtime.GOOS != "windows" {
+		return buf.Bytes()
+	}
+
+	// An ICO file with a single PNG image.
+	var ico bytes.Buffer
+	_ = binary.Write(&ico, binary.LittleEndian, []uint16{0, 1, 1})
+	ico.Write([]byte{size, size, 0, 0})
+	_ = binary.Write(&ico, binary.LittleEndian, []uint16{1, 32})
+	_ = binary.Write(&ico, binary.LittleEndian, []uint32{uint32(buf.Len()), 22})
+	ico.Write(buf.Bytes())
+	return ico.Bytes()
+}
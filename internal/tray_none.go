@@ -0,0 +1,11 @@
+//go:build notray || (darwin && !cgo)
+
+package internal
+
+import "errors"
+
+// RunTray fails in this build, which was made with the notray tag, or
+// without cgo on macOS, where the menu bar needs it.
+func RunTray() error {
+	return errors.New("manta was built without the tray (notray tag, or macOS without cgo)")
+}
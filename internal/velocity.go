@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// velocityWindow is how many days, today included, the pace of a task is
+// averaged over.
+const velocityWindow = 7
+
+// velocity is the progress of an estimated task.
+type velocity struct {
+	task     string
+	estimate int // work sessions the task is expected to take
+	done     int // work sessions finished on it so far
+	recent   int // of which in the last velocityWindow days
+}
+
+// velocityMsg carries the velocity of the current task read from the
+// history.
+type velocityMsg velocity
+
+// estimateSuffix is how an estimate is given along with a task, e.g.
+// "write report ~4".
+var estimateSuffix = regexp.MustCompile(`\s+~(\d+)$`)
+
+// parseTask splits the estimate, if any, off a task typed in the prompt.
+func parseTask(value string) (string, int) {
+	match := estimateSuffix.FindStringSubmatch(value)
+	if match == nil {
+		return value, 0
+	}
+	estimate, _ := strconv.Atoi(match[1])
+	return value[:len(value)-len(match[0])], estimate
+}
+
+// newVelocity counts the finished work sessions on task among sessions, as
+// of now, including those kept as its hash under key. Without an estimate
+// it goes by the latest one recorded for the task.
+func newVelocity(task string, key []byte, estimate int, sessions []Session, now time.Time) velocity {
+	v := velocity{task: task, estimate: estimate}
+	y, mo, d := now.Date()
+	since := time.Date(y, mo, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-velocityWindow)
+	hashed := task
+	if key != nil {
+		hashed = hashTask(key, task) // the task as kept with privacy.hash_tasks
+	}
+	for _, s := range sessions {
+		if s.Phase != WORKTIME || (s.Task != task && s.Task != hashed) {
+			continue
+		}
+		if estimate == 0 && s.Estimate > 0 {
+			v.estimate = s.Estimate
+		}
+		if s.Abandoned {
+			continue
+		}
+		v.done++
+		if !s.Start.Before(since) {
+			v.recent++
+		}
+	}
+	return v
+}
+
+// perDay returns the rolling number of work sessions a day.
+func (v velocity) perDay() float64 {
+	return float64(v.recent) / velocityWindow
+}
+
+// projected returns the day the task should be done at the current pace,
+// or false when there is no recent pace to go by.
+func (v velocity) projected(now time.Time) (time.Time, bool) {
+	left := v.estimate - v.done
+	if left <= 0 {
+		return now, true
+	}
+	if v.recent == 0 {
+		return time.Time{}, false
+	}
+	days := int(math.Ceil(float64(left)/v.perDay())) - 1
+	return now.AddDate(0, 0, days), true
+}
+
+// velocityCmd reads the velocity of task from the history in the
+// background. The estimate is the one given, else the one in the config,
+// else the latest recorded; a task without any has no velocity.
+func velocityCmd(estimates map[string]int, task string, estimate int, now time.Time) tea.Cmd {
+	if task == "" {
+		return nil
+	}
+	estimate = cmp.Or(estimate, estimates[task])
+	return func() tea.Msg {
+		sessions, err := querySessions(Query{Phase: WORKTIME})
+		if err != nil {
+			return newError(CodeHistoryRead, "read the history", err)
+		}
+		key, err := loadTaskKey(false)
+		if err != nil {
+			return newError(CodeHistoryRead, "read the task key", err)
+		}
+		v := newVelocity(task, key, estimate, sessions, now)
+		if v.estimate <= 0 {
+			return nil
+		}
+		return velocityMsg(v)
+	}
+}
+
+// estimate returns the estimate of the current task, 0 when it has none.
+func (m model) estimate() int {
+	if m.velocity == nil || m.velocity.task != m.task {
+		return 0
+	}
+	return m.velocity.estimate
+}
+
+// updateVelocity keeps the velocity read for the current task.
+func (m model) updateVelocity(msg velocityMsg) (model, tea.Cmd) {
+	if msg.task == m.task {
+		v := velocity(msg)
+		m.velocity = &v
+	}
+	return m, nil
+}
+
+// countVelocity counts a finished work session toward the current task's
+// velocity.
+func (m *model) countVelocity() {
+	if m.velocity != nil && m.velocity.task == m.task {
+		m.velocity.done++
+		m.velocity.recent++
+	}
+}
+
+// velocityView shows how far along the current task is and when it should
+// be done.
+func (m model) velocityView(pad string) string {
+	v := m.velocity
+	if v == nil || v.task != m.task || m.locked {
+		return ""
+	}
+	line := tr("velocity.line", v.done, v.estimate, v.perDay())
+	switch day, ok := v.projected(m.now); {
+	case v.done >= v.estimate:
+		line += tr("velocity.reached")
+	case ok:
+		line += tr("velocity.done_by", day.Format("Mon 2 Jan"))
+	default:
+		line += tr("velocity.idle")
+	}
+	return pad + m.theme.Help.Render(line) + "\n\n"
+}
+
+// estimateRow compares the work sessions finished on a task with its
+// estimate.
+type estimateRow struct {
+	Task     string
+	Estimate int       // the latest estimate recorded
+	Done     int       // finished work sessions
+	Last     time.Time // when the latest session on the task started
+}
+
+// ratio is how many times its estimate the task took so far.
+func (e estimateRow) ratio() float64 {
+	return float64(e.Done) / float64(e.Estimate)
+}
+
+// estimateRows sums up the estimated tasks among sessions, the most
+// recently worked on first.
+func estimateRows(sessions []Session) []estimateRow {
+	tasks := map[string]*estimateRow{}
+	for _, s := range sessions {
+		if s.Phase != WORKTIME || s.Task == "" {
+			continue
+		}
+		row, ok := tasks[s.Task]
+		if !ok {
+			row = &estimateRow{Task: s.Task}
+			tasks[s.Task] = row
+		}
+		if s.Estimate > 0 {
+			row.Estimate = s.Estimate
+		}
+		if !s.Abandoned {
+			row.Done++
+		}
+		row.Last = s.Start
+	}
+
+	var rows []estimateRow
+	for _, row := range tasks {
+		if row.Estimate > 0 {
+			rows = append(rows, *row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b estimateRow) int {
+		return cmp.Or(b.Last.Compare(a.Last), cmp.Compare(a.Task, b.Task))
+	})
+	return rows
+}
+
+// estimateAccuracy returns how many times their estimates the tasks
+// finished as of now took altogether, and how many there are. A task
+// counts as finished once it hasn't been worked on for velocityWindow
+// days.
+func estimateAccuracy(rows []estimateRow, now time.Time) (float64, int) {
+	done, estimated, n := 0, 0, 0
+	for _, row := range rows {
+		if now.Sub(row.Last) < velocityWindow*24*time.Hour {
+			continue
+		}
+		done += row.Done
+		estimated += row.Estimate
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return float64(done) / float64(estimated), n
+}
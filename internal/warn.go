@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// warnings returns how long before the end of a session of preset p the
+// configured warnings go out. Only work sessions are warned about.
+func warnings(cfg NotifyConfig, p Preset) []time.Duration {
+	if p.Phase != WORKTIME {
+		return nil
+	}
+	thresholds := make([]time.Duration, len(cfg.Warn))
+	for i, d := range cfg.Warn {
+		thresholds[i] = time.Duration(d)
+	}
+	return thresholds
+}
+
+// warningTitle is the notification sent when name has left to run.
+func warningTitle(name string, left time.Duration) string {
+	return tr("notify.warning", name, untilView(left))
+}
+
+// warn plays the warning sound and sends the notification that the
+// session ends soon.
+func (m model) warn() tea.Cmd {
+	return tea.Batch(
+		m.pending.track(soundCmd(m.player, SoundWarning)),
+		m.pending.track(notifyCmd(m.notifier, warningTitle(m.preset.Name, m.timer.Remaining(m.now)), tr("notify.wrap_up"))),
+	)
+}
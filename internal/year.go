@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// FormatHTML renders the year in focus as a standalone web page.
+// PrintYear also accepts FormatMarkdown.
+const FormatHTML = "html"
+
+// topTasks is how many tasks the year in focus lists.
+const topTasks = 5
+
+// yearInFocus is the highlights of a year of sessions.
+type yearInFocus struct {
+	Year        int
+	Total       reportRow
+	Streak      int       // longest run of days with a finished session
+	StreakStart time.Time // first day of that run
+	Month       reportRow // the month with the most focus
+	Best        *reportRow
+	Tasks       []reportRow
+}
+
+// PrintYear writes a "year in focus" summary of the given year: total
+// focus, the longest streak of days with a finished session, the busiest
+// month, the best day and the top tasks.
+func PrintYear(w io.Writer, format string, year int) error {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	r, err := newReport("", from, from.AddDate(1, 0, 0), nil)
+	if err != nil {
+		return err
+	}
+
+	y := yearInFocus{Year: year, Total: r.Total, Best: r.Best, Tasks: r.Tasks[:min(topTasks, len(r.Tasks))]}
+	streak, months := 0, map[time.Month]time.Duration{}
+	for _, d := range r.Days {
+		months[d.Day.Month()] += d.Focus
+		if d.Sessions == 0 {
+			streak = 0
+			continue
+		}
+		streak++
+		if streak > y.Streak {
+			y.Streak, y.StreakStart = streak, d.Day.AddDate(0, 0, 1-streak)
+		}
+	}
+	for m := time.January; m <= time.December; m++ {
+		if months[m] > y.Month.Focus {
+			y.Month = reportRow{Name: m.String(), Focus: months[m]}
+		}
+	}
+
+	switch format {
+	case FormatMarkdown:
+		return yearMarkdown(w, y)
+	case FormatHTML:
+		return yearPage.Execute(w, y)
+	default:
+		return fmt.Errorf("unknown format %q, want %s or %s", format, FormatMarkdown, FormatHTML)
+	}
+}
+
+// yearMarkdown writes the highlights as a markdown list.
+func yearMarkdown(w io.Writer, y yearInFocus) error {
+	fmt.Fprintf(w, "# %d in focus\n\n", y.Year)
+	fmt.Fprintf(w, "- **%d pomodoros**, %s of focus\n", y.Total.Sessions, hoursView(y.Total.Focus))
+	if y.Streak > 0 {
+		fmt.Fprintf(w, "- Longest streak: **%d days** (from %s)\n", y.Streak, y.StreakStart.Format("2 January"))
+	}
+	if y.Month.Focus > 0 {
+		fmt.Fprintf(w, "- Busiest month: **%s** (%s)\n", y.Month.Name, hoursView(y.Month.Focus))
+	}
+	if y.Best != nil {
+		fmt.Fprintf(w, "- Best day: %s (%s)\n", y.Best.Name, hoursView(y.Best.Focus))
+	}
+	if len(y.Tasks) == 0 {
+		return nil
+	}
+
+	fmt.Fprint(w, "\n## Top tasks\n\n")
+	for i, t := range y.Tasks {
+		if _, err := fmt.Fprintf(w, "%d. %s: %s (%d pomodoros)\n", i+1, t.Name, hoursView(t.Focus), t.Sessions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yearPage renders the highlights as a page to share.
+var yearPage = template.Must(template.New("year").Funcs(template.FuncMap{
+	"hours": hoursView,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Year}} in focus</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1e1e2e; color: #cdd6f4; max-width: 40rem; margin: 3rem auto; padding: 0 1rem; }
+h1 { color: #f38ba8; }
+.stats { display: grid; grid-template-columns: repeat(auto-fit, minmax(10rem, 1fr)); gap: 1rem; }
+.stat { background: #313244; border-radius: 0.5rem; padding: 1rem; }
+.stat b { display: block; font-size: 1.8rem; color: #fab387; }
+</style>
+</head>
+<body>
+<h1>🍅 {{.Year}} in focus</h1>
+<div class="stats">
+<div class="stat"><b>{{.Total.Sessions}}</b>pomodoros</div>
+<div class="stat"><b>{{hours .Total.Focus}}</b>of focus</div>
+{{- if .Streak}}
+<div class="stat"><b>{{.Streak}} days</b>longest streak, from {{.StreakStart.Format "2 January"}}</div>
+{{- end}}
+{{- if .Month.Focus}}
+<div class="stat"><b>{{.Month.Name}}</b>busiest month, {{hours .Month.Focus}}</div>
+{{- end}}
+{{- with .Best}}
+<div class="stat"><b>{{.Name}}</b>best day, {{hours .Focus}}</div>
+{{- end}}
+</div>
+{{- if .Tasks}}
+<h2>Top tasks</h2>
+<ol>
+{{- range .Tasks}}
+<li>{{.Name}}: {{hours .Focus}} ({{.Sessions}} pomodoros)</li>
+{{- end}}
+</ol>
+{{- end}}
+</body>
+</html>
+`))
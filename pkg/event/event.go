@@ -0,0 +1,96 @@
+// Package event hands what happens to sessions to the code that reacts to
+// it.
+//
+// An Event is what manta sends its hooks, webhooks and integrations, with
+// the same fields and JSON. A Bus delivers events to listeners one at a
+// time and in order, so a program embedding pkg/timer can fire its own on
+// top of Tick and have them handled the way manta handles its own.
+//
+// Like pkg/timer, the package follows the module's semantic version:
+// within a major version, exported names keep their meaning and are only
+// ever added to.
+package event
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Names of the events manta emits. Programs can emit events of other names
+// too, such as "warning".
+const (
+	Start  = "start"
+	End    = "end"
+	Pause  = "pause"
+	Resume = "resume"
+	Skip   = "skip"
+	Reset  = "reset"
+	Quit   = "quit"
+)
+
+// Event describes something that happened to a session. manta sends it as
+// the webhook payload and exposes it to commands as MANTA_* variables.
+type Event struct {
+	Name      string    `json:"event"`
+	Phase     string    `json:"phase"`
+	Preset    string    `json:"preset"`
+	Task      string    `json:"task"`
+	Remaining int       `json:"remaining"` // seconds left in the session
+	EndTime   time.Time `json:"end_time"`
+	Time      time.Time `json:"time"`
+}
+
+// Listener is called with every event emitted on the bus it subscribed
+// to. The error it returns is reported to the emitter.
+type Listener func(Event) error
+
+// Bus delivers events to its listeners. The zero Bus has none and is ready
+// to use.
+type Bus struct {
+	emitting sync.Mutex // held while an event is delivered
+
+	mu   sync.Mutex
+	subs []*subscription
+}
+
+// subscription is a listener on a bus, told apart from others of the same
+// function.
+type subscription struct {
+	listen Listener
+}
+
+// Subscribe adds l to the bus, to be called after the listeners already
+// there. Calling unsubscribe removes it again.
+func (b *Bus) Subscribe(l Listener) (unsubscribe func()) {
+	sub := &subscription{l}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subs = slices.DeleteFunc(b.subs, func(s *subscription) bool { return s == sub })
+	}
+}
+
+// Emit delivers ev to every listener in the order they subscribed and
+// returns their errors joined. A failing listener doesn't keep ev from
+// the others. Events are delivered one at a time: an Emit waits for the
+// one before it, so the listeners of a single emitter see its events in
+// order.
+func (b *Bus) Emit(ev Event) error {
+	b.emitting.Lock()
+	defer b.emitting.Unlock()
+
+	b.mu.Lock()
+	subs := slices.Clone(b.subs)
+	b.mu.Unlock()
+
+	var errs []error
+	for _, sub := range subs {
+		errs = append(errs, sub.listen(ev))
+	}
+	return errors.Join(errs...)
+}
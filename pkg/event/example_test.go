@@ -0,0 +1,33 @@
+package event_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ihorbryk/manta/pkg/event"
+)
+
+func Example() {
+	var bus event.Bus
+	bus.Subscribe(func(ev event.Event) error {
+		fmt.Println(ev.Phase, ev.Name, ev.Remaining)
+		return nil
+	})
+	unsubscribe := bus.Subscribe(func(ev event.Event) error {
+		return errors.New("lights are off")
+	})
+
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	err := bus.Emit(event.Event{Name: event.Start, Phase: "work", Remaining: 1500, Time: now})
+	fmt.Println(err)
+
+	unsubscribe()
+	err = bus.Emit(event.Event{Name: event.End, Phase: "work", Time: now.Add(25 * time.Minute)})
+	fmt.Println(err)
+	// Output:
+	// work start 1500
+	// lights are off
+	// work end 0
+	// <nil>
+}
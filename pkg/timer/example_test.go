@@ -0,0 +1,56 @@
+package timer_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ihorbryk/manta/pkg/timer"
+)
+
+func Example() {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	t := timer.Start(25*time.Minute, start)
+	t.WarnBefore(start, 5*time.Minute)
+
+	for _, now := range []time.Time{
+		start.Add(10 * time.Minute),
+		start.Add(20 * time.Minute),
+		start.Add(25 * time.Minute),
+	} {
+		switch t.Tick(now) {
+		case timer.Warning:
+			fmt.Println("warning,", t.Remaining(now), "left")
+		case timer.Completed:
+			fmt.Println("completed")
+		default:
+			fmt.Println(t.Remaining(now), "left")
+		}
+	}
+	// Output:
+	// 15m0s left
+	// warning, 5m0s left
+	// completed
+}
+
+func ExampleTimer_Pause() {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	t := timer.Start(25*time.Minute, start)
+
+	t.Pause(start.Add(10 * time.Minute))
+	fmt.Println(t.Remaining(start.Add(time.Hour)))
+
+	// Time spent paused is left out of the session.
+	t.Resume(start.Add(time.Hour))
+	fmt.Println(t.End(start.Add(time.Hour)).Format("15:04"))
+	// Output:
+	// 15m0s
+	// 10:15
+}
+
+func ExampleStartStopwatch() {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	t := timer.StartStopwatch(start)
+	fmt.Println(t.Elapsed(start.Add(42 * time.Minute)))
+	// Output:
+	// 42m0s
+}
@@ -0,0 +1,283 @@
+// Package timer counts sessions down on the wall clock.
+//
+// A Timer never reads the clock itself: every call takes the current time,
+// so callers decide where time comes from and tests can drive it step by
+// step. The remaining time is derived from when the timer started and how
+// long it has been paused, so late or missed ticks, or a machine that
+// slept, can't make it drift.
+//
+// The package is manta's own engine, published for other programs to
+// embed. Its API follows the module's semantic version: within a major
+// version, exported names keep their meaning and are only ever added to.
+// See the programs under examples/ for how to drive it.
+package timer
+
+import (
+	"slices"
+	"time"
+)
+
+// Event is something that happened to the timer during a Tick.
+type Event int
+
+const (
+	// NoEvent means nothing changed.
+	NoEvent Event = iota
+
+	// Completed means the countdown reached zero. It is reported once per
+	// session.
+	Completed
+
+	// Warning means the remaining time dropped to one of the thresholds
+	// set with WarnBefore. Each threshold is reported once.
+	Warning
+
+	// SnoozeOver means a snooze ran out and the next session is due. It
+	// is reported once, instead of Completed.
+	SnoozeOver
+)
+
+// Timer is the countdown of one session. The zero Timer is stopped.
+type Timer struct {
+	length    time.Duration
+	start     time.Time
+	resumed   time.Time // when the timer last started running
+	pausedAt  time.Time
+	pausedFor time.Duration // total time spent paused, excluding the current pause
+	paused    bool
+	overtime  bool
+	stopwatch bool // counts up with no end
+	snoozed   bool // holds off the next session instead of timing one
+	completed bool
+	warnings  []time.Duration // thresholds before the end, longest first
+	warned    int             // how many of the warnings have been reported
+}
+
+// State is a snapshot of a timer at a given time.
+type State struct {
+	Running   bool
+	Paused    bool
+	Overtime  bool
+	Snoozed   bool
+	Remaining time.Duration // negative once the countdown has passed zero
+	End       time.Time     // when the session ends if it keeps running
+}
+
+// Start returns a timer counting down length from now.
+func Start(length time.Duration, now time.Time) Timer {
+	return Timer{length: length, start: now, resumed: now}
+}
+
+// Restore returns a running timer of the given length that started at
+// start and ends at end, such as one read back from disk. Any time past
+// the length counts as paused.
+func Restore(start time.Time, length time.Duration, end time.Time) Timer {
+	return Timer{
+		length:    length,
+		start:     start,
+		resumed:   start,
+		pausedFor: end.Sub(start.Add(length)),
+	}
+}
+
+// StartStopwatch returns a timer counting up from now. It has no end, so
+// it never completes and its remaining time is minus the time elapsed.
+func StartStopwatch(now time.Time) Timer {
+	return Timer{start: now, resumed: now, stopwatch: true}
+}
+
+// RestoreStopwatch returns a running stopwatch that started at start, such
+// as one read back from disk. Any time between start and since, when it
+// would have started had it never paused, counts as paused.
+func RestoreStopwatch(start, since time.Time) Timer {
+	t := Restore(start, 0, since)
+	t.stopwatch = true
+	return t
+}
+
+// Snooze returns a timer counting down a snooze of length from now: time
+// taken after a session ends before the next one starts. A snooze can't be
+// paused, and Tick reports SnoozeOver when it runs out.
+func Snooze(length time.Duration, now time.Time) Timer {
+	t := Start(length, now)
+	t.snoozed = true
+	return t
+}
+
+// Running reports whether the timer has been started and not stopped.
+func (t Timer) Running() bool {
+	return t.length > 0 || t.stopwatch
+}
+
+// Stopwatch reports whether the timer counts up with no end.
+func (t Timer) Stopwatch() bool {
+	return t.stopwatch
+}
+
+// Snoozed reports whether the timer counts down a snooze.
+func (t Timer) Snoozed() bool {
+	return t.snoozed
+}
+
+// Paused reports whether the timer is paused.
+func (t Timer) Paused() bool {
+	return t.paused
+}
+
+// Overtime reports whether the timer counts up past its end.
+func (t Timer) Overtime() bool {
+	return t.overtime
+}
+
+// Length returns how long the session was planned to last.
+func (t Timer) Length() time.Duration {
+	return t.length
+}
+
+// Started returns when the timer started.
+func (t Timer) Started() time.Time {
+	return t.start
+}
+
+// PausedAt returns when the current pause started.
+func (t Timer) PausedAt() time.Time {
+	return t.pausedAt
+}
+
+// PausedFor returns the total time spent paused, excluding the current
+// pause.
+func (t Timer) PausedFor() time.Duration {
+	return t.pausedFor
+}
+
+// Pause freezes the countdown as of at, which may lie in the past, e.g.
+// when the user walked away some time ago. The pause never starts before
+// the timer last started running. It reports whether the timer was
+// running and is now paused.
+func (t *Timer) Pause(at time.Time) bool {
+	if !t.Running() || t.paused || t.overtime || t.snoozed {
+		return false
+	}
+	if at.Before(t.resumed) {
+		at = t.resumed
+	}
+	t.paused = true
+	t.pausedAt = at
+	return true
+}
+
+// Resume continues the countdown, leaving the time spent paused out of the
+// session.
+func (t *Timer) Resume(now time.Time) {
+	if !t.paused {
+		return
+	}
+	t.pausedFor += now.Sub(t.pausedAt)
+	t.paused = false
+	t.resumed = now
+}
+
+// Unpause continues the countdown as if it had never been paused, counting
+// the pause toward the session.
+func (t *Timer) Unpause(now time.Time) {
+	if !t.paused {
+		return
+	}
+	t.paused = false
+	t.resumed = now
+}
+
+// StartOvertime lets the timer count up past its end instead of stopping.
+func (t *Timer) StartOvertime() {
+	t.overtime = true
+	t.completed = true
+}
+
+// WarnBefore makes Tick report a Warning when the remaining time drops to
+// each of the given thresholds. Thresholds already reached at now are never
+// reported, so a restored timer doesn't warn about the past.
+func (t *Timer) WarnBefore(now time.Time, thresholds ...time.Duration) {
+	t.warnings = slices.Sorted(slices.Values(thresholds))
+	slices.Reverse(t.warnings)
+	t.warned = 0
+	for t.warned < len(t.warnings) && t.Remaining(now) <= t.warnings[t.warned] {
+		t.warned++
+	}
+}
+
+// Stop resets the timer to its stopped zero value.
+func (t *Timer) Stop() {
+	*t = Timer{}
+}
+
+// Tick advances the timer to now and reports whether the countdown
+// completed or passed a warning threshold. Completion wins over warnings
+// passed in the same tick, and several warnings passed at once are
+// reported as one.
+func (t *Timer) Tick(now time.Time) Event {
+	if !t.Running() || t.paused || t.completed || t.stopwatch {
+		return NoEvent
+	}
+	left := t.Remaining(now)
+	if left <= 0 {
+		t.completed = true
+		t.warned = len(t.warnings)
+		if t.snoozed {
+			return SnoozeOver
+		}
+		return Completed
+	}
+	event := NoEvent
+	for t.warned < len(t.warnings) && left <= t.warnings[t.warned] {
+		t.warned++
+		event = Warning
+	}
+	return event
+}
+
+// Remaining returns how much of the session is left at now. While paused
+// the clock is frozen at the moment the pause started.
+func (t Timer) Remaining(now time.Time) time.Duration {
+	if t.paused {
+		now = t.pausedAt
+	}
+	return t.start.Add(t.length + t.pausedFor).Sub(now)
+}
+
+// Elapsed returns how long the timer has been running at now, pauses
+// excluded.
+func (t Timer) Elapsed(now time.Time) time.Duration {
+	return t.length - t.Remaining(now)
+}
+
+// SecondsLeft returns the remaining time rounded up to whole seconds, so a
+// fresh timer shows its full length.
+func (t Timer) SecondsLeft(now time.Time) int {
+	return int((t.Remaining(now) + time.Second - 1) / time.Second)
+}
+
+// End returns when the session ends if it keeps running from now.
+func (t Timer) End(now time.Time) time.Time {
+	return now.Add(t.Remaining(now))
+}
+
+// Progress returns the share of the session done at now, from 0 to 1.
+func (t Timer) Progress(now time.Time) float64 {
+	if !t.Running() || t.stopwatch {
+		return 0
+	}
+	done := float64(t.length-t.Remaining(now)) / float64(t.length)
+	return min(max(done, 0), 1)
+}
+
+// State returns a snapshot of the timer at now.
+func (t Timer) State(now time.Time) State {
+	return State{
+		Running:   t.Running(),
+		Paused:    t.paused,
+		Overtime:  t.overtime,
+		Snoozed:   t.snoozed,
+		Remaining: t.Remaining(now),
+		End:       t.End(now),
+	}
+}
@@ -0,0 +1,333 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) time.Time {
+	return t0.Add(d)
+}
+
+func TestRemaining(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+
+	tests := []struct {
+		now  time.Duration
+		want time.Duration
+	}{
+		{0, 25 * time.Minute},
+		{10 * time.Minute, 15 * time.Minute},
+		{25 * time.Minute, 0},
+		{30 * time.Minute, -5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := tm.Remaining(at(tt.now)); got != tt.want {
+			t.Errorf("Remaining(+%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestSecondsLeftRoundsUp(t *testing.T) {
+	tm := Start(time.Minute, t0)
+
+	tests := []struct {
+		now  time.Duration
+		want int
+	}{
+		{0, 60},
+		{time.Millisecond, 60},
+		{time.Second, 59},
+		{59*time.Second + time.Millisecond, 1},
+		{time.Minute, 0},
+	}
+	for _, tt := range tests {
+		if got := tm.SecondsLeft(at(tt.now)); got != tt.want {
+			t.Errorf("SecondsLeft(+%v) = %d, want %d", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestPauseFreezesAndResumeExcludesPause(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+
+	if !tm.Pause(at(10 * time.Minute)) {
+		t.Fatal("Pause of a running timer returned false")
+	}
+	if tm.Pause(at(11 * time.Minute)) {
+		t.Error("Pause of a paused timer returned true")
+	}
+	if got := tm.Remaining(at(20 * time.Minute)); got != 15*time.Minute {
+		t.Errorf("Remaining while paused = %v, want 15m", got)
+	}
+
+	tm.Resume(at(20 * time.Minute))
+	if got := tm.PausedFor(); got != 10*time.Minute {
+		t.Errorf("PausedFor = %v, want 10m", got)
+	}
+	if got := tm.End(at(20 * time.Minute)); !got.Equal(at(35 * time.Minute)) {
+		t.Errorf("End = %v, want %v", got, at(35*time.Minute))
+	}
+}
+
+func TestUnpauseCountsPause(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+	tm.Pause(at(10 * time.Minute))
+	tm.Unpause(at(20 * time.Minute))
+
+	if tm.Paused() {
+		t.Fatal("still paused after Unpause")
+	}
+	if got := tm.Remaining(at(20 * time.Minute)); got != 5*time.Minute {
+		t.Errorf("Remaining = %v, want 5m", got)
+	}
+}
+
+func TestBackdatedPauseStopsAtLastResume(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+	tm.Pause(at(5 * time.Minute))
+	tm.Resume(at(10 * time.Minute))
+
+	// Idle since before the last resume; only the time after it is paused.
+	tm.Pause(at(2 * time.Minute))
+	if got := tm.PausedAt(); !got.Equal(at(10 * time.Minute)) {
+		t.Errorf("PausedAt = %v, want %v", got, at(10*time.Minute))
+	}
+	if got := tm.Remaining(at(time.Hour)); got != 20*time.Minute {
+		t.Errorf("Remaining = %v, want 20m", got)
+	}
+}
+
+func TestTickCompletesOnce(t *testing.T) {
+	tm := Start(time.Minute, t0)
+
+	if ev := tm.Tick(at(30 * time.Second)); ev != NoEvent {
+		t.Errorf("Tick before the end = %v, want NoEvent", ev)
+	}
+	if ev := tm.Tick(at(time.Minute)); ev != Completed {
+		t.Errorf("Tick at the end = %v, want Completed", ev)
+	}
+	if ev := tm.Tick(at(2 * time.Minute)); ev != NoEvent {
+		t.Errorf("Tick after completing = %v, want NoEvent", ev)
+	}
+}
+
+func TestTickLateStillCompletes(t *testing.T) {
+	// A machine that slept through the end sees the next tick late.
+	tm := Start(time.Minute, t0)
+	if ev := tm.Tick(at(time.Hour)); ev != Completed {
+		t.Errorf("late Tick = %v, want Completed", ev)
+	}
+	if got := tm.End(at(time.Hour)); !got.Equal(at(time.Minute)) {
+		t.Errorf("End = %v, want the planned end %v", got, at(time.Minute))
+	}
+}
+
+func TestTickWhilePaused(t *testing.T) {
+	tm := Start(time.Minute, t0)
+	tm.Pause(at(30 * time.Second))
+	if ev := tm.Tick(at(time.Hour)); ev != NoEvent {
+		t.Errorf("Tick while paused = %v, want NoEvent", ev)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+	tm.WarnBefore(t0, time.Minute, 5*time.Minute)
+
+	tests := []struct {
+		now  time.Duration
+		want Event
+	}{
+		{10 * time.Minute, NoEvent},
+		{20 * time.Minute, Warning},
+		{21 * time.Minute, NoEvent},
+		{24 * time.Minute, Warning},
+		{24*time.Minute + 30*time.Second, NoEvent},
+		{25 * time.Minute, Completed},
+	}
+	for _, tt := range tests {
+		if ev := tm.Tick(at(tt.now)); ev != tt.want {
+			t.Errorf("Tick(+%v) = %v, want %v", tt.now, ev, tt.want)
+		}
+	}
+}
+
+func TestWarningsPassedTogether(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+	tm.WarnBefore(t0, time.Minute, 5*time.Minute)
+
+	if ev := tm.Tick(at(24*time.Minute + 30*time.Second)); ev != Warning {
+		t.Errorf("Tick past both warnings = %v, want Warning", ev)
+	}
+	if ev := tm.Tick(at(24*time.Minute + 40*time.Second)); ev != NoEvent {
+		t.Errorf("Tick after the warnings = %v, want NoEvent", ev)
+	}
+
+	late := Start(25*time.Minute, t0)
+	late.WarnBefore(t0, time.Minute)
+	if ev := late.Tick(at(time.Hour)); ev != Completed {
+		t.Errorf("late Tick = %v, want Completed over the warning", ev)
+	}
+}
+
+func TestWarningsAlreadyPassed(t *testing.T) {
+	// A session restored with 3 minutes left only warns at 1 minute.
+	tm := Restore(t0, 25*time.Minute, at(25*time.Minute))
+	tm.WarnBefore(at(22*time.Minute), time.Minute, 5*time.Minute)
+
+	if ev := tm.Tick(at(22*time.Minute + time.Second)); ev != NoEvent {
+		t.Errorf("Tick after restoring = %v, want NoEvent", ev)
+	}
+	if ev := tm.Tick(at(24 * time.Minute)); ev != Warning {
+		t.Errorf("Tick at the last warning = %v, want Warning", ev)
+	}
+}
+
+func TestWarningsFollowPauses(t *testing.T) {
+	tm := Start(10*time.Minute, t0)
+	tm.WarnBefore(t0, 2*time.Minute)
+	tm.Pause(at(time.Minute))
+	tm.Resume(at(6 * time.Minute))
+
+	if ev := tm.Tick(at(12 * time.Minute)); ev != NoEvent {
+		t.Errorf("Tick before the shifted warning = %v, want NoEvent", ev)
+	}
+	if ev := tm.Tick(at(13 * time.Minute)); ev != Warning {
+		t.Errorf("Tick at the shifted warning = %v, want Warning", ev)
+	}
+}
+
+func TestOvertime(t *testing.T) {
+	tm := Start(time.Minute, t0)
+	tm.Tick(at(time.Minute))
+	tm.StartOvertime()
+
+	if ev := tm.Tick(at(2 * time.Minute)); ev != NoEvent {
+		t.Errorf("Tick in overtime = %v, want NoEvent", ev)
+	}
+	if tm.Pause(at(2 * time.Minute)) {
+		t.Error("Pause in overtime returned true")
+	}
+	if got := tm.Remaining(at(3 * time.Minute)); got != -2*time.Minute {
+		t.Errorf("Remaining = %v, want -2m", got)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	// Started at 9:00 for 25m, ends at 9:35 after 10m of pauses.
+	tm := Restore(t0, 25*time.Minute, at(35*time.Minute))
+
+	if got := tm.PausedFor(); got != 10*time.Minute {
+		t.Errorf("PausedFor = %v, want 10m", got)
+	}
+	if got := tm.Remaining(at(30 * time.Minute)); got != 5*time.Minute {
+		t.Errorf("Remaining = %v, want 5m", got)
+	}
+	if ev := tm.Tick(at(35 * time.Minute)); ev != Completed {
+		t.Errorf("Tick at the end = %v, want Completed", ev)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	tm := Start(10*time.Minute, t0)
+
+	tests := []struct {
+		now  time.Duration
+		want float64
+	}{
+		{0, 0},
+		{5 * time.Minute, 0.5},
+		{10 * time.Minute, 1},
+		{20 * time.Minute, 1},
+	}
+	for _, tt := range tests {
+		if got := tm.Progress(at(tt.now)); got != tt.want {
+			t.Errorf("Progress(+%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	tm := Start(time.Minute, t0)
+	tm.Pause(at(time.Second))
+	tm.Stop()
+
+	if tm.Running() || tm.Paused() {
+		t.Errorf("stopped timer: Running = %v, Paused = %v", tm.Running(), tm.Paused())
+	}
+	if ev := tm.Tick(at(time.Hour)); ev != NoEvent {
+		t.Errorf("Tick of a stopped timer = %v, want NoEvent", ev)
+	}
+}
+
+func TestState(t *testing.T) {
+	tm := Start(25*time.Minute, t0)
+	tm.Pause(at(5 * time.Minute))
+
+	want := State{
+		Running:   true,
+		Paused:    true,
+		Remaining: 20 * time.Minute,
+		End:       at(30 * time.Minute),
+	}
+	if got := tm.State(at(10 * time.Minute)); got != want {
+		t.Errorf("State = %+v, want %+v", got, want)
+	}
+}
+
+func TestStopwatch(t *testing.T) {
+	tm := StartStopwatch(t0)
+	tm.Pause(at(10 * time.Minute))
+	tm.Resume(at(15 * time.Minute))
+
+	if !tm.Running() || !tm.Stopwatch() {
+		t.Fatalf("Running = %v, Stopwatch = %v, want both", tm.Running(), tm.Stopwatch())
+	}
+	if ev := tm.Tick(at(3 * time.Hour)); ev != NoEvent {
+		t.Errorf("Tick = %v, want NoEvent", ev)
+	}
+	if got, want := tm.Elapsed(at(20*time.Minute)), 15*time.Minute; got != want {
+		t.Errorf("Elapsed = %v, want %v", got, want)
+	}
+	if got := tm.Progress(at(20 * time.Minute)); got != 0 {
+		t.Errorf("Progress = %v, want 0", got)
+	}
+}
+
+func TestRestoreStopwatch(t *testing.T) {
+	tm := StartStopwatch(t0)
+	tm.Pause(at(10 * time.Minute))
+	tm.Resume(at(15 * time.Minute))
+	now := at(20 * time.Minute)
+
+	restored := RestoreStopwatch(t0, tm.End(now))
+	if got, want := restored.Elapsed(now), tm.Elapsed(now); got != want {
+		t.Errorf("Elapsed = %v, want %v", got, want)
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	tm := Snooze(5*time.Minute, t0)
+
+	if !tm.Running() || !tm.Snoozed() {
+		t.Fatalf("Running = %v, Snoozed = %v, want both", tm.Running(), tm.Snoozed())
+	}
+	if tm.Pause(at(time.Minute)) {
+		t.Error("Pause of a snooze succeeded")
+	}
+	if ev := tm.Tick(at(4 * time.Minute)); ev != NoEvent {
+		t.Errorf("Tick before the end = %v, want NoEvent", ev)
+	}
+	if ev := tm.Tick(at(5 * time.Minute)); ev != SnoozeOver {
+		t.Errorf("Tick at the end = %v, want SnoozeOver", ev)
+	}
+	if ev := tm.Tick(at(6 * time.Minute)); ev != NoEvent {
+		t.Errorf("Tick after the end = %v, want NoEvent", ev)
+	}
+	if got, want := tm.Elapsed(at(3*time.Minute)), 3*time.Minute; got != want {
+		t.Errorf("Elapsed = %v, want %v", got, want)
+	}
+}